@@ -1,12 +1,29 @@
 package main
 
 import (
+	"context"
+	"os"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
 	"go-microservice-product-porto/internal/application/commands"
 	eventhandlers "go-microservice-product-porto/internal/application/event_handlers"
+	"go-microservice-product-porto/internal/application/events"
+	"go-microservice-product-porto/internal/application/outbox"
 	"go-microservice-product-porto/internal/application/queries"
+	"go-microservice-product-porto/internal/application/webhooks"
+	"go-microservice-product-porto/internal/application/workers"
+	"go-microservice-product-porto/internal/domain/location"
+	"go-microservice-product-porto/internal/domain/movement"
+	"go-microservice-product-porto/internal/domain/product"
 	"go-microservice-product-porto/internal/infrastructure/cache"
+	"go-microservice-product-porto/internal/infrastructure/messaging/nats"
+	"go-microservice-product-porto/internal/infrastructure/persistence/memory"
 	"go-microservice-product-porto/internal/infrastructure/persistence/mongodb"
+	"go-microservice-product-porto/internal/infrastructure/persistence/postgres"
 	"go-microservice-product-porto/internal/infrastructure/persistence/redis"
+	"go-microservice-product-porto/internal/infrastructure/persistence/sqlite"
 	"go-microservice-product-porto/internal/interfaces/api/http"
 
 	"go-microservice-product-porto/pkg/config"
@@ -25,59 +42,262 @@ func main() {
 		logger.Error().
 			Err(err).
 			Msg("Failed to load configuration")
+		os.Exit(1)
 	}
 
-	// Initialize MongoDB client
-	logger.Info().Msg("Initializing MongoDB client...")
-	mongoClient, err := mongodb.InitMongoDB(mongodb.MongoConfig{
-		Host:     cfg.MongoHost,
-		Port:     cfg.MongoPort,
-		User:     cfg.MongoUser,
-		Password: cfg.MongoPassword,
-		DBName:   cfg.MongoDBName,
-	})
+	// MongoDB holds everything but the products, which PRODUCT_BACKEND may
+	// put elsewhere. Without it only the product API runs, with its ledger
+	// and outbox kept in memory, which is enough for local development.
+	var mongoDB *mongo.Database
+	if cfg.UsesMongo() {
+		logger.Info().Msg("Initializing MongoDB client...")
+		mongoDB, err = mongodb.InitMongoDB(mongodb.ConfigFromApp(cfg))
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Msg("Failed to initialize MongoDB client")
+			os.Exit(1)
+		}
+
+		if cfg.MongoEnsureSchema {
+			logger.Info().Msg("Ensuring reservation, outbox and webhook delivery indexes...")
+			schemas := mongodb.NewSchemaManager(mongoDB)
+			for _, schema := range []mongodb.CollectionSchema{mongodb.ReservationSchema(), mongodb.OutboxSchema(), mongodb.WebhookDeliverySchema()} {
+				if _, err := schemas.Ensure(context.Background(), schema); err != nil {
+					logger.Error().
+						Str("collection", schema.Collection).
+						Err(err).
+						Msg("Failed to ensure schema")
+				}
+			}
+		}
+	} else {
+		logger.Warn().Msg("MongoDB is not configured, reservations, locations and webhooks are disabled and stock movements and outbox events are kept in memory")
+	}
+
+	// Initialize repositories
+	logger.Info().Msg("Initializing repositories...")
+	productRepo := newProductRepository(cfg, mongoDB)
+	var (
+		movementRepo        movement.Repository = memory.NewMovementRepository()
+		outboxStore         outbox.Store        = memory.NewOutboxStore()
+		transactor          outbox.Transactor   = outbox.NoTransaction{}
+		locationRepo        location.Repository
+		reservationRepo     *mongodb.ReservationRepository
+		webhookRepo         *mongodb.WebhookRepository
+		webhookDeliveryRepo *mongodb.WebhookDeliveryRepository
+	)
+	if mongoDB != nil {
+		movementRepo = mongodb.NewMovementRepository(mongoDB)
+		outboxStore = mongodb.NewOutboxStore(mongoDB)
+		// A MongoDB transaction retries its work on transient errors, which
+		// would apply product writes made elsewhere twice
+		if cfg.ProductBackend == "mongodb" {
+			transactor = mongodb.NewTransactor(context.Background(), mongoDB.Client())
+		}
+		locationRepo = mongodb.NewLocationRepository(mongoDB)
+		reservationRepo = mongodb.NewReservationRepository(mongoDB)
+		webhookRepo = mongodb.NewWebhookRepository(mongoDB)
+		webhookDeliveryRepo = mongodb.NewWebhookDeliveryRepository(mongoDB)
+	}
+
+	// Initialize cache
+	logger.Info().
+		Str("backend", cfg.CacheBackend).
+		Msg("Initializing cache...")
+	cacheCodec, err := cache.CodecByName(cfg.CacheCodec)
 	if err != nil {
 		logger.Error().
 			Err(err).
-			Msg("Failed to initialize MongoDB client")
+			Msg("Unknown cache codec, falling back to JSON")
+		cacheCodec = cache.JSON
+	}
+
+	localCache := cache.NewMemoryCache(cache.MemoryConfig{
+		MaxEntries: cfg.CacheLocalMaxEntries,
+		DefaultTTL: cfg.CacheDefaultTTL,
+		Codec:      cacheCodec,
+	})
+
+	// Redis only ever speeds things up: when it is missing or fails, the
+	// breaker turns its reads into misses and drops its writes
+	var (
+		cacheService cache.CacheService = localCache
+		cacheBreaker *cache.BreakerCache
+	)
+	if cfg.CacheBackend != "memory" {
+		redisConfig := redis.RedisConfig{
+			Host:       cfg.RedisHost,
+			Port:       cfg.RedisPort,
+			Password:   cfg.RedisPassword,
+			DefaultTTL: cfg.CacheDefaultTTL,
+			Codec:      cacheCodec,
+		}
+		if cfg.CacheBackend == "tiered" {
+			redisConfig.InvalidationChannel = cfg.CacheInvalidationChannel
+		}
+
+		redisCache, err := redis.NewRedisCache(redisConfig)
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Msg("Failed to initialize Redis cache, falling back to in-memory cache")
+		} else {
+			cacheBreaker = cache.NewBreakerCache(redisCache, cache.BreakerConfig{
+				FailureThreshold: cfg.CacheBreakerThreshold,
+				RetryInterval:    cfg.CacheBreakerRetryInterval,
+			})
+			if err := redisCache.Ping(context.Background()); err != nil {
+				cacheBreaker.Trip(err)
+			}
+
+			if cfg.CacheBackend == "tiered" {
+				tieredCache := cache.NewTieredCache(localCache, cacheBreaker, cfg.CacheLocalTTL)
+				if err := redisCache.Subscribe(context.Background(), tieredCache.Evict); err != nil {
+					logger.Error().
+						Err(err).
+						Msg("Failed to subscribe to cache invalidations, local copies will only expire")
+				}
+				cacheService = tieredCache
+			} else {
+				cacheService = cacheBreaker
+			}
+		}
 	}
+	cacheStats := cache.NewStatsCache(cacheService, queries.CacheKeyFamily)
+	cacheService = cacheStats
 
-	// Initialize repository
-	logger.Info().Msg("Initializing repository...")
-	productRepo := mongodb.NewProductRepository(mongoClient)
+	// Initialize event bus
+	logger.Info().Msg("Initializing event bus...")
+	var eventBus events.EventBus = events.NewMemoryBus()
+	if cfg.EventBus == "nats" {
+		natsBus, err := nats.NewEventBus(nats.NatsConfig{
+			URL:           cfg.NatsURL,
+			SubjectPrefix: cfg.NatsSubjectPrefix,
+		})
+		// The outbox would count events as published with no one outside
+		// this instance receiving them, so there is nothing to fall back to
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Msg("Failed to connect to NATS")
+			os.Exit(1)
+		}
+		eventBus = natsBus
+	}
+	defer eventBus.Close()
+	if _, ok := eventBus.(*events.MemoryBus); ok {
+		logger.Warn().Msg("Events stay on this instance, so with several instances each event stream client only sees the writes its instance handled; set EVENT_BUS=nats")
+	}
 
-	// Initialize Redis cache
-	logger.Info().Msg("Initializing Redis cache...")
-	cacheService, err := cache.NewCacheService(redis.RedisConfig{
-		Host:     cfg.RedisHost,
-		Port:     cfg.RedisPort,
-		Password: cfg.RedisPassword,
+	if _, err := eventBus.Subscribe(events.AllEvents, events.LogHandler); err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to subscribe event logger")
+	}
+
+	// Only product events are streamed to clients
+	productStream := events.NewStream(cfg.EventStreamReplaySize, func(eventType string) bool {
+		return strings.HasPrefix(eventType, "product.")
 	})
-	if err != nil {
+	if _, err := eventBus.Subscribe(events.AllEvents, productStream.Handle); err != nil {
 		logger.Error().
 			Err(err).
-			Msg("Failed to initialize Redis cache")
+			Msg("Failed to subscribe product event stream")
+	}
+
+	// Webhook deliveries are queued by the outbox relay rather than from the
+	// bus, so a failure to queue them is retried like a failed publish
+	var relayHandlers []events.Handler
+	if mongoDB != nil {
+		webhookDispatcher := webhooks.NewDispatcher(webhookRepo, webhookDeliveryRepo)
+		relayHandlers = append(relayHandlers, webhookDispatcher.Handle)
 	}
 
 	// Initialize event handler
 	logger.Info().Msg("Initializing event handler...")
-	eventHandler := eventhandlers.NewProductEventHandler(cacheService, productRepo)
+	cacheTTLs := cache.TTLPolicy{
+		Product: cfg.CacheProductTTL,
+		List:    cfg.CacheListTTL,
+		Search:  cfg.CacheSearchTTL,
+		Stale:   cfg.CacheStaleTTL,
+	}
+	eventHandler := eventhandlers.NewProductEventHandler(cacheService, cacheTTLs, productRepo)
 
 	// Initialize command handler
 	logger.Info().Msg("Initializing command handler...")
-	commandHandler := commands.NewProductCommandHandler(productRepo, eventHandler, cacheService)
+	commandHandler := commands.NewProductCommandHandler(productRepo, movementRepo, locationRepo, outboxStore, transactor, eventHandler, cacheService, cacheTTLs)
+	cacheCommandHandler := commands.NewCacheCommandHandler(cacheService)
 
 	// Initialize query handler
 	logger.Info().Msg("Initializing query handler...")
-	queryHandler := queries.NewProductQueryHandler(productRepo, cacheService)
+	if cfg.CursorSecret == "" {
+		logger.Warn().Msg("CURSOR_SECRET is not set, list cursors only work on the instance that issued them")
+	}
+	cursors := queries.NewCursorCodec([]byte(cfg.CursorSecret))
+	queryHandler := queries.NewProductQueryHandler(productRepo, movementRepo, cacheService, cacheTTLs, cursors)
+	cacheQueryHandler := queries.NewCacheQueryHandler(cacheService, cacheStats, cacheBreaker)
+
+	// Warm the cache in the background so startup isn't held up by it
+	warmCache := queries.WarmCacheQuery{
+		RecentProducts: cfg.CacheWarmRecentProducts,
+		Sorts:          parseListSorts(cfg.CacheWarmSorts),
+		Pages:          cfg.CacheWarmPages,
+		PageSize:       cfg.CacheWarmPageSize,
+	}
+	if cfg.CacheWarmOnStart {
+		go func() {
+			result, err := queryHandler.HandleWarmCache(context.Background(), warmCache)
+			if err != nil {
+				logger.Error().
+					Err(err).
+					Msg("Failed to warm cache")
+				return
+			}
+			logger.Info().
+				Int("recent_products", result.RecentProducts).
+				Int("list_pages", result.ListPages).
+				Msg("Cache warmed")
+		}()
+	}
+
+	// Start background workers
+	logger.Info().Msg("Starting outbox relay...")
+	relay := workers.NewOutboxRelay(outboxStore, events.NewBusPublisher(eventBus, cfg.EventSource, relayHandlers...), cfg.OutboxPollInterval, cfg.OutboxMaxAttempts)
+	go relay.Run(context.Background())
 
 	// Initialize HTTP handler
 	logger.Info().Msg("Initializing HTTP handler...")
 	productHandler := http.NewProductHandler(commandHandler, queryHandler)
+	var (
+		reservationHandler *http.ReservationHandler
+		locationHandler    *http.LocationHandler
+		webhookHandler     *http.WebhookHandler
+	)
+	if mongoDB != nil {
+		reservationCommandHandler := commands.NewReservationCommandHandler(reservationRepo, productRepo, movementRepo, outboxStore, transactor, eventHandler, cfg.ReservationTTL)
+		reservationHandler = http.NewReservationHandler(reservationCommandHandler, queries.NewReservationQueryHandler(reservationRepo))
+		locationHandler = http.NewLocationHandler(commands.NewLocationCommandHandler(locationRepo, productRepo), queries.NewLocationQueryHandler(locationRepo))
+		webhookHandler = http.NewWebhookHandler(commands.NewWebhookCommandHandler(webhookRepo, webhookDeliveryRepo), queries.NewWebhookQueryHandler(webhookRepo, webhookDeliveryRepo))
+
+		logger.Info().Msg("Starting reservation sweeper...")
+		sweeper := workers.NewReservationSweeper(reservationCommandHandler, cfg.ReservationSweepInterval)
+		go sweeper.Run(context.Background())
+
+		logger.Info().Msg("Starting webhook delivery workers...")
+		webhookWorker := workers.NewWebhookDeliveryWorker(webhookDeliveryRepo, webhookRepo, webhooks.NewHTTPSender(cfg.WebhookTimeout), cfg.WebhookPollInterval, cfg.WebhookWorkers, cfg.WebhookMaxAttempts)
+		go webhookWorker.Run(context.Background())
+	}
+	eventStreamHandler := http.NewEventStreamHandler(productStream, cfg.EventStreamHeartbeat)
+	cacheAdminHandler := http.NewCacheAdminHandler(cacheCommandHandler, cacheQueryHandler, queryHandler, warmCache)
+
+	if cfg.AdminToken == "" {
+		logger.Warn().Msg("ADMIN_TOKEN is not set, admin and webhook endpoints will refuse every request")
+	}
 
 	// Setup router
 	logger.Info().Msg("Setting up router...")
-	router := http.SetupRouter(productHandler)
+	router := http.SetupRouter(productHandler, reservationHandler, locationHandler, webhookHandler, eventStreamHandler, cacheAdminHandler, cfg.AdminToken)
 
 	// Start server
 	logger.Info().Msg("Starting server...")
@@ -85,5 +305,77 @@ func main() {
 		logger.Error().
 			Err(err).
 			Msg("Failed to start server")
+		os.Exit(1)
+	}
+}
+
+// newProductRepository opens the store PRODUCT_BACKEND names. Everything else
+// stays in MongoDB, when there is one, so outside it product writes and their
+// outbox events are no longer committed together.
+func newProductRepository(cfg *config.Config, mongoDB *mongo.Database) product.Repository {
+	logger.Info().
+		Str("backend", cfg.ProductBackend).
+		Msg("Initializing product repository...")
+
+	switch cfg.ProductBackend {
+	case "postgres":
+		logger.Warn().Msg("Products are stored outside MongoDB, outbox writes will not be atomic with them")
+
+		pool, err := postgres.InitPostgres(postgres.ConfigFromApp(cfg))
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Msg("Failed to initialize PostgreSQL client")
+			os.Exit(1)
+		}
+		if err := postgres.Migrate(context.Background(), pool); err != nil {
+			logger.Error().
+				Err(err).
+				Msg("Failed to migrate PostgreSQL schema")
+			os.Exit(1)
+		}
+		return postgres.NewProductRepository(pool)
+	case "sqlite":
+		logger.Warn().Msg("Products are stored outside MongoDB, outbox writes will not be atomic with them")
+
+		db, err := sqlite.InitSQLite(sqlite.SQLiteConfig{
+			Path:        cfg.SQLitePath,
+			BusyTimeout: cfg.SQLiteBusyTimeout,
+		})
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Msg("Failed to initialize SQLite database")
+			os.Exit(1)
+		}
+		return sqlite.NewProductRepository(db)
+	case "memory":
+		logger.Warn().Msg("Products are kept in memory and will be lost on restart")
+		return memory.NewProductRepository()
+	default:
+		if cfg.MongoEnsureSchema {
+			logger.Info().Msg("Ensuring products indexes and validator...")
+			schema := mongodb.ProductSchema(cfg.MongoProductsCollection)
+			if _, err := mongodb.NewSchemaManager(mongoDB).Ensure(context.Background(), schema); err != nil {
+				logger.Error().
+					Err(err).
+					Msg("Failed to ensure products schema")
+			}
+		}
+		return mongodb.NewProductRepository(mongoDB, cfg.MongoProductsCollection)
+	}
+}
+
+// parseListSorts reads list orderings written as "field:dir". The default
+// order always comes first, since it is what most requests ask for.
+func parseListSorts(specs []string) []queries.ListSort {
+	sorts := []queries.ListSort{{}}
+	for _, spec := range specs {
+		sortBy, sortDir, _ := strings.Cut(strings.TrimSpace(spec), ":")
+		if sortBy == "" {
+			continue
+		}
+		sorts = append(sorts, queries.ListSort{SortBy: sortBy, SortDir: sortDir})
 	}
+	return sorts
 }
@@ -0,0 +1,163 @@
+// Command migrate applies and reverts the versioned migrations of the
+// service's stores.
+//
+//	migrate [-store mongodb|postgres] up [version]
+//	migrate [-store mongodb|postgres] down <version>
+//	migrate [-store mongodb|postgres] status
+//
+// up applies every pending migration, or those up to version; down reverts
+// every migration above version, so "down 0" reverts them all.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"go-microservice-product-porto/internal/infrastructure/persistence/migrate"
+	"go-microservice-product-porto/internal/infrastructure/persistence/mongodb"
+	"go-microservice-product-porto/internal/infrastructure/persistence/postgres"
+
+	"go-microservice-product-porto/pkg/config"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+// runner is the part of a migrator the commands need, whatever store it
+// migrates.
+type runner interface {
+	Latest() int
+	Up(ctx context.Context, version int) ([]migrate.Record, error)
+	Down(ctx context.Context, version int) ([]migrate.Record, error)
+	Status(ctx context.Context) ([]migrate.Status, error)
+}
+
+var (
+	_ runner = (*migrate.Migrator[pgx.Tx])(nil)
+	_ runner = (*migrate.Migrator[*mongo.Database])(nil)
+)
+
+func main() {
+	logger.Init("info")
+
+	store := flag.String("store", "mongodb", "store to migrate: mongodb or postgres")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-store mongodb|postgres] up [version] | down <version> | status\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("Failed to load configuration")
+		os.Exit(1)
+	}
+
+	migrator, err := newMigrator(*store, cfg)
+	if err != nil {
+		logger.Error().
+			Str("store", *store).
+			Err(err).
+			Msg("Failed to initialize migrator")
+		os.Exit(1)
+	}
+
+	if err := run(context.Background(), migrator, args); err != nil {
+		logger.Error().
+			Str("store", *store).
+			Err(err).
+			Msg("Migration failed")
+		os.Exit(1)
+	}
+}
+
+func newMigrator(store string, cfg *config.Config) (runner, error) {
+	switch store {
+	case "mongodb":
+		db, err := mongodb.InitMongoDB(mongodb.ConfigFromApp(cfg))
+		if err != nil {
+			return nil, err
+		}
+		return mongodb.NewMigrator(db, cfg.MongoProductsCollection)
+	case "postgres":
+		pool, err := postgres.InitPostgres(postgres.ConfigFromApp(cfg))
+		if err != nil {
+			return nil, err
+		}
+		return postgres.NewMigrator(pool)
+	default:
+		return nil, fmt.Errorf("unknown store %q", store)
+	}
+}
+
+func run(ctx context.Context, migrator runner, args []string) error {
+	switch args[0] {
+	case "up":
+		version := migrator.Latest()
+		if len(args) > 1 {
+			v, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid version %q", args[1])
+			}
+			version = v
+		}
+		applied, err := migrator.Up(ctx, version)
+		printRecords("applied", applied)
+		return err
+	case "down":
+		if len(args) < 2 {
+			return fmt.Errorf("down needs the version to revert to")
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		reverted, err := migrator.Down(ctx, version)
+		printRecords("reverted", reverted)
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			if status.Unknown {
+				appliedAt += " (not in this build)"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+func printRecords(verb string, records []migrate.Record) {
+	if len(records) == 0 {
+		fmt.Printf("nothing %s\n", verb)
+		return
+	}
+	for _, record := range records {
+		fmt.Printf("%s %d %s\n", verb, record.Version, record.Name)
+	}
+}
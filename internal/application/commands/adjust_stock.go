@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"context"
+	"go-microservice-product-porto/internal/domain/movement"
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+// AdjustStockCommand changes stock by a signed delta instead of overwriting it,
+// so concurrent callers never need to read the current value first. With a
+// LocationID the delta applies to that location's stock.
+type AdjustStockCommand struct {
+	ProductID  string `json:"product_id"`
+	LocationID string `json:"location_id"`
+	Delta      int    `json:"delta"`
+	Reason     string `json:"reason"`
+}
+
+func (h *ProductCommandHandler) HandleAdjustStock(ctx context.Context, cmd AdjustStockCommand) (*product.Product, error) {
+	fields := map[string]string{}
+	if cmd.Delta == 0 {
+		fields["delta"] = "must not be zero"
+	}
+	if !product.StockAdjustmentReason(cmd.Reason).IsValid() {
+		fields["reason"] = "must be one of sale, return, restock, damage, correction"
+	}
+	if len(fields) > 0 {
+		return nil, errors.FieldError(product.ErrInvalidAdjustment, fields)
+	}
+
+	if cmd.LocationID != "" {
+		if err := h.requireActiveLocation(ctx, cmd.LocationID); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		prod  *product.Product
+		event *product.ProductStockUpdatedEvent
+	)
+	err := h.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		prod, err = h.repo.AdjustStock(ctx, cmd.ProductID, cmd.LocationID, cmd.Delta)
+		if err != nil {
+			return errors.StandardError(errors.EREPOSITORY, err)
+		}
+
+		m := movement.NewMovement(prod.ID.Hex(), movement.TypeAdjust, prod.Stock-cmd.Delta, prod.Stock)
+		if cmd.LocationID != "" {
+			atLocation := prod.StockLevels[cmd.LocationID]
+			m = movement.NewMovement(prod.ID.Hex(), movement.TypeAdjust, atLocation-cmd.Delta, atLocation)
+			m.LocationID = cmd.LocationID
+		}
+		m.Reason = cmd.Reason
+		if err := recordMovement(ctx, h.movements, m); err != nil {
+			return err
+		}
+
+		event = &product.ProductStockUpdatedEvent{
+			Product:  prod,
+			OldStock: prod.Stock - cmd.Delta,
+			NewStock: prod.Stock,
+			Reason:   cmd.Reason,
+		}
+		return emit(ctx, h.outbox, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	h.cacheProduct(ctx, prod)
+
+	h.eventHandler.HandleStockUpdated(ctx, event)
+
+	return prod, nil
+}
@@ -0,0 +1,13 @@
+package commands
+
+import "go-microservice-product-porto/internal/infrastructure/cache"
+
+type CacheCommandHandler struct {
+	cache cache.CacheService
+}
+
+func NewCacheCommandHandler(cacheService cache.CacheService) *CacheCommandHandler {
+	return &CacheCommandHandler{
+		cache: cacheService,
+	}
+}
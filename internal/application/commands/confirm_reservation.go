@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"context"
+	"time"
+
+	"go-microservice-product-porto/internal/domain/movement"
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/domain/reservation"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+type ConfirmReservationCommand struct {
+	ReservationID string `json:"reservation_id"`
+}
+
+func (h *ReservationCommandHandler) HandleConfirmReservation(ctx context.Context, cmd ConfirmReservationCommand) (*reservation.Reservation, error) {
+	var (
+		res   *reservation.Reservation
+		prod  *product.Product
+		event *reservation.ReservationConfirmedEvent
+	)
+	err := h.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Checked in the transaction so a hold that expires, and may be
+		// swept, before it commits can't be confirmed after all
+		current, err := h.reservations.FindByID(ctx, cmd.ReservationID)
+		if err != nil {
+			return errors.StandardError(errors.ENOTFOUND, err)
+		}
+		if current.Status != reservation.StatusActive {
+			return errors.StandardError(errors.ECONFLICT, reservation.ErrReservationNotActive)
+		}
+		if current.IsExpired(time.Now()) {
+			return errors.StandardError(errors.ECONFLICT, reservation.ErrReservationExpired)
+		}
+
+		res, prod, err = h.settle(ctx, cmd.ReservationID, reservation.StatusConfirmed, true)
+		if err != nil {
+			return err
+		}
+
+		after := prod.Stock
+		if res.LocationID != "" {
+			after = prod.StockLevels[res.LocationID]
+		}
+		confirmed := movement.NewMovement(prod.ID.Hex(), movement.TypeReservationConfirm, after+res.Quantity, after)
+		confirmed.LocationID = res.LocationID
+		confirmed.Reference = res.ID.Hex()
+		if err := recordMovement(ctx, h.movements, confirmed); err != nil {
+			return err
+		}
+
+		event = &reservation.ReservationConfirmedEvent{Reservation: res}
+		return emit(ctx, h.outbox, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	h.eventHandler.HandleReservationChanged(ctx, event, prod)
+
+	return res, nil
+}
@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"context"
+
+	"go-microservice-product-porto/internal/domain/location"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+type CreateLocationCommand struct {
+	Code    string `json:"code"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+func (h *LocationCommandHandler) HandleCreateLocation(ctx context.Context, cmd CreateLocationCommand) (*location.Location, error) {
+	loc := location.NewLocation(cmd.Code, cmd.Name, cmd.Address)
+
+	if !loc.IsValid() {
+		return nil, errors.FieldError(location.ErrInvalidLocation, loc.FieldErrors())
+	}
+
+	if err := h.locations.Create(ctx, loc); err != nil {
+		return nil, errors.StandardError(errors.EREPOSITORY, err)
+	}
+
+	return loc, nil
+}
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"context"
+	"go-microservice-product-porto/internal/domain/movement"
 	"go-microservice-product-porto/internal/domain/product"
 	"go-microservice-product-porto/pkg/errors"
 )
@@ -17,25 +18,30 @@ func (h *ProductCommandHandler) HandleCreateProduct(ctx context.Context, cmd Cre
 	newProduct := product.NewProduct(cmd.Name, cmd.Description, cmd.Price, cmd.Stock)
 
 	if !newProduct.IsValid() {
-		return errors.StandardError(errors.EVALIDATION, product.ErrInvalidProduct)
+		return errors.FieldError(product.ErrInvalidProduct, newProduct.FieldErrors())
 	}
 
-	if err := h.repo.Create(ctx, newProduct); err != nil {
-		if err == product.ErrProductAlreadyExists {
-			return errors.StandardError(errors.ECONFLICT, err)
+	event := &product.ProductCreatedEvent{Product: newProduct}
+	err := h.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := h.repo.Create(ctx, newProduct); err != nil {
+			if err == product.ErrProductAlreadyExists {
+				return errors.StandardError(errors.ECONFLICT, err)
+			}
+			return errors.StandardError(errors.EREPOSITORY, err)
 		}
-		return errors.StandardError(errors.EREPOSITORY, err)
-	}
 
-	// Update single product cache
-	if err := h.cache.Set(newProduct.ID.Hex(), newProduct); err != nil {
-		return errors.StandardError(errors.ECACHE, err)
+		if err := recordMovement(ctx, h.movements, movement.NewMovement(newProduct.ID.Hex(), movement.TypeCreate, 0, newProduct.Stock)); err != nil {
+			return err
+		}
+		return emit(ctx, h.outbox, event)
+	})
+	if err != nil {
+		return err
 	}
 
-	// Invalidate list cache to ensure fresh data
-	if err := h.cache.Delete("products:all"); err != nil {
-		return errors.StandardError(errors.ECACHE, err)
-	}
+	h.cacheProduct(ctx, newProduct)
+
+	h.eventHandler.HandleProductCreated(ctx, event)
 
 	return nil
 }
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"context"
+	"time"
+
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/domain/reservation"
+	"go-microservice-product-porto/pkg/errors"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+// maxReservationTTL caps how long a caller may hold stock.
+const maxReservationTTL = 24 * time.Hour
+
+type CreateReservationCommand struct {
+	ProductID string `json:"product_id"`
+	// LocationID is where the units are shipped from. Empty takes them from
+	// the product's unallocated stock.
+	LocationID string `json:"location_id"`
+	Quantity   int    `json:"quantity"`
+	OwnerRef   string `json:"owner_ref"`
+	TTLSeconds int    `json:"ttl_seconds"`
+}
+
+func (h *ReservationCommandHandler) HandleCreateReservation(ctx context.Context, cmd CreateReservationCommand) (*reservation.Reservation, error) {
+	ttl := h.defaultTTL
+	if cmd.TTLSeconds > 0 {
+		ttl = time.Duration(cmd.TTLSeconds) * time.Second
+	}
+
+	res := reservation.NewReservation(cmd.ProductID, cmd.LocationID, cmd.Quantity, cmd.OwnerRef, ttl)
+
+	fields := map[string]string{}
+	if res.ProductID == "" {
+		fields["product_id"] = "is required"
+	}
+	if res.Quantity <= 0 {
+		fields["quantity"] = "must be greater than 0"
+	}
+	if res.OwnerRef == "" {
+		fields["owner_ref"] = "is required"
+	}
+	if cmd.TTLSeconds < 0 || ttl > maxReservationTTL {
+		fields["ttl_seconds"] = "must be between 1 and 86400"
+	}
+	if len(fields) > 0 {
+		return nil, errors.FieldError(reservation.ErrInvalidReservation, fields)
+	}
+
+	var prod *product.Product
+	event := &reservation.ReservationCreatedEvent{Reservation: res}
+	err := h.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		prod, err = h.products.Reserve(ctx, res.ProductID, res.LocationID, res.Quantity)
+		if err != nil {
+			return errors.StandardError(errors.EREPOSITORY, err)
+		}
+
+		if err := h.reservations.Create(ctx, res); err != nil {
+			// Without transaction support the hold has to be given back by
+			// hand so the stock doesn't stay locked forever
+			if _, releaseErr := h.products.ReleaseReserved(ctx, res.ProductID, res.LocationID, res.Quantity, false); releaseErr != nil {
+				logger.Error().
+					Str("product_id", res.ProductID).
+					Int("quantity", res.Quantity).
+					Err(releaseErr).
+					Msg("failed to release hold after reservation create failed")
+			}
+			return errors.StandardError(errors.EREPOSITORY, err)
+		}
+
+		return emit(ctx, h.outbox, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	h.eventHandler.HandleReservationChanged(ctx, event, prod)
+
+	return res, nil
+}
@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"go-microservice-product-porto/internal/domain/webhook"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+// CreateWebhookCommand registers an endpoint. When Secret is empty one is
+// generated; either way it is only returned in the create response.
+type CreateWebhookCommand struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"secret"`
+}
+
+func (h *WebhookCommandHandler) HandleCreateWebhook(ctx context.Context, cmd CreateWebhookCommand) (*webhook.Subscription, error) {
+	secret := cmd.Secret
+	if secret == "" {
+		var err error
+		if secret, err = generateSecret(); err != nil {
+			return nil, errors.StandardError(errors.EINTERNAL, err)
+		}
+	}
+
+	sub := webhook.NewSubscription(cmd.URL, cmd.EventTypes, secret)
+
+	if !sub.IsValid() {
+		return nil, errors.FieldError(webhook.ErrInvalidSubscription, sub.FieldErrors())
+	}
+
+	if err := h.subscriptions.Create(ctx, sub); err != nil {
+		return nil, errors.StandardError(errors.EREPOSITORY, err)
+	}
+
+	return sub, nil
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
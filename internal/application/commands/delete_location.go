@@ -0,0 +1,33 @@
+package commands
+
+import (
+	"context"
+
+	"go-microservice-product-porto/internal/domain/location"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+type DeleteLocationCommand struct {
+	LocationID string `json:"location_id"`
+}
+
+func (h *LocationCommandHandler) HandleDeleteLocation(ctx context.Context, cmd DeleteLocationCommand) error {
+	if _, err := h.locations.FindByID(ctx, cmd.LocationID); err != nil {
+		return errors.StandardError(errors.ENOTFOUND, err)
+	}
+
+	// Stock must be transferred out first or it would silently vanish
+	inUse, err := h.products.HasStockAt(ctx, cmd.LocationID)
+	if err != nil {
+		return errors.StandardError(errors.EREPOSITORY, err)
+	}
+	if inUse {
+		return errors.StandardError(errors.ECONFLICT, location.ErrLocationInUse)
+	}
+
+	if err := h.locations.Delete(ctx, cmd.LocationID); err != nil {
+		return errors.StandardError(errors.EREPOSITORY, err)
+	}
+
+	return nil
+}
@@ -2,32 +2,42 @@ package commands
 
 import (
 	"context"
+	"go-microservice-product-porto/internal/domain/product"
 	"go-microservice-product-porto/pkg/errors"
 )
 
 type DeleteProductCommand struct {
-	ProductID string `json:"product_id"`
+	ProductID        string  `json:"product_id"`
+	ExpectedVersions []int64 `json:"-"`
 }
 
 func (h *ProductCommandHandler) HandleDeleteProduct(ctx context.Context, cmd DeleteProductCommand) error {
-	// Check if product exists before deletion
-	if _, err := h.repo.FindByID(ctx, cmd.ProductID); err != nil {
-		return errors.StandardError(errors.ENOTFOUND, err)
-	}
+	event := &product.ProductDeletedEvent{ProductID: cmd.ProductID}
+	err := retryOnConflict(cmd.ExpectedVersions, func() error {
+		return h.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			// Check if product exists before deletion
+			prod, err := h.repo.FindByID(ctx, cmd.ProductID)
+			if err != nil {
+				return errors.StandardError(errors.ENOTFOUND, err)
+			}
 
-	if err := h.repo.Delete(ctx, cmd.ProductID); err != nil {
-		return errors.StandardError(errors.EREPOSITORY, err)
-	}
+			if err := checkVersion(cmd.ExpectedVersions, prod); err != nil {
+				return err
+			}
 
-	// Invalidate product cache
-	if err := h.cache.Delete(cmd.ProductID); err != nil {
-		return errors.StandardError(errors.ECACHE, err)
+			if err := h.repo.Delete(ctx, prod); err != nil {
+				return writeError(cmd.ExpectedVersions, err)
+			}
+			return emit(ctx, h.outbox, event)
+		})
+	})
+	if err != nil {
+		return err
 	}
 
-	// Invalidate list cache
-	if err := h.cache.Delete("products:all"); err != nil {
-		return errors.StandardError(errors.ECACHE, err)
-	}
+	h.uncacheProduct(ctx, cmd.ProductID)
+
+	h.eventHandler.HandleProductDeleted(ctx, event)
 
 	return nil
 }
@@ -0,0 +1,20 @@
+package commands
+
+import (
+	"context"
+
+	"go-microservice-product-porto/pkg/errors"
+)
+
+// DeleteWebhookCommand removes a subscription. Deliveries still queued for it
+// are parked as dead by the delivery worker.
+type DeleteWebhookCommand struct {
+	SubscriptionID string `json:"subscription_id"`
+}
+
+func (h *WebhookCommandHandler) HandleDeleteWebhook(ctx context.Context, cmd DeleteWebhookCommand) error {
+	if err := h.subscriptions.Delete(ctx, cmd.SubscriptionID); err != nil {
+		return errors.StandardError(errors.EREPOSITORY, err)
+	}
+	return nil
+}
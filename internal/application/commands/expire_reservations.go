@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/domain/reservation"
+	"go-microservice-product-porto/pkg/errors"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+type ExpireReservationsCommand struct {
+	Now   time.Time `json:"now"`
+	Limit int       `json:"limit"`
+}
+
+// HandleExpireReservations releases holds whose expiry has passed and returns
+// how many were expired.
+func (h *ReservationCommandHandler) HandleExpireReservations(ctx context.Context, cmd ExpireReservationsCommand) (int, error) {
+	expired, err := h.reservations.FindExpired(ctx, cmd.Now, cmd.Limit)
+	if err != nil {
+		return 0, errors.StandardError(errors.EREPOSITORY, err)
+	}
+
+	count := 0
+	for _, candidate := range expired {
+		var (
+			prod  *product.Product
+			event *reservation.ReservationExpiredEvent
+		)
+		err := h.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			res, settled, err := h.settle(ctx, candidate.ID.Hex(), reservation.StatusExpired, false)
+			if err != nil {
+				return err
+			}
+
+			prod = settled
+			event = &reservation.ReservationExpiredEvent{Reservation: res}
+			return emit(ctx, h.outbox, event)
+		})
+		if err != nil {
+			// Confirmed or released while we were sweeping
+			if stderrors.Is(err, reservation.ErrReservationNotActive) {
+				continue
+			}
+			logger.Error().
+				Str("reservation_id", candidate.ID.Hex()).
+				Err(err).
+				Msg("failed to expire reservation")
+			continue
+		}
+
+		h.eventHandler.HandleReservationChanged(ctx, event, prod)
+		count++
+	}
+
+	return count, nil
+}
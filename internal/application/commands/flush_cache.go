@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+
+	"go-microservice-product-porto/pkg/errors"
+)
+
+var errInvalidFlush = stderrors.New("invalid cache flush")
+
+// globEscaper escapes the characters Redis treats as glob syntax in patterns.
+var globEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+// FlushCachePrefixCommand removes every cached entry whose key starts with
+// Prefix.
+type FlushCachePrefixCommand struct {
+	Prefix string `json:"prefix"`
+}
+
+func (h *CacheCommandHandler) HandleFlushCachePrefix(ctx context.Context, cmd FlushCachePrefixCommand) (int64, error) {
+	if cmd.Prefix == "" {
+		// An empty prefix would flush the whole cache
+		return 0, errors.FieldError(errInvalidFlush, map[string]string{"prefix": "is required"})
+	}
+
+	deleted, err := h.cache.DeletePattern(ctx, globEscaper.Replace(cmd.Prefix)+"*")
+	if err != nil {
+		return deleted, errors.StandardError(errors.ECACHE, err)
+	}
+	return deleted, nil
+}
+
+// FlushCacheTagsCommand removes every cached entry registered under any of
+// Tags.
+type FlushCacheTagsCommand struct {
+	Tags []string `json:"tags"`
+}
+
+func (h *CacheCommandHandler) HandleFlushCacheTags(ctx context.Context, cmd FlushCacheTagsCommand) (int64, error) {
+	if len(cmd.Tags) == 0 {
+		return 0, errors.FieldError(errInvalidFlush, map[string]string{"tags": "must not be empty"})
+	}
+
+	removed, err := h.cache.InvalidateTags(ctx, cmd.Tags...)
+	if err != nil {
+		return removed, errors.StandardError(errors.ECACHE, err)
+	}
+	return removed, nil
+}
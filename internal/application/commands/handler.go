@@ -1,21 +1,155 @@
 package commands
 
 import (
+	"context"
+	stderrors "errors"
+
 	eventhandlers "go-microservice-product-porto/internal/application/event_handlers"
+	"go-microservice-product-porto/internal/application/outbox"
+	"go-microservice-product-porto/internal/domain/location"
+	"go-microservice-product-porto/internal/domain/movement"
 	"go-microservice-product-porto/internal/domain/product"
 	"go-microservice-product-porto/internal/infrastructure/cache"
+	"go-microservice-product-porto/pkg/common"
+	"go-microservice-product-porto/pkg/errors"
+	"go-microservice-product-porto/pkg/logger"
 )
 
+// maxConflictRetries bounds how often an unconditional read-modify-write is
+// retried after losing a race with a concurrent writer.
+const maxConflictRetries = 3
+
 type ProductCommandHandler struct {
 	repo         product.Repository
+	movements    movement.Repository
+	locations    location.Repository
+	outbox       outbox.Store
+	tx           outbox.Transactor
 	eventHandler *eventhandlers.ProductEventHandler
 	cache        cache.CacheService
+	ttls         cache.TTLPolicy
 }
 
-func NewProductCommandHandler(repo product.Repository, eventHandler *eventhandlers.ProductEventHandler, cache cache.CacheService) *ProductCommandHandler {
+func NewProductCommandHandler(repo product.Repository, movements movement.Repository, locations location.Repository, outboxStore outbox.Store, tx outbox.Transactor, eventHandler *eventhandlers.ProductEventHandler, cache cache.CacheService, ttls cache.TTLPolicy) *ProductCommandHandler {
 	return &ProductCommandHandler{
 		repo:         repo,
+		movements:    movements,
+		locations:    locations,
+		outbox:       outboxStore,
+		tx:           tx,
 		eventHandler: eventHandler,
 		cache:        cache,
+		ttls:         ttls,
+	}
+}
+
+// cacheProduct refreshes the cached copy of a product that was just written.
+// The write already succeeded, so a cache failure is only logged: the cache
+// drops the stale entry once it is back, and the event handler retries the
+// refresh.
+func (h *ProductCommandHandler) cacheProduct(ctx context.Context, prod *product.Product) {
+	if err := h.cache.Set(ctx, prod.ID.Hex(), prod, cache.WithTTL(h.ttls.Product)); err != nil {
+		logger.Warn().
+			Str("product_id", prod.ID.Hex()).
+			Err(err).
+			Msg("product written but not cached")
+	}
+}
+
+// uncacheProduct drops the cached copy of a deleted product, logging rather
+// than failing like cacheProduct.
+func (h *ProductCommandHandler) uncacheProduct(ctx context.Context, id string) {
+	if err := h.cache.Delete(ctx, id); err != nil {
+		logger.Warn().
+			Str("product_id", id).
+			Err(err).
+			Msg("product deleted but still cached")
+	}
+}
+
+// requireActiveLocation fails unless the location exists and accepts stock.
+// Without a location repository no location exists.
+func (h *ProductCommandHandler) requireActiveLocation(ctx context.Context, locationID string) error {
+	if h.locations == nil {
+		return errors.StandardError(errors.ENOTFOUND, location.ErrLocationNotFound)
+	}
+	loc, err := h.locations.FindByID(ctx, locationID)
+	if err != nil {
+		return errors.StandardError(errors.ENOTFOUND, err)
+	}
+	if !loc.Active {
+		return errors.StandardError(errors.EVALIDATION, location.ErrLocationInactive)
+	}
+	return nil
+}
+
+// retryOnConflict runs a read-modify-write and repeats it when another writer
+// got there first. Callers that pinned versions through If-Match are never
+// retried: their precondition simply failed.
+func retryOnConflict(expectedVersions []int64, fn func() error) error {
+	var err error
+	for attempt := 0; attempt < maxConflictRetries; attempt++ {
+		err = fn()
+		if expectedVersions != nil || !stderrors.Is(err, product.ErrVersionConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+// checkVersion fails with EPRECONDITION when the caller pinned versions and
+// the one that was just loaded is not among them. Nil pins nothing.
+func checkVersion(expectedVersions []int64, prod *product.Product) error {
+	if expectedVersions == nil {
+		return nil
+	}
+	for _, version := range expectedVersions {
+		if version == prod.Version {
+			return nil
+		}
+	}
+	return errors.StandardError(errors.EPRECONDITION, product.ErrVersionConflict)
+}
+
+// writeError wraps a failed versioned write. A lost race surfaces as a failed
+// precondition when the caller pinned versions, and as a conflict otherwise.
+func writeError(expectedVersions []int64, err error) error {
+	if stderrors.Is(err, product.ErrVersionConflict) {
+		if expectedVersions != nil {
+			return errors.StandardError(errors.EPRECONDITION, product.ErrVersionConflict)
+		}
+		return err
+	}
+	return errors.StandardError(errors.EREPOSITORY, err)
+}
+
+// recordMovement appends stock mutations to the ledger, attributing them to
+// the actor in ctx. It runs inside the mutation's transaction so the ledger
+// and the stock level can never disagree.
+func recordMovement(ctx context.Context, movements movement.Repository, entries ...*movement.Movement) error {
+	for _, m := range entries {
+		m.Actor = common.ActorFromContext(ctx)
+		if err := movements.Append(ctx, m); err != nil {
+			return errors.StandardError(errors.EREPOSITORY, err)
+		}
+	}
+	return nil
+}
+
+// emit stages events in the outbox. It runs inside the mutation's transaction
+// so an event is published if and only if the change was committed.
+func emit(ctx context.Context, store outbox.Store, events ...product.Event) error {
+	messages := make([]*outbox.Message, 0, len(events))
+	for _, event := range events {
+		message, err := outbox.NewMessage(event)
+		if err != nil {
+			return errors.StandardError(errors.EINTERNAL, err)
+		}
+		messages = append(messages, message)
+	}
+
+	if err := store.Add(ctx, messages...); err != nil {
+		return errors.StandardError(errors.EREPOSITORY, err)
 	}
+	return nil
 }
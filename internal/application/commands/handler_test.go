@@ -0,0 +1,384 @@
+package commands
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	eventhandlers "go-microservice-product-porto/internal/application/event_handlers"
+	"go-microservice-product-porto/internal/application/outbox"
+	"go-microservice-product-porto/internal/domain/location"
+	"go-microservice-product-porto/internal/domain/movement"
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/infrastructure/cache"
+	"go-microservice-product-porto/internal/infrastructure/persistence/memory"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+type txKey struct{}
+
+// recordingTx marks the context it hands to fn, so the fakes below can tell
+// whether a write joined the transaction.
+type recordingTx struct{}
+
+func (recordingTx) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(context.WithValue(ctx, txKey{}, true))
+}
+
+func inTx(ctx context.Context) bool {
+	joined, _ := ctx.Value(txKey{}).(bool)
+	return joined
+}
+
+// txMovements counts ledger entries appended outside the transaction.
+type txMovements struct {
+	*memory.MovementRepository
+	outside int
+}
+
+func (m *txMovements) Append(ctx context.Context, entry *movement.Movement) error {
+	if !inTx(ctx) {
+		m.outside++
+	}
+	return m.MovementRepository.Append(ctx, entry)
+}
+
+// txOutbox records staged messages, counts those staged outside the
+// transaction and fails every Add while err is set.
+type txOutbox struct {
+	*memory.OutboxStore
+	added   []*outbox.Message
+	outside int
+	err     error
+}
+
+func (o *txOutbox) Add(ctx context.Context, messages ...*outbox.Message) error {
+	if o.err != nil {
+		return o.err
+	}
+	if !inTx(ctx) {
+		o.outside += len(messages)
+	}
+	o.added = append(o.added, messages...)
+	return o.OutboxStore.Add(ctx, messages...)
+}
+
+func (o *txOutbox) eventTypes() []string {
+	types := make([]string, len(o.added))
+	for i, message := range o.added {
+		types[i] = message.EventType
+	}
+	return types
+}
+
+// racingRepo lets a concurrent writer update the product just before each of
+// the next races calls to Update, so those calls lose on the version check.
+type racingRepo struct {
+	*memory.ProductRepository
+	races   int
+	updates int
+}
+
+func (r *racingRepo) Update(ctx context.Context, prod *product.Product) error {
+	r.updates++
+	if r.races > 0 {
+		r.races--
+		rival, err := r.ProductRepository.FindByID(ctx, prod.ID.Hex())
+		if err != nil {
+			return err
+		}
+		rival.Description = "changed concurrently"
+		if err := r.ProductRepository.Update(ctx, rival); err != nil {
+			return err
+		}
+	}
+	return r.ProductRepository.Update(ctx, prod)
+}
+
+// activeLocations finds every location, active.
+type activeLocations struct {
+	location.Repository
+}
+
+func (activeLocations) FindByID(_ context.Context, id string) (*location.Location, error) {
+	loc := location.NewLocation(id, id, "")
+	return loc, nil
+}
+
+func newTestProductHandler(repo product.Repository, movements movement.Repository, store outbox.Store, tx outbox.Transactor) *ProductCommandHandler {
+	c := cache.NewMemoryCache(cache.MemoryConfig{})
+	ttls := cache.TTLPolicy{Product: time.Hour, List: time.Minute, Search: time.Minute}
+	return NewProductCommandHandler(repo, movements, activeLocations{}, store, tx,
+		eventhandlers.NewProductEventHandler(c, ttls, repo), c, ttls)
+}
+
+func createProduct(t *testing.T, repo product.Repository, stock int) *product.Product {
+	t.Helper()
+	prod := product.NewProduct("Widget", "", 1, stock)
+	if err := repo.Create(context.Background(), prod); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return prod
+}
+
+func errorCode(err error) string {
+	var appErr *errors.AppError
+	if !stderrors.As(err, &appErr) {
+		return ""
+	}
+	return appErr.Code
+}
+
+func TestUpdateProductChecksVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected func(current int64) []int64
+		wantCode string
+	}{
+		{
+			name:     "unconditional",
+			expected: func(int64) []int64 { return nil },
+		},
+		{
+			name:     "current version",
+			expected: func(current int64) []int64 { return []int64{current} },
+		},
+		{
+			name:     "current version among others",
+			expected: func(current int64) []int64 { return []int64{current - 1, current, current + 1} },
+		},
+		{
+			name:     "stale version",
+			expected: func(current int64) []int64 { return []int64{current - 1} },
+			wantCode: errors.EPRECONDITION,
+		},
+		{
+			name:     "no version can match",
+			expected: func(int64) []int64 { return []int64{} },
+			wantCode: errors.EPRECONDITION,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := memory.NewProductRepository()
+			prod := createProduct(t, repo, 5)
+			store := &txOutbox{OutboxStore: memory.NewOutboxStore()}
+			h := newTestProductHandler(repo, memory.NewMovementRepository(), store, recordingTx{})
+
+			updated, err := h.HandleUpdateProduct(ctx, UpdateProductCommand{
+				ProductID:        prod.ID.Hex(),
+				Name:             "Gadget",
+				Price:            2,
+				Stock:            5,
+				ExpectedVersions: tt.expected(prod.Version),
+			})
+
+			if tt.wantCode != "" {
+				if errorCode(err) != tt.wantCode || !stderrors.Is(err, product.ErrVersionConflict) {
+					t.Fatalf("error = %v, want %s version conflict", err, tt.wantCode)
+				}
+				if len(store.added) != 0 {
+					t.Errorf("staged %v for a rejected update, want nothing", store.eventTypes())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("HandleUpdateProduct: %v", err)
+			}
+			if updated.Version != prod.Version+1 || updated.Name != "Gadget" {
+				t.Errorf("updated to %q at version %d, want Gadget at %d", updated.Name, updated.Version, prod.Version+1)
+			}
+		})
+	}
+}
+
+func TestUpdateProductRetriesOnConflict(t *testing.T) {
+	tests := []struct {
+		name        string
+		races       int
+		pinned      bool
+		wantUpdates int
+		wantCode    string
+	}{
+		{name: "retried until it wins", races: maxConflictRetries - 1, wantUpdates: maxConflictRetries},
+		{name: "gives up after maxConflictRetries", races: maxConflictRetries, wantUpdates: maxConflictRetries, wantCode: errors.ECONFLICT},
+		{name: "pinned version is not retried", races: 1, pinned: true, wantUpdates: 1, wantCode: errors.EPRECONDITION},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := &racingRepo{ProductRepository: memory.NewProductRepository(), races: tt.races}
+			prod := createProduct(t, repo.ProductRepository, 5)
+			store := &txOutbox{OutboxStore: memory.NewOutboxStore()}
+			h := newTestProductHandler(repo, memory.NewMovementRepository(), store, recordingTx{})
+
+			cmd := UpdateProductCommand{ProductID: prod.ID.Hex(), Name: "Gadget", Price: 2, Stock: 5}
+			if tt.pinned {
+				cmd.ExpectedVersions = []int64{prod.Version}
+			}
+			updated, err := h.HandleUpdateProduct(ctx, cmd)
+
+			if repo.updates != tt.wantUpdates {
+				t.Errorf("%d update attempts, want %d", repo.updates, tt.wantUpdates)
+			}
+			if tt.wantCode != "" {
+				if errorCode(err) != tt.wantCode || !stderrors.Is(err, product.ErrVersionConflict) {
+					t.Fatalf("error = %v, want %s version conflict", err, tt.wantCode)
+				}
+				if len(store.added) != 0 {
+					t.Errorf("staged %v for a failed update, want nothing", store.eventTypes())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("HandleUpdateProduct: %v", err)
+			}
+			// Each retry reloads the product and writes over the rival's version
+			wantVersion := prod.Version + int64(tt.races) + 1
+			if updated.Name != "Gadget" || updated.Version != wantVersion {
+				t.Errorf("updated to %q at version %d, want Gadget at %d", updated.Name, updated.Version, wantVersion)
+			}
+			if len(store.added) != 1 {
+				t.Errorf("staged %v, want one event", store.eventTypes())
+			}
+		})
+	}
+}
+
+func TestStockCommandsWriteLedgerAndOutboxInTransaction(t *testing.T) {
+	loc1, loc2 := primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex()
+	tests := []struct {
+		name          string
+		run           func(ctx context.Context, h *ProductCommandHandler, id string) (*product.Product, error)
+		wantStock     int
+		wantMovements int
+		wantEvents    []string
+	}{
+		{
+			name: "update stock",
+			run: func(ctx context.Context, h *ProductCommandHandler, id string) (*product.Product, error) {
+				return h.HandleUpdateProduct(ctx, UpdateProductCommand{ProductID: id, Name: "Widget", Price: 1, Stock: 7})
+			},
+			wantStock:     7,
+			wantMovements: 1,
+			wantEvents:    []string{"product.updated", "product.stock.updated"},
+		},
+		{
+			name: "adjust",
+			run: func(ctx context.Context, h *ProductCommandHandler, id string) (*product.Product, error) {
+				return h.HandleAdjustStock(ctx, AdjustStockCommand{ProductID: id, Delta: -4, Reason: "sale"})
+			},
+			wantStock:     6,
+			wantMovements: 1,
+			wantEvents:    []string{"product.stock.updated"},
+		},
+		{
+			name: "adjust at location",
+			run: func(ctx context.Context, h *ProductCommandHandler, id string) (*product.Product, error) {
+				return h.HandleAdjustStock(ctx, AdjustStockCommand{ProductID: id, LocationID: loc1, Delta: 2, Reason: "restock"})
+			},
+			wantStock:     12,
+			wantMovements: 1,
+			wantEvents:    []string{"product.stock.updated"},
+		},
+		{
+			// Allocating from the unallocated pool records only the location side
+			name: "transfer",
+			run: func(ctx context.Context, h *ProductCommandHandler, id string) (*product.Product, error) {
+				if _, err := h.HandleTransferStock(ctx, TransferStockCommand{ProductID: id, ToLocationID: loc1, Quantity: 4}); err != nil {
+					return nil, err
+				}
+				return h.HandleTransferStock(ctx, TransferStockCommand{ProductID: id, FromLocationID: loc1, ToLocationID: loc2, Quantity: 3})
+			},
+			wantStock:     10,
+			wantMovements: 3,
+			wantEvents:    []string{"product.stock.transferred", "product.stock.transferred"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := memory.NewProductRepository()
+			prod := createProduct(t, repo, 10)
+			movements := &txMovements{MovementRepository: memory.NewMovementRepository()}
+			store := &txOutbox{OutboxStore: memory.NewOutboxStore()}
+			h := newTestProductHandler(repo, movements, store, recordingTx{})
+
+			got, err := tt.run(ctx, h, prod.ID.Hex())
+			if err != nil {
+				t.Fatalf("command failed: %v", err)
+			}
+			if got.Stock != tt.wantStock {
+				t.Errorf("stock = %d, want %d", got.Stock, tt.wantStock)
+			}
+
+			ledger, total, err := movements.FindByProduct(ctx, movement.Filter{ProductID: prod.ID.Hex(), Page: 1, PageSize: 10})
+			if err != nil {
+				t.Fatalf("FindByProduct: %v", err)
+			}
+			if int(total) != tt.wantMovements || len(ledger) != tt.wantMovements {
+				t.Errorf("%d ledger entries, want %d", total, tt.wantMovements)
+			}
+			if types := store.eventTypes(); !equalStrings(types, tt.wantEvents) {
+				t.Errorf("staged %v, want %v", types, tt.wantEvents)
+			}
+			if movements.outside != 0 || store.outside != 0 {
+				t.Errorf("%d ledger entries and %d messages written outside the transaction, want 0", movements.outside, store.outside)
+			}
+		})
+	}
+}
+
+func TestStockCommandsFailWhenOutboxFails(t *testing.T) {
+	errOutbox := stderrors.New("outbox unavailable")
+	commands := map[string]func(ctx context.Context, h *ProductCommandHandler, id string) error{
+		"adjust": func(ctx context.Context, h *ProductCommandHandler, id string) error {
+			_, err := h.HandleAdjustStock(ctx, AdjustStockCommand{ProductID: id, Delta: 1, Reason: "restock"})
+			return err
+		},
+		"transfer": func(ctx context.Context, h *ProductCommandHandler, id string) error {
+			_, err := h.HandleTransferStock(ctx, TransferStockCommand{ProductID: id, ToLocationID: primitive.NewObjectID().Hex(), Quantity: 1})
+			return err
+		},
+	}
+
+	for name, run := range commands {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := memory.NewProductRepository()
+			prod := createProduct(t, repo, 10)
+			store := &txOutbox{OutboxStore: memory.NewOutboxStore(), err: errOutbox}
+			h := newTestProductHandler(repo, memory.NewMovementRepository(), store, recordingTx{})
+
+			// The error aborts the transaction, so the stock change is rolled
+			// back with it wherever transactions are supported
+			err := run(ctx, h, prod.ID.Hex())
+			if errorCode(err) != errors.EREPOSITORY || !stderrors.Is(err, errOutbox) {
+				t.Fatalf("error = %v, want %s wrapping %v", err, errors.EREPOSITORY, errOutbox)
+			}
+			var cached product.Product
+			if err := h.cache.Get(ctx, prod.ID.Hex(), &cached); err == nil {
+				t.Error("product cached after a failed command")
+			}
+		})
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
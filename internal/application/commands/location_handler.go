@@ -0,0 +1,18 @@
+package commands
+
+import (
+	"go-microservice-product-porto/internal/domain/location"
+	"go-microservice-product-porto/internal/domain/product"
+)
+
+type LocationCommandHandler struct {
+	locations location.Repository
+	products  product.Repository
+}
+
+func NewLocationCommandHandler(locations location.Repository, products product.Repository) *LocationCommandHandler {
+	return &LocationCommandHandler{
+		locations: locations,
+		products:  products,
+	}
+}
@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"context"
+
+	"go-microservice-product-porto/internal/domain/webhook"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+// RedeliverWebhookCommand sends a dead delivery again, e.g. once the partner
+// fixed their endpoint.
+type RedeliverWebhookCommand struct {
+	SubscriptionID string `json:"subscription_id"`
+	DeliveryID     string `json:"delivery_id"`
+}
+
+func (h *WebhookCommandHandler) HandleRedeliverWebhook(ctx context.Context, cmd RedeliverWebhookCommand) (*webhook.Delivery, error) {
+	delivery, err := h.deliveries.FindByID(ctx, cmd.DeliveryID)
+	if err != nil {
+		return nil, errors.StandardError(errors.ENOTFOUND, err)
+	}
+	if delivery.SubscriptionID != cmd.SubscriptionID {
+		return nil, errors.StandardError(errors.ENOTFOUND, webhook.ErrDeliveryNotFound)
+	}
+
+	delivery, err = h.deliveries.Requeue(ctx, cmd.DeliveryID)
+	if err != nil {
+		return nil, errors.StandardError(errors.EREPOSITORY, err)
+	}
+
+	return delivery, nil
+}
@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"context"
+
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/domain/reservation"
+)
+
+type ReleaseReservationCommand struct {
+	ReservationID string `json:"reservation_id"`
+}
+
+func (h *ReservationCommandHandler) HandleReleaseReservation(ctx context.Context, cmd ReleaseReservationCommand) (*reservation.Reservation, error) {
+	var (
+		res   *reservation.Reservation
+		prod  *product.Product
+		event *reservation.ReservationReleasedEvent
+	)
+	err := h.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		res, prod, err = h.settle(ctx, cmd.ReservationID, reservation.StatusReleased, false)
+		if err != nil {
+			return err
+		}
+
+		event = &reservation.ReservationReleasedEvent{Reservation: res}
+		return emit(ctx, h.outbox, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	h.eventHandler.HandleReservationChanged(ctx, event, prod)
+
+	return res, nil
+}
@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"context"
+	"time"
+
+	eventhandlers "go-microservice-product-porto/internal/application/event_handlers"
+	"go-microservice-product-porto/internal/application/outbox"
+	"go-microservice-product-porto/internal/domain/movement"
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/domain/reservation"
+	"go-microservice-product-porto/pkg/errors"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+type ReservationCommandHandler struct {
+	reservations reservation.Repository
+	products     product.Repository
+	movements    movement.Repository
+	outbox       outbox.Store
+	tx           outbox.Transactor
+	eventHandler *eventhandlers.ProductEventHandler
+	defaultTTL   time.Duration
+}
+
+func NewReservationCommandHandler(reservations reservation.Repository, products product.Repository, movements movement.Repository, outboxStore outbox.Store, tx outbox.Transactor, eventHandler *eventhandlers.ProductEventHandler, defaultTTL time.Duration) *ReservationCommandHandler {
+	return &ReservationCommandHandler{
+		reservations: reservations,
+		products:     products,
+		movements:    movements,
+		outbox:       outboxStore,
+		tx:           tx,
+		eventHandler: eventHandler,
+		defaultTTL:   defaultTTL,
+	}
+}
+
+// settle moves an active reservation to its final status and gives the held
+// units back to the product, consuming them from stock, and from the location
+// they were held at, when confirmed. The transition comes first so only one
+// caller can release a hold; if the release then fails the reservation is put
+// back to active, since without a transaction nothing else would undo it.
+func (h *ReservationCommandHandler) settle(ctx context.Context, id string, to reservation.Status, consume bool) (*reservation.Reservation, *product.Product, error) {
+	res, err := h.reservations.Transition(ctx, id, reservation.StatusActive, to)
+	if err != nil {
+		return nil, nil, errors.StandardError(errors.EREPOSITORY, err)
+	}
+
+	prod, err := h.products.ReleaseReserved(ctx, res.ProductID, res.LocationID, res.Quantity, consume)
+	if err != nil {
+		if _, revertErr := h.reservations.Transition(ctx, id, to, reservation.StatusActive); revertErr != nil {
+			logger.Error().
+				Str("reservation_id", id).
+				Str("product_id", res.ProductID).
+				Err(revertErr).
+				Msg("reservation settled but product hold was not released")
+		}
+		return nil, nil, errors.StandardError(errors.EREPOSITORY, err)
+	}
+
+	return res, prod, nil
+}
@@ -0,0 +1,179 @@
+package commands
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	eventhandlers "go-microservice-product-porto/internal/application/event_handlers"
+	"go-microservice-product-porto/internal/application/outbox"
+	"go-microservice-product-porto/internal/domain/movement"
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/domain/reservation"
+	"go-microservice-product-porto/internal/infrastructure/cache"
+	"go-microservice-product-porto/internal/infrastructure/persistence/memory"
+)
+
+var errReleaseFailed = stderrors.New("release failed")
+
+// failingRelease is a product repository whose ReleaseReserved always fails.
+type failingRelease struct {
+	product.Repository
+}
+
+func (failingRelease) ReleaseReserved(context.Context, string, string, int, bool) (*product.Product, error) {
+	return nil, errReleaseFailed
+}
+
+func TestSettleRestoresReservationWhenReleaseFails(t *testing.T) {
+	tests := []struct {
+		name    string
+		settle  func(ctx context.Context, h *ReservationCommandHandler, res *reservation.Reservation) error
+		wantErr bool
+	}{
+		{
+			name: "confirm",
+			settle: func(ctx context.Context, h *ReservationCommandHandler, res *reservation.Reservation) error {
+				_, err := h.HandleConfirmReservation(ctx, ConfirmReservationCommand{ReservationID: res.ID.Hex()})
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "release",
+			settle: func(ctx context.Context, h *ReservationCommandHandler, res *reservation.Reservation) error {
+				_, err := h.HandleReleaseReservation(ctx, ReleaseReservationCommand{ReservationID: res.ID.Hex()})
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			// The sweeper logs the failure and moves on to the next hold
+			name: "expire",
+			settle: func(ctx context.Context, h *ReservationCommandHandler, res *reservation.Reservation) error {
+				expired, err := h.HandleExpireReservations(ctx, ExpireReservationsCommand{Now: res.ExpiresAt, Limit: 10})
+				if err == nil && expired != 0 {
+					t.Errorf("expired %d reservations, want 0", expired)
+				}
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			products := memory.NewProductRepository()
+			prod := product.NewProduct("Widget", "", 1, 10)
+			if err := products.Create(ctx, prod); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if _, err := products.Reserve(ctx, prod.ID.Hex(), "", 3); err != nil {
+				t.Fatalf("Reserve: %v", err)
+			}
+			reservations := memory.NewReservationRepository()
+			res := reservation.NewReservation(prod.ID.Hex(), "", 3, "checkout-1", time.Minute)
+			if err := reservations.Create(ctx, res); err != nil {
+				t.Fatalf("Create reservation: %v", err)
+			}
+
+			h := NewReservationCommandHandler(reservations, failingRelease{products}, memory.NewMovementRepository(),
+				memory.NewOutboxStore(), outbox.NoTransaction{}, nil, time.Minute)
+			err := tt.settle(ctx, h, res)
+			if tt.wantErr && !stderrors.Is(err, errReleaseFailed) {
+				t.Fatalf("error = %v, want %v", err, errReleaseFailed)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("error = %v, want nil", err)
+			}
+
+			got, err := reservations.FindByID(ctx, res.ID.Hex())
+			if err != nil {
+				t.Fatalf("FindByID: %v", err)
+			}
+			if got.Status != reservation.StatusActive {
+				t.Errorf("status = %s, want %s", got.Status, reservation.StatusActive)
+			}
+			held, err := products.FindByID(ctx, prod.ID.Hex())
+			if err != nil {
+				t.Fatalf("FindByID: %v", err)
+			}
+			if held.Stock != 10 || held.Reserved != 3 {
+				t.Errorf("stock %d, reserved %d, want 10 and 3", held.Stock, held.Reserved)
+			}
+		})
+	}
+}
+
+func TestReservationLifecycleWritesLedgerAndOutboxInTransaction(t *testing.T) {
+	tests := []struct {
+		name          string
+		settle        func(ctx context.Context, h *ReservationCommandHandler, id string) error
+		wantStock     int
+		wantMovements int
+		wantEvents    []string
+	}{
+		{
+			name: "confirm",
+			settle: func(ctx context.Context, h *ReservationCommandHandler, id string) error {
+				_, err := h.HandleConfirmReservation(ctx, ConfirmReservationCommand{ReservationID: id})
+				return err
+			},
+			wantStock:     7,
+			wantMovements: 1,
+			wantEvents:    []string{"reservation.created", "reservation.confirmed"},
+		},
+		{
+			name: "release",
+			settle: func(ctx context.Context, h *ReservationCommandHandler, id string) error {
+				_, err := h.HandleReleaseReservation(ctx, ReleaseReservationCommand{ReservationID: id})
+				return err
+			},
+			wantStock:  10,
+			wantEvents: []string{"reservation.created", "reservation.released"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			products := memory.NewProductRepository()
+			prod := createProduct(t, products, 10)
+			movements := &txMovements{MovementRepository: memory.NewMovementRepository()}
+			store := &txOutbox{OutboxStore: memory.NewOutboxStore()}
+			c := cache.NewMemoryCache(cache.MemoryConfig{})
+			h := NewReservationCommandHandler(memory.NewReservationRepository(), products, movements, store, recordingTx{},
+				eventhandlers.NewProductEventHandler(c, cache.TTLPolicy{Product: time.Hour}, products), time.Minute)
+
+			res, err := h.HandleCreateReservation(ctx, CreateReservationCommand{ProductID: prod.ID.Hex(), Quantity: 3, OwnerRef: "checkout-1"})
+			if err != nil {
+				t.Fatalf("HandleCreateReservation: %v", err)
+			}
+			if err := tt.settle(ctx, h, res.ID.Hex()); err != nil {
+				t.Fatalf("settle: %v", err)
+			}
+
+			got, err := products.FindByID(ctx, prod.ID.Hex())
+			if err != nil {
+				t.Fatalf("FindByID: %v", err)
+			}
+			if got.Stock != tt.wantStock || got.Reserved != 0 {
+				t.Errorf("stock %d, reserved %d, want %d and 0", got.Stock, got.Reserved, tt.wantStock)
+			}
+			_, total, err := movements.FindByProduct(ctx, movement.Filter{ProductID: prod.ID.Hex(), Page: 1, PageSize: 10})
+			if err != nil {
+				t.Fatalf("FindByProduct: %v", err)
+			}
+			if int(total) != tt.wantMovements {
+				t.Errorf("%d ledger entries, want %d", total, tt.wantMovements)
+			}
+			if types := store.eventTypes(); !equalStrings(types, tt.wantEvents) {
+				t.Errorf("staged %v, want %v", types, tt.wantEvents)
+			}
+			if movements.outside != 0 || store.outside != 0 {
+				t.Errorf("%d ledger entries and %d messages written outside the transaction, want 0", movements.outside, store.outside)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"context"
+	"go-microservice-product-porto/internal/domain/movement"
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+// TransferStockCommand moves stock between locations. Leaving FromLocationID
+// empty allocates unallocated stock to a location; leaving ToLocationID empty
+// returns it to the unallocated pool.
+type TransferStockCommand struct {
+	ProductID      string `json:"product_id"`
+	FromLocationID string `json:"from_location_id"`
+	ToLocationID   string `json:"to_location_id"`
+	Quantity       int    `json:"quantity"`
+}
+
+func (h *ProductCommandHandler) HandleTransferStock(ctx context.Context, cmd TransferStockCommand) (*product.Product, error) {
+	fields := map[string]string{}
+	if cmd.Quantity <= 0 {
+		fields["quantity"] = "must be greater than 0"
+	}
+	if cmd.FromLocationID == cmd.ToLocationID {
+		fields["to_location_id"] = "must differ from from_location_id"
+	}
+	if len(fields) > 0 {
+		return nil, errors.FieldError(product.ErrInvalidTransfer, fields)
+	}
+
+	for _, locationID := range []string{cmd.FromLocationID, cmd.ToLocationID} {
+		if locationID == "" {
+			continue
+		}
+		if err := h.requireActiveLocation(ctx, locationID); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		prod  *product.Product
+		event *product.ProductStockTransferredEvent
+	)
+	err := h.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		prod, err = h.repo.TransferStock(ctx, cmd.ProductID, cmd.FromLocationID, cmd.ToLocationID, cmd.Quantity)
+		if err != nil {
+			return errors.StandardError(errors.EREPOSITORY, err)
+		}
+
+		// Each side of the transfer is its own ledger entry
+		var entries []*movement.Movement
+		for _, side := range []struct {
+			locationID, counterpart string
+			delta                   int
+		}{
+			{cmd.FromLocationID, cmd.ToLocationID, -cmd.Quantity},
+			{cmd.ToLocationID, cmd.FromLocationID, cmd.Quantity},
+		} {
+			if side.locationID == "" {
+				continue
+			}
+			atLocation := prod.StockLevels[side.locationID]
+			m := movement.NewMovement(prod.ID.Hex(), movement.TypeTransfer, atLocation-side.delta, atLocation)
+			m.LocationID = side.locationID
+			m.Reference = side.counterpart
+			entries = append(entries, m)
+		}
+		if err := recordMovement(ctx, h.movements, entries...); err != nil {
+			return err
+		}
+
+		event = &product.ProductStockTransferredEvent{
+			Product:        prod,
+			FromLocationID: cmd.FromLocationID,
+			ToLocationID:   cmd.ToLocationID,
+			Quantity:       cmd.Quantity,
+		}
+		return emit(ctx, h.outbox, event)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	h.cacheProduct(ctx, prod)
+
+	h.eventHandler.HandleStockTransferred(ctx, event)
+
+	return prod, nil
+}
@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"context"
+
+	"go-microservice-product-porto/internal/domain/location"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+type UpdateLocationCommand struct {
+	LocationID string `json:"location_id"`
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	Address    string `json:"address"`
+	Active     bool   `json:"active"`
+}
+
+func (h *LocationCommandHandler) HandleUpdateLocation(ctx context.Context, cmd UpdateLocationCommand) (*location.Location, error) {
+	loc, err := h.locations.FindByID(ctx, cmd.LocationID)
+	if err != nil {
+		return nil, errors.StandardError(errors.ENOTFOUND, err)
+	}
+
+	loc.Update(cmd.Code, cmd.Name, cmd.Address, cmd.Active)
+
+	if !loc.IsValid() {
+		return nil, errors.FieldError(location.ErrInvalidLocation, loc.FieldErrors())
+	}
+
+	if err := h.locations.Update(ctx, loc); err != nil {
+		return nil, errors.StandardError(errors.EREPOSITORY, err)
+	}
+
+	return loc, nil
+}
@@ -0,0 +1,204 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+
+	"go-microservice-product-porto/internal/domain/movement"
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/pkg/common"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+type UpdateProductCommand struct {
+	ProductID        string  `json:"product_id"`
+	Name             string  `json:"name"`
+	Description      string  `json:"description"`
+	Price            float64 `json:"price"`
+	Stock            int     `json:"stock"`
+	ExpectedVersions []int64 `json:"-"`
+}
+
+// PatchProductCommand carries an RFC 7396 JSON Merge Patch for a product.
+type PatchProductCommand struct {
+	ProductID        string          `json:"product_id"`
+	Patch            json.RawMessage `json:"patch"`
+	ExpectedVersions []int64         `json:"-"`
+}
+
+// productFields is the mutable part of a product that merge patches apply to.
+type productFields struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price"`
+	Stock       int     `json:"stock"`
+}
+
+var patchableFields = map[string]bool{
+	"name":        true,
+	"description": true,
+	"price":       true,
+	"stock":       true,
+}
+
+func (h *ProductCommandHandler) HandleUpdateProduct(ctx context.Context, cmd UpdateProductCommand) (*product.Product, error) {
+	return h.updateProduct(ctx, cmd.ProductID, cmd.ExpectedVersions, func(*product.Product) (productFields, error) {
+		return productFields{
+			Name:        cmd.Name,
+			Description: cmd.Description,
+			Price:       cmd.Price,
+			Stock:       cmd.Stock,
+		}, nil
+	})
+}
+
+func (h *ProductCommandHandler) HandlePatchProduct(ctx context.Context, cmd PatchProductCommand) (*product.Product, error) {
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(cmd.Patch, &patch); err != nil {
+		return nil, errors.StandardError(errors.EBADREQUEST, fmt.Errorf("merge patch must be a JSON object: %v", err))
+	}
+
+	readOnly := map[string]string{}
+	for field := range patch {
+		if !patchableFields[field] {
+			readOnly[field] = "cannot be modified"
+		}
+	}
+	if len(readOnly) > 0 {
+		return nil, errors.FieldError(product.ErrInvalidProduct, readOnly)
+	}
+
+	return h.updateProduct(ctx, cmd.ProductID, cmd.ExpectedVersions, func(prod *product.Product) (productFields, error) {
+		return applyMergePatch(prod, cmd.Patch)
+	})
+}
+
+// updateProduct loads the product, derives its new fields and writes them
+// back, retrying the whole cycle if a concurrent writer wins the race.
+func (h *ProductCommandHandler) updateProduct(ctx context.Context, productID string, expectedVersions []int64, fieldsFor func(*product.Product) (productFields, error)) (*product.Product, error) {
+	var (
+		prod, previous *product.Product
+		updated        *product.ProductUpdatedEvent
+		stockUpdated   *product.ProductStockUpdatedEvent
+	)
+	err := retryOnConflict(expectedVersions, func() error {
+		return h.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			var err error
+			prod, err = h.repo.FindByID(ctx, productID)
+			if err != nil {
+				return errors.StandardError(errors.ENOTFOUND, err)
+			}
+
+			if err := checkVersion(expectedVersions, prod); err != nil {
+				return err
+			}
+
+			fields, err := fieldsFor(prod)
+			if err != nil {
+				return err
+			}
+
+			before := *prod
+			previous = &before
+			prod.Update(fields.Name, fields.Description, fields.Price, fields.Stock)
+
+			if !prod.IsValid() {
+				return errors.FieldError(product.ErrInvalidProduct, prod.FieldErrors())
+			}
+
+			if err := h.repo.Update(ctx, prod); err != nil {
+				return writeError(expectedVersions, err)
+			}
+
+			updated = &product.ProductUpdatedEvent{
+				Product: prod,
+				Changes: changedFields(previous, prod),
+			}
+			events := []product.Event{updated}
+
+			stockUpdated = nil
+			if previous.Stock != prod.Stock {
+				m := movement.NewMovement(prod.ID.Hex(), movement.TypeSet, previous.Stock, prod.Stock)
+				if err := recordMovement(ctx, h.movements, m); err != nil {
+					return err
+				}
+
+				stockUpdated = &product.ProductStockUpdatedEvent{
+					Product:  prod,
+					OldStock: previous.Stock,
+					NewStock: prod.Stock,
+				}
+				events = append(events, stockUpdated)
+			}
+
+			return emit(ctx, h.outbox, events...)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	h.cacheProduct(ctx, prod)
+
+	h.eventHandler.HandleProductUpdated(ctx, updated)
+
+	if stockUpdated != nil {
+		h.eventHandler.HandleStockUpdated(ctx, stockUpdated)
+	}
+
+	return prod, nil
+}
+
+// applyMergePatch merges patch into the product's mutable fields.
+func applyMergePatch(prod *product.Product, patch json.RawMessage) (productFields, error) {
+	var fields productFields
+
+	current, err := json.Marshal(productFields{
+		Name:        prod.Name,
+		Description: prod.Description,
+		Price:       prod.Price,
+		Stock:       prod.Stock,
+	})
+	if err != nil {
+		return fields, errors.StandardError(errors.EINTERNAL, err)
+	}
+
+	merged, err := common.MergePatch(current, patch)
+	if err != nil {
+		return fields, errors.StandardError(errors.EBADREQUEST, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&fields); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if stderrors.As(err, &typeErr) {
+			return fields, errors.FieldError(product.ErrInvalidProduct, map[string]string{
+				typeErr.Field: fmt.Sprintf("must be a %s", typeErr.Type),
+			})
+		}
+		return fields, errors.StandardError(errors.EBADREQUEST, err)
+	}
+
+	return fields, nil
+}
+
+func changedFields(before, after *product.Product) []string {
+	var changes []string
+	if before.Name != after.Name {
+		changes = append(changes, "name")
+	}
+	if before.Description != after.Description {
+		changes = append(changes, "description")
+	}
+	if before.Price != after.Price {
+		changes = append(changes, "price")
+	}
+	if before.Stock != after.Stock {
+		changes = append(changes, "stock")
+	}
+	return changes
+}
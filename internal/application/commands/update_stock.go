@@ -2,44 +2,90 @@ package commands
 
 import (
 	"context"
+	"go-microservice-product-porto/internal/domain/movement"
 	"go-microservice-product-porto/internal/domain/product"
 	"go-microservice-product-porto/pkg/errors"
 	"time"
 )
 
+// UpdateStockCommand overwrites stock. With a LocationID it sets the quantity
+// held at that location and moves the product total by the difference.
 type UpdateStockCommand struct {
-	ProductID string `json:"product_id"`
-	Stock     int    `json:"stock"`
+	ProductID        string  `json:"product_id"`
+	LocationID       string  `json:"location_id"`
+	Stock            int     `json:"stock"`
+	ExpectedVersions []int64 `json:"-"`
 }
 
-func (h *ProductCommandHandler) HandleUpdateStock(ctx context.Context, cmd UpdateStockCommand) error {
-	prod, err := h.repo.FindByID(ctx, cmd.ProductID)
-	if err != nil {
-		return errors.StandardError(errors.ENOTFOUND, err)
+func (h *ProductCommandHandler) HandleUpdateStock(ctx context.Context, cmd UpdateStockCommand) (*product.Product, error) {
+	if cmd.Stock < 0 {
+		return nil, errors.StandardError(errors.EVALIDATION, product.ErrInvalidStock)
 	}
 
-	if cmd.Stock < 0 {
-		return errors.StandardError(errors.EVALIDATION, product.ErrInvalidStock)
+	if cmd.LocationID != "" {
+		if err := h.requireActiveLocation(ctx, cmd.LocationID); err != nil {
+			return nil, err
+		}
 	}
 
-	oldStock := prod.Stock
-	prod.Stock = cmd.Stock
-	prod.UpdatedAt = time.Now()
+	var (
+		prod  *product.Product
+		event *product.ProductStockUpdatedEvent
+	)
+	err := retryOnConflict(cmd.ExpectedVersions, func() error {
+		return h.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			var err error
+			prod, err = h.repo.FindByID(ctx, cmd.ProductID)
+			if err != nil {
+				return errors.StandardError(errors.ENOTFOUND, err)
+			}
 
-	if err := h.repo.Update(ctx, prod); err != nil {
-		return errors.StandardError(errors.EREPOSITORY, err)
-	}
+			if err := checkVersion(cmd.ExpectedVersions, prod); err != nil {
+				return err
+			}
 
-	// Handle cache update
-	if err := h.cache.Set(prod.ID.Hex(), prod); err != nil {
-		return errors.StandardError(errors.ECACHE, err)
-	}
+			oldStock, oldAtLocation := prod.Stock, 0
+			if cmd.LocationID != "" {
+				oldAtLocation = prod.SetLocationStock(cmd.LocationID, cmd.Stock)
+			} else {
+				prod.Stock = cmd.Stock
+				prod.UpdatedAt = time.Now()
+			}
+
+			if !prod.IsValid() {
+				return errors.FieldError(product.ErrInvalidStock, prod.FieldErrors())
+			}
 
-	h.eventHandler.HandleStockUpdated(&product.ProductStockUpdatedEvent{
-		Product:  prod,
-		OldStock: oldStock,
-		NewStock: cmd.Stock,
+			if err := h.repo.Update(ctx, prod); err != nil {
+				return writeError(cmd.ExpectedVersions, err)
+			}
+
+			if oldStock != prod.Stock {
+				m := movement.NewMovement(prod.ID.Hex(), movement.TypeSet, oldStock, prod.Stock)
+				if cmd.LocationID != "" {
+					m = movement.NewMovement(prod.ID.Hex(), movement.TypeSet, oldAtLocation, cmd.Stock)
+					m.LocationID = cmd.LocationID
+				}
+				if err := recordMovement(ctx, h.movements, m); err != nil {
+					return err
+				}
+			}
+
+			event = &product.ProductStockUpdatedEvent{
+				Product:  prod,
+				OldStock: oldStock,
+				NewStock: prod.Stock,
+			}
+			return emit(ctx, h.outbox, event)
+		})
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	h.cacheProduct(ctx, prod)
+
+	h.eventHandler.HandleStockUpdated(ctx, event)
 
-	return nil
+	return prod, nil
 }
@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"context"
+
+	"go-microservice-product-porto/internal/domain/webhook"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+// UpdateWebhookCommand replaces a subscription's settings. An empty Secret
+// keeps the current one, so callers only send it to rotate.
+type UpdateWebhookCommand struct {
+	SubscriptionID string   `json:"subscription_id"`
+	URL            string   `json:"url"`
+	EventTypes     []string `json:"event_types"`
+	Secret         string   `json:"secret"`
+	Active         bool     `json:"active"`
+}
+
+func (h *WebhookCommandHandler) HandleUpdateWebhook(ctx context.Context, cmd UpdateWebhookCommand) (*webhook.Subscription, error) {
+	sub, err := h.subscriptions.FindByID(ctx, cmd.SubscriptionID)
+	if err != nil {
+		return nil, errors.StandardError(errors.ENOTFOUND, err)
+	}
+
+	sub.Update(cmd.URL, cmd.EventTypes, cmd.Secret, cmd.Active)
+
+	if !sub.IsValid() {
+		return nil, errors.FieldError(webhook.ErrInvalidSubscription, sub.FieldErrors())
+	}
+
+	if err := h.subscriptions.Update(ctx, sub); err != nil {
+		return nil, errors.StandardError(errors.EREPOSITORY, err)
+	}
+
+	return sub, nil
+}
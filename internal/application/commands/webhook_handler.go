@@ -0,0 +1,15 @@
+package commands
+
+import "go-microservice-product-porto/internal/domain/webhook"
+
+type WebhookCommandHandler struct {
+	subscriptions webhook.SubscriptionRepository
+	deliveries    webhook.DeliveryRepository
+}
+
+func NewWebhookCommandHandler(subscriptions webhook.SubscriptionRepository, deliveries webhook.DeliveryRepository) *WebhookCommandHandler {
+	return &WebhookCommandHandler{
+		subscriptions: subscriptions,
+		deliveries:    deliveries,
+	}
+}
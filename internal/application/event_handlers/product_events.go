@@ -1,6 +1,8 @@
 package eventhandlers
 
 import (
+	"context"
+
 	"go-microservice-product-porto/internal/domain/product"
 	"go-microservice-product-porto/internal/infrastructure/cache"
 	"go-microservice-product-porto/pkg/errors"
@@ -9,24 +11,39 @@ import (
 
 type ProductEventHandler struct {
 	cache cache.CacheService
+	ttls  cache.TTLPolicy
 	repo  product.Repository
 }
 
-func NewProductEventHandler(cache cache.CacheService, repo product.Repository) *ProductEventHandler {
+func NewProductEventHandler(cache cache.CacheService, ttls cache.TTLPolicy, repo product.Repository) *ProductEventHandler {
 	return &ProductEventHandler{
 		cache: cache,
+		ttls:  ttls,
 		repo:  repo,
 	}
 }
 
-func (h *ProductEventHandler) HandleProductCreated(event *product.ProductCreatedEvent) {
-	if err := h.cache.Delete("products_list"); err != nil {
-		log.Printf("Error deleting products_list from cache: %v", errors.StandardError(errors.ECACHE, err))
+func (h *ProductEventHandler) HandleProductCreated(ctx context.Context, event *product.ProductCreatedEvent) {
+	h.invalidate(ctx, cache.TagProductLists, cache.TagProductSearches)
+}
+
+func (h *ProductEventHandler) HandleProductUpdated(ctx context.Context, event *product.ProductUpdatedEvent) {
+	// Name or description changes can move a product in or out of any
+	// list or search, not just the ones it is cached in
+	h.invalidate(ctx, cache.TagProductLists, cache.TagProductSearches, cache.ProductTag(event.Product.ID.Hex()))
+
+	if err := h.cache.Set(ctx, event.Product.ID.Hex(), event.Product, cache.WithTTL(h.ttls.Product)); err != nil {
+		log.Printf("Error updating cache: %v", errors.StandardError(errors.ECACHE, err))
 	}
+
+	log.Printf("Product %s updated, changed fields: %v", event.Product.ID.Hex(), event.Changes)
 }
 
-func (h *ProductEventHandler) HandleStockUpdated(event *product.ProductStockUpdatedEvent) {
-	if err := h.cache.Set(event.Product.ID.Hex(), event.Product); err != nil {
+func (h *ProductEventHandler) HandleStockUpdated(ctx context.Context, event *product.ProductStockUpdatedEvent) {
+	// Lists can be sorted by stock, searches are not
+	h.invalidate(ctx, cache.TagProductLists, cache.ProductTag(event.Product.ID.Hex()))
+
+	if err := h.cache.Set(ctx, event.Product.ID.Hex(), event.Product, cache.WithTTL(h.ttls.Product)); err != nil {
 		log.Printf("Error updating cache: %v", errors.StandardError(errors.ECACHE, err))
 		return
 	}
@@ -34,12 +51,43 @@ func (h *ProductEventHandler) HandleStockUpdated(event *product.ProductStockUpda
 	log.Printf("Stock updated for product %s from %d to %d",
 		event.Product.ID.Hex(), event.OldStock, event.NewStock)
 }
-func (h *ProductEventHandler) HandleProductDeleted(event *product.ProductDeletedEvent) {
-	if err := h.cache.Delete(event.ProductID); err != nil {
+
+func (h *ProductEventHandler) HandleStockTransferred(ctx context.Context, event *product.ProductStockTransferredEvent) {
+	h.invalidate(ctx, cache.ProductTag(event.Product.ID.Hex()))
+
+	if err := h.cache.Set(ctx, event.Product.ID.Hex(), event.Product, cache.WithTTL(h.ttls.Product)); err != nil {
+		log.Printf("Error updating cache: %v", errors.StandardError(errors.ECACHE, err))
+		return
+	}
+
+	log.Printf("Transferred %d units of product %s from %q to %q",
+		event.Quantity, event.Product.ID.Hex(), event.FromLocationID, event.ToLocationID)
+}
+
+func (h *ProductEventHandler) HandleProductDeleted(ctx context.Context, event *product.ProductDeletedEvent) {
+	if err := h.cache.Delete(ctx, event.ProductID); err != nil {
 		log.Printf("Error deleting product from cache: %v", errors.StandardError(errors.ECACHE, err))
 	}
 
-	if err := h.cache.Delete("products_list"); err != nil {
-		log.Printf("Error deleting products_list from cache: %v", errors.StandardError(errors.ECACHE, err))
+	h.invalidate(ctx, cache.TagProductLists, cache.TagProductSearches, cache.ProductTag(event.ProductID))
+}
+
+// HandleReservationChanged refreshes the cached product after a reservation
+// changed how much of its stock is held.
+func (h *ProductEventHandler) HandleReservationChanged(ctx context.Context, event product.Event, prod *product.Product) {
+	h.invalidate(ctx, cache.ProductTag(prod.ID.Hex()))
+
+	if err := h.cache.Set(ctx, prod.ID.Hex(), prod, cache.WithTTL(h.ttls.Product)); err != nil {
+		log.Printf("Error updating cache: %v", errors.StandardError(errors.ECACHE, err))
+	}
+
+	log.Printf("%s for product %s: stock %d, reserved %d",
+		event.GetEventType(), prod.ID.Hex(), prod.Stock, prod.Reserved)
+}
+
+// invalidate drops every cached result carrying one of tags.
+func (h *ProductEventHandler) invalidate(ctx context.Context, tags ...string) {
+	if _, err := h.cache.InvalidateTags(ctx, tags...); err != nil {
+		log.Printf("Error invalidating cache tags %v: %v", tags, errors.StandardError(errors.ECACHE, err))
 	}
 }
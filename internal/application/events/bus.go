@@ -0,0 +1,98 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-microservice-product-porto/internal/domain/product"
+)
+
+// AllEvents subscribes a handler to every event type.
+const AllEvents = "*"
+
+// Envelope is an event as it travels over the bus. Its fields mirror the
+// CloudEvents context attributes so adapters can map it one to one.
+type Envelope struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Source  string          `json:"source"`
+	Subject string          `json:"subject"`
+	Time    time.Time       `json:"time"`
+	Data    json.RawMessage `json:"data"`
+}
+
+func NewEnvelope(source string, event product.Event) (*Envelope, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s event: %v", event.GetEventType(), err)
+	}
+
+	return &Envelope{
+		ID:      primitive.NewObjectID().Hex(),
+		Type:    event.GetEventType(),
+		Source:  source,
+		Subject: event.GetAggregateID(),
+		Time:    time.Now().UTC(),
+		Data:    data,
+	}, nil
+}
+
+// Handler consumes one event. Delivery is at least once, so handlers must
+// tolerate seeing the same envelope ID more than once.
+type Handler func(ctx context.Context, envelope *Envelope) error
+
+type Subscription interface {
+	Unsubscribe() error
+}
+
+// SubscribeOptions tune a single subscription.
+type SubscribeOptions struct {
+	// Queue, when set, shares deliveries among the subscribers in the same
+	// queue group, so each event reaches only one of them. It suits consumers
+	// that split work between instances, never ones every instance must see.
+	Queue string
+}
+
+type SubscribeOption func(*SubscribeOptions)
+
+// WithQueue joins the subscription to a queue group. An empty group leaves
+// the subscription receiving every event.
+func WithQueue(group string) SubscribeOption {
+	return func(opts *SubscribeOptions) {
+		opts.Queue = group
+	}
+}
+
+// NewSubscribeOptions applies opts over the defaults.
+func NewSubscribeOptions(opts ...SubscribeOption) SubscribeOptions {
+	var options SubscribeOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// EventBus fans events out to subscribers by event type.
+type EventBus interface {
+	Publish(ctx context.Context, envelope *Envelope) error
+	// Subscribe registers handler for eventType, or for every event when
+	// eventType is AllEvents.
+	Subscribe(eventType string, handler Handler, opts ...SubscribeOption) (Subscription, error)
+	Close() error
+}
+
+// Subscribe registers a handler that receives the event payload decoded as T,
+// e.g. Subscribe(bus, "product.stock.updated", func(ctx, e *product.ProductStockUpdatedEvent) error).
+func Subscribe[T any](bus EventBus, eventType string, handler func(ctx context.Context, event *T) error, opts ...SubscribeOption) (Subscription, error) {
+	return bus.Subscribe(eventType, func(ctx context.Context, envelope *Envelope) error {
+		event := new(T)
+		if err := json.Unmarshal(envelope.Data, event); err != nil {
+			return fmt.Errorf("failed to decode %s event %s: %v", envelope.Type, envelope.ID, err)
+		}
+		return handler(ctx, event)
+	}, opts...)
+}
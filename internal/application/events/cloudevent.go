@@ -0,0 +1,58 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const cloudEventsSpecVersion = "1.0"
+
+// cloudEvent is the structured-mode JSON representation of a CloudEvent.
+type cloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// MarshalCloudEvent encodes an envelope as a CloudEvents 1.0 JSON document.
+func MarshalCloudEvent(envelope *Envelope) ([]byte, error) {
+	return json.Marshal(cloudEvent{
+		SpecVersion:     cloudEventsSpecVersion,
+		ID:              envelope.ID,
+		Source:          envelope.Source,
+		Type:            envelope.Type,
+		Subject:         envelope.Subject,
+		Time:            envelope.Time,
+		DataContentType: "application/json",
+		Data:            envelope.Data,
+	})
+}
+
+// UnmarshalCloudEvent decodes a CloudEvents 1.0 JSON document.
+func UnmarshalCloudEvent(data []byte) (*Envelope, error) {
+	var event cloudEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, fmt.Errorf("invalid CloudEvent: %v", err)
+	}
+	if event.SpecVersion != cloudEventsSpecVersion {
+		return nil, fmt.Errorf("unsupported CloudEvents specversion %q", event.SpecVersion)
+	}
+	if event.ID == "" || event.Type == "" || event.Source == "" {
+		return nil, fmt.Errorf("CloudEvent is missing id, type or source")
+	}
+
+	return &Envelope{
+		ID:      event.ID,
+		Type:    event.Type,
+		Source:  event.Source,
+		Subject: event.Subject,
+		Time:    event.Time,
+		Data:    event.Data,
+	}, nil
+}
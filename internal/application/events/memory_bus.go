@@ -0,0 +1,100 @@
+package events
+
+import (
+	"context"
+	stderrors "errors"
+	"sort"
+	"sync"
+)
+
+type memoryHandler struct {
+	handler Handler
+	queue   string
+}
+
+// MemoryBus dispatches events synchronously to in-process subscribers. It
+// suits tests and single-node deployments; nothing survives a restart.
+type MemoryBus struct {
+	mu       sync.RWMutex
+	nextID   int
+	handlers map[string]map[int]memoryHandler
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		handlers: make(map[string]map[int]memoryHandler),
+	}
+}
+
+// Publish runs every matching handler and reports their failures together,
+// so one broken subscriber doesn't starve the others. Of the handlers in a
+// queue group only the one subscribed first runs.
+func (b *MemoryBus) Publish(ctx context.Context, envelope *Envelope) error {
+	b.mu.RLock()
+	var matched []Handler
+	queued := map[string]bool{}
+	for _, eventType := range []string{envelope.Type, AllEvents} {
+		ids := make([]int, 0, len(b.handlers[eventType]))
+		for id := range b.handlers[eventType] {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+
+		for _, id := range ids {
+			h := b.handlers[eventType][id]
+			if h.queue != "" {
+				if queued[h.queue] {
+					continue
+				}
+				queued[h.queue] = true
+			}
+			matched = append(matched, h.handler)
+		}
+	}
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, handler := range matched {
+		if err := handler(ctx, envelope); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return stderrors.Join(errs...)
+}
+
+func (b *MemoryBus) Subscribe(eventType string, handler Handler, opts ...SubscribeOption) (Subscription, error) {
+	options := NewSubscribeOptions(opts...)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	if b.handlers[eventType] == nil {
+		b.handlers[eventType] = make(map[int]memoryHandler)
+	}
+	b.handlers[eventType][b.nextID] = memoryHandler{handler: handler, queue: options.Queue}
+
+	return &memorySubscription{bus: b, eventType: eventType, id: b.nextID}, nil
+}
+
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = make(map[string]map[int]memoryHandler)
+	return nil
+}
+
+type memorySubscription struct {
+	bus       *MemoryBus
+	eventType string
+	id        int
+}
+
+func (s *memorySubscription) Unsubscribe() error {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	delete(s.bus.handlers[s.eventType], s.id)
+	return nil
+}
@@ -0,0 +1,59 @@
+package events
+
+import (
+	"context"
+
+	"go-microservice-product-porto/internal/application/outbox"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+// BusPublisher relays outbox messages onto an EventBus. The outbox message ID
+// becomes the envelope ID, so consumers can drop redelivered duplicates.
+// Handlers given to it run after the bus accepted the envelope and before the
+// message counts as published, so they see every event at least once even
+// where the bus itself never redelivers.
+type BusPublisher struct {
+	bus      EventBus
+	source   string
+	handlers []Handler
+}
+
+func NewBusPublisher(bus EventBus, source string, handlers ...Handler) *BusPublisher {
+	return &BusPublisher{
+		bus:      bus,
+		source:   source,
+		handlers: handlers,
+	}
+}
+
+func (p *BusPublisher) Publish(ctx context.Context, message *outbox.Message) error {
+	envelope := &Envelope{
+		ID:      message.ID.Hex(),
+		Type:    message.EventType,
+		Source:  p.source,
+		Subject: message.AggregateID,
+		Time:    message.OccurredAt.UTC(),
+		Data:    message.Payload,
+	}
+	if err := p.bus.Publish(ctx, envelope); err != nil {
+		return err
+	}
+
+	for _, handler := range p.handlers {
+		if err := handler(ctx, envelope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LogHandler writes every event it receives to the log.
+func LogHandler(ctx context.Context, envelope *Envelope) error {
+	logger.Info().
+		Str("event_id", envelope.ID).
+		Str("event_type", envelope.Type).
+		Str("subject", envelope.Subject).
+		RawJSON("data", envelope.Data).
+		Msg("event published")
+	return nil
+}
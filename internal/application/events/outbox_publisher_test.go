@@ -0,0 +1,68 @@
+package events
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+
+	"go-microservice-product-porto/internal/application/outbox"
+	"go-microservice-product-porto/internal/domain/product"
+)
+
+func TestBusPublisherPublish(t *testing.T) {
+	errBusDown := stderrors.New("bus unavailable")
+	errHandler := stderrors.New("handler failed")
+
+	tests := []struct {
+		name        string
+		busErr      error
+		handlerErr  error
+		wantErr     error
+		wantHandled int
+	}{
+		{name: "published", wantHandled: 1},
+		{name: "bus rejects", busErr: errBusDown, wantErr: errBusDown},
+		{name: "handler fails", handlerErr: errHandler, wantErr: errHandler, wantHandled: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			message, err := outbox.NewMessage(product.ProductDeletedEvent{ProductID: "p-1"})
+			if err != nil {
+				t.Fatalf("NewMessage: %v", err)
+			}
+
+			bus := NewMemoryBus()
+			var published []*Envelope
+			if _, err := bus.Subscribe(AllEvents, func(_ context.Context, envelope *Envelope) error {
+				published = append(published, envelope)
+				return tt.busErr
+			}); err != nil {
+				t.Fatalf("Subscribe: %v", err)
+			}
+
+			var handled []*Envelope
+			publisher := NewBusPublisher(bus, "test", func(_ context.Context, envelope *Envelope) error {
+				handled = append(handled, envelope)
+				return tt.handlerErr
+			})
+
+			err = publisher.Publish(ctx, message)
+			if !stderrors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("Publish = %v, want %v", err, tt.wantErr)
+			}
+			if len(handled) != tt.wantHandled {
+				t.Fatalf("handlers ran %d times, want %d", len(handled), tt.wantHandled)
+			}
+
+			envelope := published[0]
+			if envelope.ID != message.ID.Hex() || envelope.Type != "product.deleted" || envelope.Subject != "p-1" || envelope.Source != "test" {
+				t.Errorf("envelope = %+v, want message %s as product.deleted for p-1 from test", envelope, message.ID.Hex())
+			}
+			if tt.wantHandled > 0 && handled[0] != envelope {
+				t.Error("handler saw a different envelope than the bus")
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// listenerBuffer bounds how far a listener may fall behind before it is
+// dropped. Dropped clients reconnect and catch up from the replay buffer.
+const listenerBuffer = 64
+
+// Stream keeps a bounded history of recent events and fans new ones out to
+// live listeners, so server-sent event clients can resume where they left off
+// after a reconnect. A stream only sees what its bus delivers, so with the
+// in-memory bus each instance streams just the events it published itself;
+// running several instances behind one endpoint needs NATS.
+type Stream struct {
+	mu        sync.Mutex
+	accept    func(eventType string) bool
+	history   []*Envelope
+	next      int
+	full      bool
+	seen      map[string]struct{}
+	listeners map[*Listener]struct{}
+}
+
+// NewStream keeps up to capacity events for which accept returns true. The
+// history always holds at least the latest event.
+func NewStream(capacity int, accept func(eventType string) bool) *Stream {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &Stream{
+		accept:    accept,
+		history:   make([]*Envelope, capacity),
+		seen:      make(map[string]struct{}, capacity),
+		listeners: make(map[*Listener]struct{}),
+	}
+}
+
+// Handle is a Handler that records envelope and passes it on to listeners.
+// Envelopes still in the history are ignored, since delivery is at least once.
+func (s *Stream) Handle(ctx context.Context, envelope *Envelope) error {
+	if !s.accept(envelope.Type) {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.seen[envelope.ID]; ok {
+		return nil
+	}
+	if evicted := s.history[s.next]; evicted != nil {
+		delete(s.seen, evicted.ID)
+	}
+	s.history[s.next] = envelope
+	s.seen[envelope.ID] = struct{}{}
+	s.next = (s.next + 1) % len(s.history)
+	if s.next == 0 {
+		s.full = true
+	}
+
+	for listener := range s.listeners {
+		if !listener.wants(envelope.Type) {
+			continue
+		}
+		select {
+		case listener.ch <- envelope:
+		default:
+			// Never block publishers on a slow client
+			s.drop(listener)
+		}
+	}
+	return nil
+}
+
+// Listen registers a listener for types, or for every accepted type when
+// types is empty. It also returns the buffered events newer than
+// lastEventID; when that ID has already been evicted the whole buffer is
+// replayed, since that is the closest the stream can get.
+func (s *Stream) Listen(lastEventID string, types []string) ([]*Envelope, *Listener) {
+	listener := &Listener{
+		ch:     make(chan *Envelope, listenerBuffer),
+		stream: s,
+	}
+	if len(types) > 0 {
+		listener.types = make(map[string]struct{}, len(types))
+		for _, t := range types {
+			listener.types[t] = struct{}{}
+		}
+	}
+	listener.C = listener.ch
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var replay []*Envelope
+	if lastEventID != "" {
+		history := s.ordered()
+		start := 0
+		for i, envelope := range history {
+			if envelope.ID == lastEventID {
+				start = i + 1
+				break
+			}
+		}
+		for _, envelope := range history[start:] {
+			if listener.wants(envelope.Type) {
+				replay = append(replay, envelope)
+			}
+		}
+	}
+
+	s.listeners[listener] = struct{}{}
+	return replay, listener
+}
+
+// ordered returns the history oldest first. Callers must hold s.mu.
+func (s *Stream) ordered() []*Envelope {
+	if !s.full {
+		return append([]*Envelope(nil), s.history[:s.next]...)
+	}
+	return append(append([]*Envelope(nil), s.history[s.next:]...), s.history[:s.next]...)
+}
+
+// drop unregisters listener and closes its channel. Callers must hold s.mu.
+func (s *Stream) drop(listener *Listener) {
+	if _, ok := s.listeners[listener]; !ok {
+		return
+	}
+	delete(s.listeners, listener)
+	close(listener.ch)
+}
+
+// Listener receives events on C until it is closed, or until it falls too
+// far behind, in which case C is closed by the stream.
+type Listener struct {
+	C      <-chan *Envelope
+	ch     chan *Envelope
+	types  map[string]struct{}
+	stream *Stream
+}
+
+func (l *Listener) wants(eventType string) bool {
+	if l.types == nil {
+		return true
+	}
+	_, ok := l.types[eventType]
+	return ok
+}
+
+func (l *Listener) Close() {
+	l.stream.mu.Lock()
+	defer l.stream.mu.Unlock()
+
+	l.stream.drop(l)
+}
@@ -0,0 +1,54 @@
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-microservice-product-porto/internal/domain/product"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusPublished Status = "published"
+	StatusFailed    Status = "failed"
+)
+
+// Message is a domain event staged for publication. It is written in the same
+// transaction as the change that produced it, so an event exists if and only
+// if the change was committed.
+type Message struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	EventType     string             `bson:"event_type" json:"event_type"`
+	AggregateID   string             `bson:"aggregate_id" json:"aggregate_id"`
+	Payload       json.RawMessage    `bson:"payload" json:"payload"`
+	Status        Status             `bson:"status" json:"status"`
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	LastError     string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	OccurredAt    time.Time          `bson:"occurred_at" json:"occurred_at"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at" json:"next_attempt_at"`
+	LockedUntil   time.Time          `bson:"locked_until,omitempty" json:"-"`
+	PublishedAt   time.Time          `bson:"published_at,omitempty" json:"published_at,omitempty"`
+}
+
+func NewMessage(event product.Event) (*Message, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s event: %v", event.GetEventType(), err)
+	}
+
+	now := time.Now()
+	return &Message{
+		ID:            primitive.NewObjectID(),
+		EventType:     event.GetEventType(),
+		AggregateID:   event.GetAggregateID(),
+		Payload:       payload,
+		Status:        StatusPending,
+		OccurredAt:    now,
+		NextAttemptAt: now,
+	}, nil
+}
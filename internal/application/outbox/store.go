@@ -0,0 +1,39 @@
+package outbox
+
+import (
+	"context"
+	"time"
+)
+
+// Store persists outbox messages. Add must join the caller's transaction.
+type Store interface {
+	Add(ctx context.Context, messages ...*Message) error
+	// Claim leases up to limit pending messages that are due at now, so that
+	// concurrent relays don't publish the same message at the same time.
+	Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*Message, error)
+	MarkPublished(ctx context.Context, id string) error
+	// MarkFailed records a failed attempt and schedules the next one, or
+	// parks the message as failed when dead is set.
+	MarkFailed(ctx context.Context, id string, lastError string, nextAttemptAt time.Time, dead bool) error
+}
+
+// Transactor runs fn in a transaction. Repositories called with the context
+// passed to fn take part in that transaction.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// NoTransaction runs work without a transaction, for stores that can't share
+// one with the outbox. A failure part way through leaves the writes made
+// before it in place.
+type NoTransaction struct{}
+
+func (NoTransaction) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// EventPublisher delivers outbox messages to downstream consumers. Delivery is
+// at least once, so consumers must tolerate duplicates.
+type EventPublisher interface {
+	Publish(ctx context.Context, message *Message) error
+}
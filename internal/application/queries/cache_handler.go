@@ -0,0 +1,18 @@
+package queries
+
+import "go-microservice-product-porto/internal/infrastructure/cache"
+
+type CacheQueryHandler struct {
+	cache cache.CacheService
+	// stats and breaker are nil when the cache isn't wrapped in them
+	stats   *cache.StatsCache
+	breaker *cache.BreakerCache
+}
+
+func NewCacheQueryHandler(cacheService cache.CacheService, stats *cache.StatsCache, breaker *cache.BreakerCache) *CacheQueryHandler {
+	return &CacheQueryHandler{
+		cache:   cacheService,
+		stats:   stats,
+		breaker: breaker,
+	}
+}
@@ -0,0 +1,44 @@
+package queries
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Key families reported in cache statistics.
+const (
+	CacheFamilyProduct = "product"
+	CacheFamilyList    = "product_list"
+	CacheFamilySearch  = "product_search"
+	CacheFamilyOther   = "other"
+)
+
+const (
+	listCacheKeyPrefix   = "products_list_"
+	searchCacheKeyPrefix = "search_products_"
+)
+
+func listCacheKey(query ListProductsQuery) string {
+	return fmt.Sprintf("%sp%d_s%d_%s_%s", listCacheKeyPrefix, query.Page, query.PageSize, query.SortBy, query.SortDir)
+}
+
+func searchCacheKey(query SearchProductsQuery) string {
+	return fmt.Sprintf("%s%s_%.2f_%.2f", searchCacheKeyPrefix, query.Name, query.MinPrice, query.MaxPrice)
+}
+
+// CacheKeyFamily tells which family a cache key belongs to. Single products
+// are cached under their bare ID.
+func CacheKeyFamily(key string) string {
+	switch {
+	case strings.HasPrefix(key, listCacheKeyPrefix):
+		return CacheFamilyList
+	case strings.HasPrefix(key, searchCacheKeyPrefix):
+		return CacheFamilySearch
+	case primitive.IsValidObjectID(key):
+		return CacheFamilyProduct
+	default:
+		return CacheFamilyOther
+	}
+}
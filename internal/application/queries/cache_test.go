@@ -0,0 +1,258 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/infrastructure/cache"
+)
+
+// countingRepository serves a fixed catalog and counts the reads that reach it.
+type countingRepository struct {
+	product.Repository
+	products []*product.Product
+	calls    atomic.Int64
+}
+
+func newCountingRepository(n int) *countingRepository {
+	r := &countingRepository{}
+	for i := 0; i < n; i++ {
+		p := product.NewProduct(fmt.Sprintf("product-%d", i), "description", float64(i+1), i)
+		p.ID = primitive.NewObjectID()
+		r.products = append(r.products, p)
+	}
+	return r
+}
+
+func (r *countingRepository) FindByID(ctx context.Context, id string) (*product.Product, error) {
+	r.calls.Add(1)
+	for _, p := range r.products {
+		if p.ID.Hex() == id {
+			return p, nil
+		}
+	}
+	return nil, product.ErrProductNotFound
+}
+
+func (r *countingRepository) FindAll(ctx context.Context, page, pageSize int, sortBy, sortDir string) ([]*product.Product, int64, error) {
+	r.calls.Add(1)
+	start := (page - 1) * pageSize
+	if start > len(r.products) {
+		start = len(r.products)
+	}
+	end := start + pageSize
+	if end > len(r.products) {
+		end = len(r.products)
+	}
+	return r.products[start:end], int64(len(r.products)), nil
+}
+
+func (r *countingRepository) Search(ctx context.Context, name string, minPrice, maxPrice float64) ([]*product.Product, error) {
+	r.calls.Add(1)
+	return r.products, nil
+}
+
+// memoryCache is a codec-backed map, so hits pay the same decoding cost as
+// they would against Redis without needing a server.
+type memoryCache struct {
+	mu      sync.Mutex
+	codec   cache.Codec
+	entries map[string][]byte
+}
+
+func newMemoryCache(codec cache.Codec) *memoryCache {
+	return &memoryCache{codec: codec, entries: map[string][]byte{}}
+}
+
+func (c *memoryCache) Set(ctx context.Context, key string, value interface{}, opts ...cache.Option) error {
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = data
+	return nil
+}
+
+func (c *memoryCache) Get(ctx context.Context, key string, target interface{}) error {
+	c.mu.Lock()
+	data, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok {
+		return cache.ErrMiss
+	}
+	return c.codec.Unmarshal(data, target)
+}
+
+func (c *memoryCache) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+	return nil
+}
+
+func (c *memoryCache) MSet(ctx context.Context, items map[string]interface{}, opts ...cache.Option) error {
+	for key, value := range items {
+		if err := c.Set(ctx, key, value, opts...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *memoryCache) MGet(ctx context.Context, keys []string, target interface{}) error {
+	c.mu.Lock()
+	entries := map[string][]byte{}
+	for _, key := range keys {
+		if data, ok := c.entries[key]; ok {
+			entries[key] = data
+		}
+	}
+	c.mu.Unlock()
+	return cache.DecodeEntries(c.codec, entries, target)
+}
+
+func (c *memoryCache) DeletePattern(ctx context.Context, pattern string) (int64, error) {
+	return 0, nil
+}
+
+func (c *memoryCache) InvalidateTags(ctx context.Context, tags ...string) (int64, error) {
+	return 0, nil
+}
+
+func (c *memoryCache) Inspect(ctx context.Context, key string) (*cache.Entry, error) {
+	c.mu.Lock()
+	data, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok {
+		return nil, cache.ErrMiss
+	}
+	return cache.NewEntry(c.codec, key, data, 0), nil
+}
+
+var codecs = map[string]cache.Codec{
+	"json":    cache.JSON,
+	"msgpack": cache.MessagePack,
+	"gob":     cache.Gob,
+}
+
+func newTestHandler(codec cache.Codec) (*ProductQueryHandler, *countingRepository) {
+	repo := newCountingRepository(50)
+	ttls := cache.TTLPolicy{Product: time.Hour, List: time.Minute, Search: time.Minute}
+	return NewProductQueryHandler(repo, nil, newMemoryCache(codec), ttls, nil), repo
+}
+
+func TestCacheHitsSkipRepository(t *testing.T) {
+	ctx := context.Background()
+
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			h, repo := newTestHandler(codec)
+			id := repo.products[3].ID.Hex()
+
+			for i := 0; i < 3; i++ {
+				p, err := h.HandleGetProduct(ctx, GetProductQuery{ID: id})
+				if err != nil {
+					t.Fatalf("HandleGetProduct: %v", err)
+				}
+				if p.CreatedAt.IsZero() || p.UpdatedAt.IsZero() {
+					t.Errorf("cached product lost its timestamps: %+v", p)
+				}
+
+				list, err := h.HandleListProducts(ctx, ListProductsQuery{Page: 1, PageSize: 10})
+				if err != nil {
+					t.Fatalf("HandleListProducts: %v", err)
+				}
+				if len(list.Products) != 10 || *list.Total != 50 {
+					t.Errorf("list = %d products of %d, want 10 of 50", len(list.Products), *list.Total)
+				}
+
+				found, err := h.HandleSearchProducts(ctx, SearchProductsQuery{Name: "product"})
+				if err != nil {
+					t.Fatalf("HandleSearchProducts: %v", err)
+				}
+				if len(found) != 50 {
+					t.Errorf("search found %d products, want 50", len(found))
+				}
+			}
+
+			// One miss each for get, list and search; every later call is a hit
+			if calls := repo.calls.Load(); calls != 3 {
+				t.Errorf("repository calls = %d, want 3", calls)
+			}
+		})
+	}
+}
+
+func BenchmarkHandleGetProductHit(b *testing.B) {
+	for name, codec := range codecs {
+		b.Run(name, func(b *testing.B) {
+			h, repo := newTestHandler(codec)
+			query := GetProductQuery{ID: repo.products[0].ID.Hex()}
+			ctx := context.Background()
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := h.HandleGetProduct(ctx, query); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			if calls := repo.calls.Load(); calls > 1 {
+				b.Fatalf("repository calls = %d, want at most 1", calls)
+			}
+		})
+	}
+}
+
+func BenchmarkHandleListProductsHit(b *testing.B) {
+	for name, codec := range codecs {
+		b.Run(name, func(b *testing.B) {
+			h, repo := newTestHandler(codec)
+			query := ListProductsQuery{Page: 1, PageSize: 10, SortDir: "asc"}
+			ctx := context.Background()
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := h.HandleListProducts(ctx, query); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			if calls := repo.calls.Load(); calls > 1 {
+				b.Fatalf("repository calls = %d, want at most 1", calls)
+			}
+		})
+	}
+}
+
+func BenchmarkHandleSearchProductsHit(b *testing.B) {
+	for name, codec := range codecs {
+		b.Run(name, func(b *testing.B) {
+			h, repo := newTestHandler(codec)
+			query := SearchProductsQuery{Name: "product"}
+			ctx := context.Background()
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := h.HandleSearchProducts(ctx, query); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			if calls := repo.calls.Load(); calls > 1 {
+				b.Fatalf("repository calls = %d, want at most 1", calls)
+			}
+		})
+	}
+}
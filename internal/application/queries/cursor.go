@@ -0,0 +1,129 @@
+package queries
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	stderrors "errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+var ErrInvalidCursor = stderrors.New("invalid cursor")
+
+// listCursor is what a cursor token carries: the order of the listing and
+// the position of the last product the client was given.
+type listCursor struct {
+	SortBy  string          `json:"s,omitempty"`
+	SortDir string          `json:"d,omitempty"`
+	Value   json.RawMessage `json:"v,omitempty"`
+	ID      string          `json:"id"`
+}
+
+// CursorCodec turns list positions into opaque tokens and back. Tokens are
+// signed, so the only positions a client can hand back are ones it was given,
+// and every instance sharing the secret accepts the others' tokens.
+type CursorCodec struct {
+	key []byte
+}
+
+// NewCursorCodec signs with secret. Without one it signs with a random key,
+// so tokens only work on this instance until it restarts.
+func NewCursorCodec(secret []byte) *CursorCodec {
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			panic(err)
+		}
+	}
+	return &CursorCodec{key: secret}
+}
+
+// Encode returns the token for the position just after p in a listing.
+func (c *CursorCodec) Encode(sortBy, sortDir string, p *product.Product) (string, error) {
+	cursor := listCursor{SortBy: sortBy, SortDir: sortDir, ID: p.ID.Hex()}
+	if value, ok := product.SortValue(p, sortBy); ok {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		cursor.Value = raw
+	}
+
+	payload, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(c.sign(payload)), nil
+}
+
+// Decode checks a token's signature and returns the order and position it
+// holds. Tampered, truncated or foreign tokens fail with ErrInvalidCursor.
+func (c *CursorCodec) Decode(token string) (sortBy, sortDir string, after *product.Keyset, err error) {
+	invalid := errors.StandardError(errors.EINVALID, ErrInvalidCursor)
+
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", "", nil, invalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return "", "", nil, invalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, c.sign(payload)) {
+		return "", "", nil, invalid
+	}
+
+	var cursor listCursor
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return "", "", nil, invalid
+	}
+	id, err := primitive.ObjectIDFromHex(cursor.ID)
+	if err != nil {
+		return "", "", nil, invalid
+	}
+	after = &product.Keyset{ID: id}
+
+	// The sort field decides what type the value was
+	if zero, ok := product.SortValue(&product.Product{}, cursor.SortBy); ok {
+		var value interface{}
+		switch zero.(type) {
+		case string:
+			var v string
+			err = json.Unmarshal(cursor.Value, &v)
+			value = v
+		case float64:
+			var v float64
+			err = json.Unmarshal(cursor.Value, &v)
+			value = v
+		case int:
+			var v int
+			err = json.Unmarshal(cursor.Value, &v)
+			value = v
+		case time.Time:
+			var v time.Time
+			err = json.Unmarshal(cursor.Value, &v)
+			value = v
+		}
+		if err != nil {
+			return "", "", nil, invalid
+		}
+		after.Value = value
+	}
+
+	return cursor.SortBy, cursor.SortDir, after, nil
+}
+
+func (c *CursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,71 @@
+package queries
+
+import (
+	stderrors "errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-microservice-product-porto/internal/domain/product"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	codec := NewCursorCodec([]byte("secret"))
+
+	p := product.NewProduct("Lamp", "", 24.99, 7)
+	p.ID = primitive.NewObjectID()
+	p.CreatedAt = time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC)
+
+	for _, sortBy := range []string{"", "name", "price", "stock", "created_at"} {
+		token, err := codec.Encode(sortBy, "desc", p)
+		if err != nil {
+			t.Fatalf("Encode %q: %v", sortBy, err)
+		}
+
+		gotSortBy, gotSortDir, after, err := codec.Decode(token)
+		if err != nil {
+			t.Fatalf("Decode %q: %v", sortBy, err)
+		}
+		if gotSortBy != sortBy || gotSortDir != "desc" || after.ID != p.ID {
+			t.Errorf("%q: decoded %q %q %s, want %q desc %s", sortBy, gotSortBy, gotSortDir, after.ID.Hex(), sortBy, p.ID.Hex())
+		}
+
+		want, _ := product.SortValue(p, sortBy)
+		if wantTime, ok := want.(time.Time); ok {
+			if got, ok := after.Value.(time.Time); !ok || !got.Equal(wantTime) {
+				t.Errorf("%q: value = %v, want %v", sortBy, after.Value, want)
+			}
+		} else if after.Value != want {
+			t.Errorf("%q: value = %#v, want %#v", sortBy, after.Value, want)
+		}
+	}
+}
+
+func TestCursorRejectsForgeries(t *testing.T) {
+	codec := NewCursorCodec([]byte("secret"))
+	p := product.NewProduct("Lamp", "", 1, 1)
+	p.ID = primitive.NewObjectID()
+
+	token, err := codec.Encode("price", "asc", p)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	payload, signature, _ := strings.Cut(token, ".")
+	foreign, err := NewCursorCodec([]byte("other")).Encode("price", "asc", p)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	for name, bad := range map[string]string{
+		"signed elsewhere": foreign,
+		"edited payload":   "x" + payload[1:] + "." + signature,
+		"no signature":     payload,
+		"garbage":          "not a cursor",
+	} {
+		if _, _, _, err := codec.Decode(bad); !stderrors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: Decode error = %v, want ErrInvalidCursor", name, err)
+		}
+	}
+}
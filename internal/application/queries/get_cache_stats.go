@@ -0,0 +1,27 @@
+package queries
+
+import (
+	"context"
+
+	"go-microservice-product-porto/internal/infrastructure/cache"
+)
+
+type GetCacheStatsQuery struct{}
+
+type CacheStatsResponse struct {
+	Families []cache.FamilyStats `json:"families"`
+	// Breaker is only reported when a shared cache sits behind a breaker.
+	Breaker *cache.BreakerStats `json:"breaker,omitempty"`
+}
+
+func (h *CacheQueryHandler) HandleGetCacheStats(ctx context.Context, query GetCacheStatsQuery) (*CacheStatsResponse, error) {
+	response := &CacheStatsResponse{Families: []cache.FamilyStats{}}
+	if h.stats != nil {
+		response.Families = h.stats.Stats()
+	}
+	if h.breaker != nil {
+		stats := h.breaker.Stats()
+		response.Breaker = &stats
+	}
+	return response, nil
+}
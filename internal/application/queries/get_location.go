@@ -0,0 +1,20 @@
+package queries
+
+import (
+	"context"
+
+	"go-microservice-product-porto/internal/domain/location"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+type GetLocationQuery struct {
+	ID string `json:"id"`
+}
+
+func (h *LocationQueryHandler) HandleGetLocation(ctx context.Context, query GetLocationQuery) (*location.Location, error) {
+	loc, err := h.repo.FindByID(ctx, query.ID)
+	if err != nil {
+		return nil, errors.StandardError(errors.ENOTFOUND, err)
+	}
+	return loc, nil
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 
 	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/infrastructure/cache"
 	"go-microservice-product-porto/pkg/errors"
+	"go-microservice-product-porto/pkg/logger"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -20,49 +22,23 @@ func (h *ProductQueryHandler) HandleGetProduct(ctx context.Context, query GetPro
 	}
 
 	// Try to get from cache first
-	cachedProduct, err := h.cache.Get(objectID.Hex())
-	if err != nil {
-		// Check list cache
-		listCacheKey := "products_list_p1_s10_name_asc" // Default list cache key
-		cachedList, err := h.cache.Get(listCacheKey)
-		if err == nil && cachedList != nil {
-			if listResponse, ok := cachedList.(*ListProductsResponse); ok {
-				// Search for product in cached list
-				for _, p := range listResponse.Products {
-					if p.ID == objectID {
-						// Found in list cache, store in individual cache
-						h.cache.Set(objectID.Hex(), p)
-						return p, nil
-					}
-				}
-			}
-		}
-	}
-
-	if productMap, ok := cachedProduct.(map[string]interface{}); ok {
-		// Convert map to Product struct
-		product := &product.Product{
-			ID:          objectID,
-			Name:        productMap["name"].(string),
-			Description: productMap["description"].(string),
-			Price:       productMap["price"].(float64),
-			Stock:       int(productMap["stock"].(float64)),
-		}
-		return product, nil
+	if cached, err := cache.Get[*product.Product](ctx, h.cache, objectID.Hex()); err == nil && cached != nil {
+		return cached, nil
 	}
 
 	// Get from repository if not in cache
 	product, err := h.repo.FindByID(ctx, objectID.Hex())
 	if err != nil {
-		if err == err {
-			return nil, errors.StandardError(errors.ENOTFOUND, err)
-		}
-		return nil, errors.StandardError(errors.EREPOSITORY, err)
+		return nil, errors.StandardError(errors.ENOTFOUND, err)
 	}
 
-	// Update cache
-	if err := h.cache.Set(objectID.Hex(), product); err != nil {
-		return nil, errors.StandardError(errors.ECACHE, err)
+	// Update cache. The product was found, so a cache failure only costs
+	// the next read a trip to the repository
+	if err := h.cache.Set(ctx, objectID.Hex(), product, cache.WithTTL(h.ttls.Product), cache.AsFill()); err != nil {
+		logger.Warn().
+			Str("product_id", objectID.Hex()).
+			Err(err).
+			Msg("product loaded but not cached")
 	}
 
 	return product, nil
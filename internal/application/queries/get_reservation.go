@@ -0,0 +1,20 @@
+package queries
+
+import (
+	"context"
+
+	"go-microservice-product-porto/internal/domain/reservation"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+type GetReservationQuery struct {
+	ID string `json:"id"`
+}
+
+func (h *ReservationQueryHandler) HandleGetReservation(ctx context.Context, query GetReservationQuery) (*reservation.Reservation, error) {
+	res, err := h.repo.FindByID(ctx, query.ID)
+	if err != nil {
+		return nil, errors.StandardError(errors.ENOTFOUND, err)
+	}
+	return res, nil
+}
@@ -0,0 +1,20 @@
+package queries
+
+import (
+	"context"
+
+	"go-microservice-product-porto/internal/domain/webhook"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+type GetWebhookQuery struct {
+	ID string `json:"id"`
+}
+
+func (h *WebhookQueryHandler) HandleGetWebhook(ctx context.Context, query GetWebhookQuery) (*webhook.Subscription, error) {
+	sub, err := h.subscriptions.FindByID(ctx, query.ID)
+	if err != nil {
+		return nil, errors.StandardError(errors.ENOTFOUND, err)
+	}
+	return sub, nil
+}
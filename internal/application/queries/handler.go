@@ -1,18 +1,43 @@
 package queries
 
 import (
+	"go-microservice-product-porto/internal/domain/movement"
 	"go-microservice-product-porto/internal/domain/product"
 	"go-microservice-product-porto/internal/infrastructure/cache"
 )
 
 type ProductQueryHandler struct {
-	repo  product.Repository
-	cache cache.CacheService
+	repo      product.Repository
+	movements movement.Repository
+	cache     cache.CacheService
+	ttls      cache.TTLPolicy
+	// loader reads lists and searches, which only queries write
+	loader  *cache.Loader
+	cursors *CursorCodec
 }
 
-func NewProductQueryHandler(repo product.Repository, cache cache.CacheService) *ProductQueryHandler {
+// NewProductQueryHandler creates the handler. Without a cursor codec, list
+// cursors are signed with a key of this instance's own.
+func NewProductQueryHandler(repo product.Repository, movements movement.Repository, cacheService cache.CacheService, ttls cache.TTLPolicy, cursors *CursorCodec) *ProductQueryHandler {
+	if cursors == nil {
+		cursors = NewCursorCodec(nil)
+	}
 	return &ProductQueryHandler{
-		repo:  repo,
-		cache: cache,
+		repo:      repo,
+		movements: movements,
+		cache:     cacheService,
+		ttls:      ttls,
+		loader:    cache.NewLoader(cacheService, ttls.Stale),
+		cursors:   cursors,
+	}
+}
+
+// productTags returns the cache tags of products, so cached results that
+// include them are dropped when any of them changes.
+func productTags(products []*product.Product) []string {
+	tags := make([]string, len(products))
+	for i, p := range products {
+		tags[i] = cache.ProductTag(p.ID.Hex())
 	}
+	return tags
 }
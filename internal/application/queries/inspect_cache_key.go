@@ -0,0 +1,35 @@
+package queries
+
+import (
+	"context"
+
+	"go-microservice-product-porto/internal/infrastructure/cache"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+type InspectCacheKeyQuery struct {
+	Key string `json:"key"`
+}
+
+type InspectCacheKeyResponse struct {
+	*cache.Entry
+	Family string `json:"family"`
+	// TTL is in seconds, and zero for entries that never expire.
+	TTL float64 `json:"ttl"`
+}
+
+func (h *CacheQueryHandler) HandleInspectCacheKey(ctx context.Context, query InspectCacheKeyQuery) (*InspectCacheKeyResponse, error) {
+	entry, err := h.cache.Inspect(ctx, query.Key)
+	if err != nil {
+		if cache.IsMiss(err) {
+			return nil, errors.StandardError(errors.ENOTFOUND, err)
+		}
+		return nil, errors.StandardError(errors.ECACHE, err)
+	}
+
+	return &InspectCacheKeyResponse{
+		Entry:  entry,
+		Family: CacheKeyFamily(entry.Key),
+		TTL:    entry.TTL.Seconds(),
+	}, nil
+}
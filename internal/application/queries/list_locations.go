@@ -0,0 +1,18 @@
+package queries
+
+import (
+	"context"
+
+	"go-microservice-product-porto/internal/domain/location"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+type ListLocationsQuery struct{}
+
+func (h *LocationQueryHandler) HandleListLocations(ctx context.Context, query ListLocationsQuery) ([]*location.Location, error) {
+	locations, err := h.repo.FindAll(ctx)
+	if err != nil {
+		return nil, errors.StandardError(errors.EREPOSITORY, err)
+	}
+	return locations, nil
+}
@@ -3,23 +3,38 @@ package queries
 import (
 	"context"
 
-	"fmt"
 	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/infrastructure/cache"
 	"go-microservice-product-porto/pkg/errors"
 )
 
+// maxListPageSize caps a page, numbered or keyset, so one request can't read
+// the whole catalog.
+const maxListPageSize = 100
+
 type ListProductsQuery struct {
 	Page     int    `json:"page"`
 	PageSize int    `json:"page_size"`
 	SortBy   string `json:"sort_by"`
 	SortDir  string `json:"sort_dir"` // "asc" or "desc"
+	// Keyset pages by position instead of by number: each page carries a
+	// NextCursor to pass back as Cursor, and Page is ignored. An empty
+	// Cursor starts at the beginning; any other carries its own order,
+	// which overrides SortBy and SortDir.
+	Keyset bool   `json:"keyset"`
+	Cursor string `json:"cursor"`
+	// IncludeTotal counts every product for a keyset page. Numbered pages
+	// are always counted.
+	IncludeTotal bool `json:"include_total"`
 }
 
 type ListProductsResponse struct {
 	Products []*product.Product `json:"products"`
-	Total    int64              `json:"total"`
-	Page     int                `json:"page"`
+	Total    *int64             `json:"total,omitempty"`
+	Page     int                `json:"page,omitempty"`
 	PageSize int                `json:"page_size"`
+	// NextCursor continues a keyset listing. It is empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 func (h *ProductQueryHandler) HandleListProducts(ctx context.Context, query ListProductsQuery) (*ListProductsResponse, error) {
@@ -30,40 +45,85 @@ func (h *ProductQueryHandler) HandleListProducts(ctx context.Context, query List
 	if query.PageSize <= 0 {
 		query.PageSize = 10
 	}
+	if query.PageSize > maxListPageSize {
+		query.PageSize = maxListPageSize
+	}
 
 	// Validate sort direction
 	if query.SortDir != "" && query.SortDir != "asc" && query.SortDir != "desc" {
 		query.SortDir = "asc"
 	}
 
+	if query.Keyset || query.Cursor != "" {
+		return h.listProductsAfter(ctx, query)
+	}
+
 	// Generate cache key based on query parameters
-	cacheKey := fmt.Sprintf("products_list_p%d_s%d_%s_%s", query.Page, query.PageSize, query.SortBy, query.SortDir)
+	cacheKey := listCacheKey(query)
+
+	// Concurrent misses share one load, and an expired page is served while
+	// it refreshes
+	return cache.Fetch(ctx, h.loader, cacheKey, func(ctx context.Context) (*ListProductsResponse, []cache.Option, error) {
+		products, total, err := h.repo.FindAll(ctx, query.Page, query.PageSize, query.SortBy, query.SortDir)
+		if err != nil {
+			return nil, nil, errors.StandardError(errors.EREPOSITORY, err)
+		}
+
+		response := &ListProductsResponse{
+			Products: products,
+			Total:    &total,
+			Page:     query.Page,
+			PageSize: query.PageSize,
+		}
 
-	// Try to get from cache first
-	cachedData, err := h.cache.Get(cacheKey)
-	if err == nil && cachedData != nil {
-		if response, ok := cachedData.(*ListProductsResponse); ok {
-			return response, nil
+		// Tag the page so any product change, or a change to one of its
+		// products, drops it
+		tags := append([]string{cache.TagProductLists}, productTags(products)...)
+		return response, []cache.Option{cache.WithTTL(h.ttls.List), cache.WithTags(tags...)}, nil
+	})
+}
+
+// listProductsAfter serves keyset pages straight from the repository. They
+// are read in one pass by jobs walking the catalog, so caching them would
+// only push out the pages that are asked for again.
+func (h *ProductQueryHandler) listProductsAfter(ctx context.Context, query ListProductsQuery) (*ListProductsResponse, error) {
+	sortBy, sortDir := query.SortBy, query.SortDir
+	if _, ok := product.SortValue(&product.Product{}, sortBy); !ok {
+		sortBy = ""
+	}
+
+	var after *product.Keyset
+	if query.Cursor != "" {
+		var err error
+		if sortBy, sortDir, after, err = h.cursors.Decode(query.Cursor); err != nil {
+			return nil, err
 		}
 	}
 
-	// Get from repository if not in cache
-	products, total, err := h.repo.FindAll(ctx, query.Page, query.PageSize, query.SortBy, query.SortDir)
+	// One product more than the page holds tells whether another follows
+	products, err := h.repo.FindPage(ctx, sortBy, sortDir, after, query.PageSize+1)
 	if err != nil {
 		return nil, errors.StandardError(errors.EREPOSITORY, err)
 	}
 
 	response := &ListProductsResponse{
 		Products: products,
-		Total:    total,
-		Page:     query.Page,
 		PageSize: query.PageSize,
 	}
-
-	// Store in cache
-	if err := h.cache.Set(cacheKey, response); err != nil {
-		return nil, errors.StandardError(errors.ECACHE, err)
+	if len(products) > query.PageSize {
+		response.Products = products[:query.PageSize]
+		last := response.Products[len(response.Products)-1]
+		if response.NextCursor, err = h.cursors.Encode(sortBy, sortDir, last); err != nil {
+			return nil, errors.StandardError(errors.EINTERNAL, err)
+		}
 	}
 
+	if query.IncludeTotal {
+		total, err := h.repo.Count(ctx)
+		if err != nil {
+			return nil, errors.StandardError(errors.EREPOSITORY, err)
+		}
+		response.Total = &total
+	}
 	return response, nil
 }
@@ -0,0 +1,37 @@
+package queries
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/infrastructure/cache"
+	"go-microservice-product-porto/internal/infrastructure/persistence/memory"
+)
+
+func TestListProductsCapsPageSize(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewProductRepository()
+	for i := 0; i < maxListPageSize+5; i++ {
+		if err := repo.Create(ctx, product.NewProduct(fmt.Sprintf("product-%d", i), "", 1, 1)); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	ttls := cache.TTLPolicy{Product: time.Hour, List: time.Minute, Search: time.Minute}
+	h := NewProductQueryHandler(repo, nil, newMemoryCache(cache.JSON), ttls, nil)
+
+	for _, query := range []ListProductsQuery{
+		{Page: 1, PageSize: 1000},
+		{Keyset: true, PageSize: 1000},
+	} {
+		list, err := h.HandleListProducts(ctx, query)
+		if err != nil {
+			t.Fatalf("HandleListProducts: %v", err)
+		}
+		if list.PageSize != maxListPageSize || len(list.Products) != maxListPageSize {
+			t.Errorf("keyset %v: page size %d with %d products, want %d", query.Keyset, list.PageSize, len(list.Products), maxListPageSize)
+		}
+	}
+}
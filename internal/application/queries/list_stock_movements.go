@@ -0,0 +1,57 @@
+package queries
+
+import (
+	"context"
+	"time"
+
+	"go-microservice-product-porto/internal/domain/movement"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+type ListStockMovementsQuery struct {
+	ProductID string    `json:"product_id"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Page      int       `json:"page"`
+	PageSize  int       `json:"page_size"`
+}
+
+type ListStockMovementsResponse struct {
+	Movements []*movement.Movement `json:"movements"`
+	Total     int64                `json:"total"`
+	Page      int                  `json:"page"`
+	PageSize  int                  `json:"page_size"`
+}
+
+func (h *ProductQueryHandler) HandleListStockMovements(ctx context.Context, query ListStockMovementsQuery) (*ListStockMovementsResponse, error) {
+	// Set default values if not provided
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 {
+		query.PageSize = 20
+	}
+
+	// Make sure the product exists so unknown IDs aren't reported as empty history
+	if _, err := h.repo.FindByID(ctx, query.ProductID); err != nil {
+		return nil, errors.StandardError(errors.ENOTFOUND, err)
+	}
+
+	movements, total, err := h.movements.FindByProduct(ctx, movement.Filter{
+		ProductID: query.ProductID,
+		From:      query.From,
+		To:        query.To,
+		Page:      query.Page,
+		PageSize:  query.PageSize,
+	})
+	if err != nil {
+		return nil, errors.StandardError(errors.EREPOSITORY, err)
+	}
+
+	return &ListStockMovementsResponse{
+		Movements: movements,
+		Total:     total,
+		Page:      query.Page,
+		PageSize:  query.PageSize,
+	}, nil
+}
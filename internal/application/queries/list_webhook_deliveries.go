@@ -0,0 +1,54 @@
+package queries
+
+import (
+	"context"
+
+	"go-microservice-product-porto/internal/domain/webhook"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+type ListWebhookDeliveriesQuery struct {
+	SubscriptionID string                 `json:"subscription_id"`
+	Status         webhook.DeliveryStatus `json:"status"`
+	Page           int                    `json:"page"`
+	PageSize       int                    `json:"page_size"`
+}
+
+type ListWebhookDeliveriesResponse struct {
+	Deliveries []*webhook.Delivery `json:"deliveries"`
+	Total      int64               `json:"total"`
+	Page       int                 `json:"page"`
+	PageSize   int                 `json:"page_size"`
+}
+
+func (h *WebhookQueryHandler) HandleListWebhookDeliveries(ctx context.Context, query ListWebhookDeliveriesQuery) (*ListWebhookDeliveriesResponse, error) {
+	// Set default values if not provided
+	if query.Page <= 0 {
+		query.Page = 1
+	}
+	if query.PageSize <= 0 {
+		query.PageSize = 20
+	}
+
+	// Make sure the subscription exists so unknown IDs aren't reported as an empty log
+	if _, err := h.subscriptions.FindByID(ctx, query.SubscriptionID); err != nil {
+		return nil, errors.StandardError(errors.ENOTFOUND, err)
+	}
+
+	deliveries, total, err := h.deliveries.FindBySubscription(ctx, webhook.DeliveryFilter{
+		SubscriptionID: query.SubscriptionID,
+		Status:         query.Status,
+		Page:           query.Page,
+		PageSize:       query.PageSize,
+	})
+	if err != nil {
+		return nil, errors.StandardError(errors.EREPOSITORY, err)
+	}
+
+	return &ListWebhookDeliveriesResponse{
+		Deliveries: deliveries,
+		Total:      total,
+		Page:       query.Page,
+		PageSize:   query.PageSize,
+	}, nil
+}
@@ -0,0 +1,18 @@
+package queries
+
+import (
+	"context"
+
+	"go-microservice-product-porto/internal/domain/webhook"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+type ListWebhooksQuery struct{}
+
+func (h *WebhookQueryHandler) HandleListWebhooks(ctx context.Context, query ListWebhooksQuery) ([]*webhook.Subscription, error) {
+	subscriptions, err := h.subscriptions.FindAll(ctx)
+	if err != nil {
+		return nil, errors.StandardError(errors.EREPOSITORY, err)
+	}
+	return subscriptions, nil
+}
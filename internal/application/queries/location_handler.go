@@ -0,0 +1,13 @@
+package queries
+
+import "go-microservice-product-porto/internal/domain/location"
+
+type LocationQueryHandler struct {
+	repo location.Repository
+}
+
+func NewLocationQueryHandler(repo location.Repository) *LocationQueryHandler {
+	return &LocationQueryHandler{
+		repo: repo,
+	}
+}
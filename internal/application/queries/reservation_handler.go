@@ -0,0 +1,13 @@
+package queries
+
+import "go-microservice-product-porto/internal/domain/reservation"
+
+type ReservationQueryHandler struct {
+	repo reservation.Repository
+}
+
+func NewReservationQueryHandler(repo reservation.Repository) *ReservationQueryHandler {
+	return &ReservationQueryHandler{
+		repo: repo,
+	}
+}
@@ -2,8 +2,8 @@ package queries
 
 import (
 	"context"
-	"fmt"
 	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/infrastructure/cache"
 	"go-microservice-product-porto/pkg/errors"
 )
 
@@ -21,26 +21,15 @@ type SearchProductsQuery struct {
 
 func (h *ProductQueryHandler) HandleSearchProducts(ctx context.Context, query SearchProductsQuery) ([]*product.Product, error) {
 	// Generate cache key based on search parameters
-	cacheKey := fmt.Sprintf("search_products_%s_%.2f_%.2f", query.Name, query.MinPrice, query.MaxPrice)
+	cacheKey := searchCacheKey(query)
 
-	// Try to get from cache first
-	cachedResults, err := h.cache.Get(cacheKey)
-	if err == nil && cachedResults != nil {
-		if products, ok := cachedResults.([]*product.Product); ok {
-			return products, nil
+	return cache.Fetch(ctx, h.loader, cacheKey, func(ctx context.Context) ([]*product.Product, []cache.Option, error) {
+		products, err := h.repo.Search(ctx, query.Name, query.MinPrice, query.MaxPrice)
+		if err != nil {
+			return nil, nil, errors.StandardError(errors.EREPOSITORY, err)
 		}
-	}
 
-	// Perform search in repository
-	products, err := h.repo.Search(ctx, query.Name, query.MinPrice, query.MaxPrice)
-	if err != nil {
-		return nil, errors.StandardError(errors.EREPOSITORY, err)
-	}
-
-	// Store results in cache
-	if err := h.cache.Set(cacheKey, products); err != nil {
-		return nil, errors.StandardError(errors.ECACHE, err)
-	}
-
-	return products, nil
+		tags := append([]string{cache.TagProductSearches}, productTags(products)...)
+		return products, []cache.Option{cache.WithTTL(h.ttls.Search), cache.WithTags(tags...)}, nil
+	})
 }
@@ -0,0 +1,176 @@
+package queries
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/infrastructure/cache"
+)
+
+// gatedRepository holds every list and search until its gate is opened, so
+// tests can pile requests up behind a slow load. A call is counted while it
+// waits, and only once however it returns.
+type gatedRepository struct {
+	*countingRepository
+	gate chan struct{}
+}
+
+func newGatedRepository(n int) *gatedRepository {
+	return &gatedRepository{
+		countingRepository: newCountingRepository(n),
+		gate:               make(chan struct{}),
+	}
+}
+
+func (r *gatedRepository) FindAll(ctx context.Context, page, pageSize int, sortBy, sortDir string) ([]*product.Product, int64, error) {
+	r.calls.Add(1)
+	<-r.gate
+	r.calls.Add(-1)
+	return r.countingRepository.FindAll(ctx, page, pageSize, sortBy, sortDir)
+}
+
+func (r *gatedRepository) Search(ctx context.Context, name string, minPrice, maxPrice float64) ([]*product.Product, error) {
+	r.calls.Add(1)
+	<-r.gate
+	r.calls.Add(-1)
+	return r.countingRepository.Search(ctx, name, minPrice, maxPrice)
+}
+
+// waitForCalls waits until the repository has been called want times.
+func waitForCalls(t *testing.T, repo *gatedRepository, want int64) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for repo.calls.Load() < want {
+		if time.Now().After(deadline) {
+			t.Fatalf("repository calls = %d, want %d", repo.calls.Load(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestConcurrentMissesLoadOnce(t *testing.T) {
+	const callers = 100
+
+	queries := map[string]func(h *ProductQueryHandler) (int, error){
+		"list": func(h *ProductQueryHandler) (int, error) {
+			list, err := h.HandleListProducts(context.Background(), ListProductsQuery{Page: 1, PageSize: 10})
+			if err != nil {
+				return 0, err
+			}
+			return len(list.Products), nil
+		},
+		"search": func(h *ProductQueryHandler) (int, error) {
+			found, err := h.HandleSearchProducts(context.Background(), SearchProductsQuery{Name: "product"})
+			return len(found), err
+		},
+	}
+
+	for name, query := range queries {
+		t.Run(name, func(t *testing.T) {
+			repo := newGatedRepository(10)
+			ttls := cache.TTLPolicy{List: time.Minute, Search: time.Minute}
+			h := NewProductQueryHandler(repo, nil, cache.NewMemoryCache(cache.MemoryConfig{}), ttls, nil)
+
+			var wg sync.WaitGroup
+			errs := make(chan error, callers)
+			for i := 0; i < callers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					n, err := query(h)
+					if err == nil && n != 10 {
+						t.Errorf("got %d products, want 10", n)
+					}
+					errs <- err
+				}()
+			}
+
+			// Let the first load reach the repository and the rest queue
+			// behind it before it returns
+			waitForCalls(t, repo, 1)
+			time.Sleep(20 * time.Millisecond)
+			close(repo.gate)
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				if err != nil {
+					t.Fatalf("query: %v", err)
+				}
+			}
+			if calls := repo.calls.Load(); calls != 1 {
+				t.Errorf("repository calls = %d, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestStaleListServedWhileRefreshing(t *testing.T) {
+	const callers = 50
+
+	repo := newGatedRepository(10)
+	close(repo.gate)
+	ttls := cache.TTLPolicy{List: 10 * time.Millisecond, Stale: time.Hour}
+	h := NewProductQueryHandler(repo, nil, cache.NewMemoryCache(cache.MemoryConfig{}), ttls, nil)
+	query := ListProductsQuery{Page: 1, PageSize: 10}
+
+	if _, err := h.HandleListProducts(context.Background(), query); err != nil {
+		t.Fatalf("HandleListProducts: %v", err)
+	}
+	loadedAt := staleAt(t, h)
+
+	// Hold the refresh in the repository once the page has gone stale
+	repo.gate = make(chan struct{})
+	time.Sleep(20 * time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			list, err := h.HandleListProducts(ctx, query)
+			if err != nil {
+				t.Errorf("HandleListProducts: %v", err)
+				return
+			}
+			if len(list.Products) != 10 {
+				t.Errorf("got %d products, want 10", len(list.Products))
+			}
+		}()
+	}
+
+	// Every caller is answered from the stale page while one refresh waits
+	wg.Wait()
+	waitForCalls(t, repo, 2)
+	close(repo.gate)
+
+	deadline := time.Now().Add(time.Second)
+	for !staleAt(t, h).After(loadedAt) {
+		if time.Now().After(deadline) {
+			t.Fatal("stale page was never refreshed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if calls := repo.calls.Load(); calls != 2 {
+		t.Errorf("repository calls = %d, want 2", calls)
+	}
+}
+
+// staleAt returns when the cached first page of the product list goes stale.
+func staleAt(t *testing.T, h *ProductQueryHandler) time.Time {
+	t.Helper()
+	cached, err := cache.Get[struct {
+		StaleAt time.Time `json:"stale_at"`
+	}](context.Background(), h.cache, "products_list_p1_s10__")
+	if err != nil {
+		t.Fatalf("cached page: %v", err)
+	}
+	return cached.StaleAt
+}
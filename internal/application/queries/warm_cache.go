@@ -0,0 +1,75 @@
+package queries
+
+import (
+	"context"
+
+	"go-microservice-product-porto/internal/infrastructure/cache"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+// ListSort is one ordering of the product list.
+type ListSort struct {
+	SortBy  string `json:"sort_by"`
+	SortDir string `json:"sort_dir"`
+}
+
+// WarmCacheQuery chooses what to load into a cold cache: the most recently
+// created products one by one, and the first pages of the list in each sort.
+// Request counts don't survive a restart, so recency stands in for
+// popularity.
+type WarmCacheQuery struct {
+	RecentProducts int        `json:"recent_products"`
+	Sorts          []ListSort `json:"sorts"`
+	Pages          int        `json:"pages"`
+	PageSize       int        `json:"page_size"`
+}
+
+type WarmCacheResponse struct {
+	RecentProducts int `json:"recent_products"`
+	ListPages      int `json:"list_pages"`
+}
+
+// HandleWarmCache preloads the cache so the first requests after a deploy
+// don't all land on the repository. List pages go through the same loader
+// as requests do, so pages that are already cached are left alone.
+func (h *ProductQueryHandler) HandleWarmCache(ctx context.Context, query WarmCacheQuery) (*WarmCacheResponse, error) {
+	response := &WarmCacheResponse{}
+
+	if query.RecentProducts > 0 {
+		products, _, err := h.repo.FindAll(ctx, 1, query.RecentProducts, "created_at", "desc")
+		if err != nil {
+			return response, errors.StandardError(errors.EREPOSITORY, err)
+		}
+
+		items := make(map[string]interface{}, len(products))
+		for _, p := range products {
+			items[p.ID.Hex()] = p
+		}
+		if err := h.cache.MSet(ctx, items, cache.WithTTL(h.ttls.Product), cache.AsFill()); err != nil {
+			return response, errors.StandardError(errors.ECACHE, err)
+		}
+		response.RecentProducts = len(products)
+	}
+
+	for _, sort := range query.Sorts {
+		for page := 1; page <= query.Pages; page++ {
+			list, err := h.HandleListProducts(ctx, ListProductsQuery{
+				Page:     page,
+				PageSize: query.PageSize,
+				SortBy:   sort.SortBy,
+				SortDir:  sort.SortDir,
+			})
+			if err != nil {
+				return response, err
+			}
+			response.ListPages++
+
+			// Past the last page there is nothing left to warm
+			if int64(page*list.PageSize) >= *list.Total {
+				break
+			}
+		}
+	}
+
+	return response, nil
+}
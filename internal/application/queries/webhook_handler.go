@@ -0,0 +1,15 @@
+package queries
+
+import "go-microservice-product-porto/internal/domain/webhook"
+
+type WebhookQueryHandler struct {
+	subscriptions webhook.SubscriptionRepository
+	deliveries    webhook.DeliveryRepository
+}
+
+func NewWebhookQueryHandler(subscriptions webhook.SubscriptionRepository, deliveries webhook.DeliveryRepository) *WebhookQueryHandler {
+	return &WebhookQueryHandler{
+		subscriptions: subscriptions,
+		deliveries:    deliveries,
+	}
+}
@@ -0,0 +1,86 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"go-microservice-product-porto/internal/application/events"
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/domain/webhook"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+// Dispatcher turns relayed outbox events into pending webhook deliveries. It
+// only stages deliveries; sending them is left to the delivery worker so slow
+// partner endpoints never hold up the relay.
+type Dispatcher struct {
+	subscriptions webhook.SubscriptionRepository
+	deliveries    webhook.DeliveryRepository
+}
+
+func NewDispatcher(subscriptions webhook.SubscriptionRepository, deliveries webhook.DeliveryRepository) *Dispatcher {
+	return &Dispatcher{
+		subscriptions: subscriptions,
+		deliveries:    deliveries,
+	}
+}
+
+// Handle is an events.Handler. Deliveries are keyed by event ID, so seeing
+// the same envelope twice does not send it twice.
+func (d *Dispatcher) Handle(ctx context.Context, envelope *events.Envelope) error {
+	envelopes := []*events.Envelope{envelope}
+	if outOfStock(envelope) {
+		derived := *envelope
+		derived.ID = envelope.ID + ":out_of_stock"
+		derived.Type = webhook.EventOutOfStock
+		envelopes = append(envelopes, &derived)
+	}
+
+	for _, e := range envelopes {
+		if err := d.enqueue(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) enqueue(ctx context.Context, envelope *events.Envelope) error {
+	subscriptions, err := d.subscriptions.FindActiveByEventType(ctx, envelope.Type)
+	if err != nil {
+		return errors.StandardError(errors.EREPOSITORY, err)
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	payload, err := events.MarshalCloudEvent(envelope)
+	if err != nil {
+		return errors.StandardError(errors.EINTERNAL, err)
+	}
+
+	deliveries := make([]*webhook.Delivery, 0, len(subscriptions))
+	for _, sub := range subscriptions {
+		deliveries = append(deliveries, webhook.NewDelivery(sub.ID.Hex(), envelope.ID, envelope.Type, payload))
+	}
+
+	if err := d.deliveries.Enqueue(ctx, deliveries...); err != nil {
+		return errors.StandardError(errors.EREPOSITORY, fmt.Errorf("failed to enqueue %s deliveries: %w", envelope.Type, err))
+	}
+	return nil
+}
+
+// outOfStock reports whether the event took a product's stock to zero. Every
+// stock change is published as product.stock.updated, so that is the only
+// event worth inspecting.
+func outOfStock(envelope *events.Envelope) bool {
+	if envelope.Type != (product.ProductStockUpdatedEvent{}).GetEventType() {
+		return false
+	}
+
+	var event product.ProductStockUpdatedEvent
+	if err := json.Unmarshal(envelope.Data, &event); err != nil {
+		return false
+	}
+	return event.OldStock > 0 && event.NewStock == 0
+}
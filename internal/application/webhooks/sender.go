@@ -0,0 +1,123 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"go-microservice-product-porto/internal/domain/webhook"
+)
+
+const (
+	// SignatureHeader carries "sha256=" followed by the hex HMAC-SHA256 of
+	// the timestamp, a dot and the raw body, keyed by the subscription secret.
+	SignatureHeader = "X-Webhook-Signature"
+	// TimestampHeader is the Unix time the delivery was signed at, so
+	// receivers can reject replayed requests.
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// maxResponseBody bounds how much of a response is read before the
+// connection is reused.
+const maxResponseBody = 64 << 10
+
+// Request is one signed delivery attempt.
+type Request struct {
+	URL        string
+	Secret     string
+	DeliveryID string
+	EventType  string
+	Body       []byte
+}
+
+// Sender posts deliveries to subscriber endpoints. It returns the response
+// status, if any, and an error unless the endpoint answered with a 2xx.
+type Sender interface {
+	Send(ctx context.Context, req *Request) (int, error)
+}
+
+type HTTPSender struct {
+	client *http.Client
+}
+
+// NewHTTPSender builds a sender that refuses to connect to private,
+// loopback and link-local addresses. Subscription URLs are checked when they
+// are saved, but a public name can still resolve, or redirect, to an
+// internal address, so every connection is checked once it is resolved.
+func NewHTTPSender(timeout time.Duration) *HTTPSender {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: refusePrivateAddresses,
+	}
+	return &HTTPSender{
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				// A proxy would be dialled instead of the endpoint
+				Proxy:               nil,
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: timeout,
+				MaxIdleConns:        100,
+				IdleConnTimeout:     90 * time.Second,
+			},
+		},
+	}
+}
+
+func refusePrivateAddresses(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !webhook.IsPublicIP(ip) {
+		return fmt.Errorf("webhook endpoint address %s is not public", host)
+	}
+	return nil
+}
+
+func (s *HTTPSender) Send(ctx context.Context, req *Request) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build webhook request: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/cloudevents+json")
+	httpReq.Header.Set("User-Agent", "product-service-webhooks")
+	httpReq.Header.Set(EventHeader, req.EventType)
+	httpReq.Header.Set(DeliveryHeader, req.DeliveryID)
+	httpReq.Header.Set(TimestampHeader, timestamp)
+	httpReq.Header.Set(SignatureHeader, "sha256="+Sign(req.Secret, timestamp, req.Body))
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach webhook endpoint: %v", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook endpoint responded with %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign computes the hex HMAC-SHA256 signature of a delivery body.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,31 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPSenderRefusesPrivateAddresses(t *testing.T) {
+	reached := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	defer server.Close()
+
+	sender := NewHTTPSender(time.Second)
+	// localhost passes validation as a name would, so only the dial can stop it
+	url := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	for _, target := range []string{server.URL, url} {
+		status, err := sender.Send(context.Background(), &Request{URL: target, Secret: "secret", Body: []byte("{}")})
+		if err == nil || status != 0 {
+			t.Errorf("Send(%s) = %d, %v; want a refused connection", target, status, err)
+		}
+	}
+	if reached {
+		t.Error("the private endpoint was reached")
+	}
+}
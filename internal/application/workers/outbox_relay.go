@@ -0,0 +1,122 @@
+package workers
+
+import (
+	"context"
+	"time"
+
+	"go-microservice-product-porto/internal/application/outbox"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+const (
+	// relayBatchSize bounds how many messages one poll claims.
+	relayBatchSize = 100
+	// relayLease is how long a claimed message stays hidden from other relays.
+	relayLease = 30 * time.Second
+	// relayBaseBackoff and relayMaxBackoff bound the delay between attempts.
+	relayBaseBackoff = time.Second
+	relayMaxBackoff  = 10 * time.Minute
+)
+
+// OutboxRelay publishes committed outbox messages. A message is only marked
+// published after the publisher accepted it, so delivery is at least once.
+type OutboxRelay struct {
+	store       outbox.Store
+	publisher   outbox.EventPublisher
+	interval    time.Duration
+	maxAttempts int
+}
+
+func NewOutboxRelay(store outbox.Store, publisher outbox.EventPublisher, interval time.Duration, maxAttempts int) *OutboxRelay {
+	return &OutboxRelay{
+		store:       store,
+		publisher:   publisher,
+		interval:    interval,
+		maxAttempts: maxAttempts,
+	}
+}
+
+// Run relays on every tick until ctx is cancelled.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.relay(ctx)
+		}
+	}
+}
+
+func (r *OutboxRelay) relay(ctx context.Context) {
+	for {
+		messages, err := r.store.Claim(ctx, time.Now(), relayBatchSize, relayLease)
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Msg("failed to claim outbox messages")
+		}
+
+		for _, message := range messages {
+			r.publish(ctx, message)
+		}
+
+		// A short batch means the backlog is drained
+		if err != nil || len(messages) < relayBatchSize {
+			return
+		}
+	}
+}
+
+func (r *OutboxRelay) publish(ctx context.Context, message *outbox.Message) {
+	id := message.ID.Hex()
+
+	if err := r.publisher.Publish(ctx, message); err != nil {
+		attempts := message.Attempts + 1
+		dead := attempts >= r.maxAttempts
+
+		logEvent := logger.Warn()
+		if dead {
+			logEvent = logger.Error()
+		}
+		logEvent.
+			Str("message_id", id).
+			Str("event_type", message.EventType).
+			Int("attempts", attempts).
+			Bool("dead", dead).
+			Err(err).
+			Msg("failed to publish outbox message")
+
+		if markErr := r.store.MarkFailed(ctx, id, err.Error(), time.Now().Add(backoff(attempts, relayBaseBackoff, relayMaxBackoff)), dead); markErr != nil {
+			logger.Error().
+				Str("message_id", id).
+				Err(markErr).
+				Msg("failed to record outbox publish failure")
+		}
+		return
+	}
+
+	if err := r.store.MarkPublished(ctx, id); err != nil {
+		// The lease expires and the message is published again, which
+		// at-least-once consumers tolerate
+		logger.Error().
+			Str("message_id", id).
+			Err(err).
+			Msg("failed to mark outbox message published")
+	}
+}
+
+// backoff doubles the delay from base with every attempt, up to max.
+func backoff(attempts int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
@@ -0,0 +1,192 @@
+package workers
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"go-microservice-product-porto/internal/application/events"
+	"go-microservice-product-porto/internal/application/outbox"
+	"go-microservice-product-porto/internal/application/webhooks"
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/domain/webhook"
+	"go-microservice-product-porto/internal/infrastructure/persistence/memory"
+)
+
+// fakeSubscriptions has one subscription, to every event type. A nil
+// subscription is not found.
+type fakeSubscriptions struct {
+	webhook.SubscriptionRepository
+	subscription *webhook.Subscription
+}
+
+func (f *fakeSubscriptions) FindByID(context.Context, string) (*webhook.Subscription, error) {
+	if f.subscription == nil {
+		return nil, webhook.ErrSubscriptionNotFound
+	}
+	return f.subscription, nil
+}
+
+func (f *fakeSubscriptions) FindActiveByEventType(context.Context, string) ([]*webhook.Subscription, error) {
+	return []*webhook.Subscription{f.subscription}, nil
+}
+
+// fakeDeliveries fails the first failures calls to Enqueue and records how
+// deliveries are marked.
+type fakeDeliveries struct {
+	webhook.DeliveryRepository
+	failures  int
+	enqueued  []*webhook.Delivery
+	delivered []int
+	failed    []markedFailure
+}
+
+type markedFailure struct {
+	status        int
+	nextAttemptAt time.Time
+	dead          bool
+}
+
+func (f *fakeDeliveries) MarkDelivered(_ context.Context, _ string, responseStatus int) error {
+	f.delivered = append(f.delivered, responseStatus)
+	return nil
+}
+
+func (f *fakeDeliveries) MarkFailed(_ context.Context, _ string, responseStatus int, _ string, nextAttemptAt time.Time, dead bool) error {
+	f.failed = append(f.failed, markedFailure{status: responseStatus, nextAttemptAt: nextAttemptAt, dead: dead})
+	return nil
+}
+
+func (f *fakeDeliveries) Enqueue(_ context.Context, deliveries ...*webhook.Delivery) error {
+	if f.failures > 0 {
+		f.failures--
+		return stderrors.New("enqueue failed")
+	}
+	f.enqueued = append(f.enqueued, deliveries...)
+	return nil
+}
+
+func TestOutboxRelayRetriesFailedWebhookEnqueue(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewOutboxStore()
+	message, err := outbox.NewMessage(product.ProductCreatedEvent{Product: product.NewProduct("Widget", "", 1, 1)})
+	if err != nil {
+		t.Fatalf("NewMessage: %v", err)
+	}
+	if err := store.Add(ctx, message); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	subscriptions := &fakeSubscriptions{subscription: webhook.NewSubscription("https://partner.example.com/hook", nil, "secret")}
+	deliveries := &fakeDeliveries{failures: 1}
+	dispatcher := webhooks.NewDispatcher(subscriptions, deliveries)
+	relay := NewOutboxRelay(store, events.NewBusPublisher(events.NewMemoryBus(), "test", dispatcher.Handle), time.Second, 5)
+
+	relay.relay(ctx)
+	if len(deliveries.enqueued) != 0 {
+		t.Fatalf("%d deliveries enqueued by the failed attempt, want 0", len(deliveries.enqueued))
+	}
+
+	// The failed attempt is backed off rather than counted as published
+	retry, err := store.Claim(ctx, time.Now().Add(time.Minute), relayBatchSize, relayLease)
+	if err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	if len(retry) != 1 || retry[0].Attempts != 1 {
+		t.Fatalf("claimed %d messages for retry, want 1 with 1 attempt", len(retry))
+	}
+
+	relay.publish(ctx, retry[0])
+	if len(deliveries.enqueued) != 1 || deliveries.enqueued[0].EventID != message.ID.Hex() {
+		t.Fatalf("enqueued %d deliveries after the retry, want 1 for event %s", len(deliveries.enqueued), message.ID.Hex())
+	}
+	left, err := store.Claim(ctx, time.Now().Add(time.Hour), relayBatchSize, relayLease)
+	if err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	if len(left) != 0 {
+		t.Errorf("%d messages left after the retry, want 0", len(left))
+	}
+}
+
+// failingPublisher rejects every message.
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(context.Context, *outbox.Message) error {
+	return stderrors.New("broker unavailable")
+}
+
+// recordingStore records every failure the relay marks.
+type recordingStore struct {
+	*memory.OutboxStore
+	failed []markedFailure
+}
+
+func (s *recordingStore) MarkFailed(ctx context.Context, id string, lastError string, nextAttemptAt time.Time, dead bool) error {
+	s.failed = append(s.failed, markedFailure{nextAttemptAt: nextAttemptAt, dead: dead})
+	return s.OutboxStore.MarkFailed(ctx, id, lastError, nextAttemptAt, dead)
+}
+
+func TestOutboxRelayBacksOffThenDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	store := &recordingStore{OutboxStore: memory.NewOutboxStore()}
+	message, err := outbox.NewMessage(product.ProductDeletedEvent{ProductID: "p-1"})
+	if err != nil {
+		t.Fatalf("NewMessage: %v", err)
+	}
+	if err := store.Add(ctx, message); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	relay := NewOutboxRelay(store, failingPublisher{}, time.Second, 3)
+
+	wantDelays := []time.Duration{relayBaseBackoff, 2 * relayBaseBackoff, 4 * relayBaseBackoff}
+	for attempt, wantDelay := range wantDelays {
+		claimed, err := store.Claim(ctx, time.Now().Add(time.Hour), relayBatchSize, relayLease)
+		if err != nil {
+			t.Fatalf("Claim: %v", err)
+		}
+		if len(claimed) != 1 || claimed[0].Attempts != attempt {
+			t.Fatalf("attempt %d: claimed %d messages, want 1 with %d attempts", attempt+1, len(claimed), attempt)
+		}
+
+		before := time.Now()
+		relay.publish(ctx, claimed[0])
+
+		got := store.failed[attempt]
+		if delay := got.nextAttemptAt.Sub(before); delay < wantDelay || delay > wantDelay+time.Second {
+			t.Errorf("attempt %d: retried after %v, want %v", attempt+1, delay, wantDelay)
+		}
+		if wantDead := attempt == len(wantDelays)-1; got.dead != wantDead {
+			t.Errorf("attempt %d: dead = %v, want %v", attempt+1, got.dead, wantDead)
+		}
+	}
+
+	// A dead message is parked, not claimed again
+	claimed, err := store.Claim(ctx, time.Now().Add(24*time.Hour), relayBatchSize, relayLease)
+	if err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	if len(claimed) != 0 {
+		t.Errorf("claimed %d dead messages, want 0", len(claimed))
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Second},
+		{attempts: 2, want: 2 * time.Second},
+		{attempts: 4, want: 8 * time.Second},
+		{attempts: 7, want: 10 * time.Second},
+		{attempts: 100, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := backoff(tt.attempts, time.Second, 10*time.Second); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package workers
+
+import (
+	"context"
+	"time"
+
+	"go-microservice-product-porto/internal/application/commands"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+// sweepBatchSize bounds how many reservations one sweep expires.
+const sweepBatchSize = 100
+
+// ReservationSweeper periodically releases reservations whose hold expired.
+type ReservationSweeper struct {
+	commandHandler *commands.ReservationCommandHandler
+	interval       time.Duration
+}
+
+func NewReservationSweeper(commandHandler *commands.ReservationCommandHandler, interval time.Duration) *ReservationSweeper {
+	return &ReservationSweeper{
+		commandHandler: commandHandler,
+		interval:       interval,
+	}
+}
+
+// Run sweeps on every tick until ctx is cancelled.
+func (s *ReservationSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+func (s *ReservationSweeper) sweep(ctx context.Context) {
+	for {
+		expired, err := s.commandHandler.HandleExpireReservations(ctx, commands.ExpireReservationsCommand{
+			Now:   time.Now(),
+			Limit: sweepBatchSize,
+		})
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Msg("failed to sweep expired reservations")
+			return
+		}
+
+		if expired > 0 {
+			logger.Info().
+				Int("expired", expired).
+				Msg("expired reservations released")
+		}
+
+		// A short batch means the backlog is drained
+		if expired < sweepBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package workers
+
+import (
+	"context"
+	stderrors "errors"
+	"sync"
+	"time"
+
+	"go-microservice-product-porto/internal/application/webhooks"
+	"go-microservice-product-porto/internal/domain/webhook"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+const (
+	// deliveryBatchSize bounds how many deliveries one poll claims.
+	deliveryBatchSize = 100
+	// deliveryLease is how long a claimed delivery stays hidden from other
+	// workers. It must outlast the sender's timeout.
+	deliveryLease = 2 * time.Minute
+	// deliveryBaseBackoff and deliveryMaxBackoff bound the delay between
+	// attempts; partner outages tend to last longer than broker hiccups.
+	deliveryBaseBackoff = 5 * time.Second
+	deliveryMaxBackoff  = time.Hour
+)
+
+// WebhookDeliveryWorker sends pending webhook deliveries through a fixed pool
+// of goroutines, retrying failures with exponential backoff until they are
+// delivered or dead.
+type WebhookDeliveryWorker struct {
+	deliveries    webhook.DeliveryRepository
+	subscriptions webhook.SubscriptionRepository
+	sender        webhooks.Sender
+	interval      time.Duration
+	poolSize      int
+	maxAttempts   int
+}
+
+func NewWebhookDeliveryWorker(deliveries webhook.DeliveryRepository, subscriptions webhook.SubscriptionRepository, sender webhooks.Sender, interval time.Duration, poolSize, maxAttempts int) *WebhookDeliveryWorker {
+	return &WebhookDeliveryWorker{
+		deliveries:    deliveries,
+		subscriptions: subscriptions,
+		sender:        sender,
+		interval:      interval,
+		poolSize:      poolSize,
+		maxAttempts:   maxAttempts,
+	}
+}
+
+// Run polls on every tick until ctx is cancelled, then waits for in-flight
+// deliveries to finish.
+func (w *WebhookDeliveryWorker) Run(ctx context.Context) {
+	jobs := make(chan *webhook.Delivery)
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.poolSize; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for delivery := range jobs {
+				w.deliver(ctx, delivery)
+			}
+		}()
+	}
+	defer wg.Wait()
+	defer close(jobs)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.poll(ctx, jobs)
+		}
+	}
+}
+
+func (w *WebhookDeliveryWorker) poll(ctx context.Context, jobs chan<- *webhook.Delivery) {
+	for {
+		deliveries, err := w.deliveries.Claim(ctx, time.Now(), deliveryBatchSize, deliveryLease)
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Msg("failed to claim webhook deliveries")
+		}
+
+		for _, delivery := range deliveries {
+			select {
+			case jobs <- delivery:
+			case <-ctx.Done():
+				// Unsent claims become due again once their lease expires
+				return
+			}
+		}
+
+		// A short batch means the backlog is drained
+		if err != nil || len(deliveries) < deliveryBatchSize {
+			return
+		}
+	}
+}
+
+func (w *WebhookDeliveryWorker) deliver(ctx context.Context, delivery *webhook.Delivery) {
+	id := delivery.ID.Hex()
+
+	// Load the subscription on every attempt so rotated secrets and
+	// deactivations take effect for queued deliveries too
+	sub, err := w.subscriptions.FindByID(ctx, delivery.SubscriptionID)
+	if err != nil {
+		w.fail(ctx, delivery, 0, err, stderrors.Is(err, webhook.ErrSubscriptionNotFound))
+		return
+	}
+	if !sub.Active {
+		w.fail(ctx, delivery, 0, webhook.ErrSubscriptionInactive, true)
+		return
+	}
+
+	status, err := w.sender.Send(ctx, &webhooks.Request{
+		URL:        sub.URL,
+		Secret:     sub.Secret,
+		DeliveryID: id,
+		EventType:  delivery.EventType,
+		Body:       delivery.Payload,
+	})
+	if err != nil {
+		w.fail(ctx, delivery, status, err, false)
+		return
+	}
+
+	if err := w.deliveries.MarkDelivered(ctx, id, status); err != nil {
+		// The lease expires and the delivery is sent again, which receivers
+		// must tolerate anyway
+		logger.Error().
+			Str("delivery_id", id).
+			Err(err).
+			Msg("failed to mark webhook delivery delivered")
+	}
+}
+
+// fail records a failed attempt. Deliveries are parked as dead once they run
+// out of attempts, or straight away when retrying cannot help.
+func (w *WebhookDeliveryWorker) fail(ctx context.Context, delivery *webhook.Delivery, status int, err error, dead bool) {
+	id := delivery.ID.Hex()
+	attempts := delivery.Attempts + 1
+	dead = dead || attempts >= w.maxAttempts
+
+	logEvent := logger.Warn()
+	if dead {
+		logEvent = logger.Error()
+	}
+	logEvent.
+		Str("delivery_id", id).
+		Str("subscription_id", delivery.SubscriptionID).
+		Str("event_type", delivery.EventType).
+		Int("status", status).
+		Int("attempts", attempts).
+		Bool("dead", dead).
+		Err(err).
+		Msg("failed to deliver webhook")
+
+	nextAttemptAt := time.Now().Add(backoff(attempts, deliveryBaseBackoff, deliveryMaxBackoff))
+	if markErr := w.deliveries.MarkFailed(ctx, id, status, err.Error(), nextAttemptAt, dead); markErr != nil {
+		logger.Error().
+			Str("delivery_id", id).
+			Err(markErr).
+			Msg("failed to record webhook delivery failure")
+	}
+}
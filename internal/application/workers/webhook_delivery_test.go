@@ -0,0 +1,125 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"go-microservice-product-porto/internal/application/webhooks"
+	"go-microservice-product-porto/internal/domain/webhook"
+)
+
+// fakeSender answers every request with status and err.
+type fakeSender struct {
+	status   int
+	err      error
+	requests []*webhooks.Request
+}
+
+func (s *fakeSender) Send(_ context.Context, req *webhooks.Request) (int, error) {
+	s.requests = append(s.requests, req)
+	return s.status, s.err
+}
+
+func TestWebhookDeliveryWorkerDeliver(t *testing.T) {
+	errRefused := stderrors.New("endpoint answered 503")
+
+	tests := []struct {
+		name          string
+		subscription  func() *webhook.Subscription
+		sender        *fakeSender
+		attempts      int
+		wantSent      int
+		wantDelivered bool
+		wantDead      bool
+		wantDelay     time.Duration
+	}{
+		{
+			name:          "delivered",
+			sender:        &fakeSender{status: 204},
+			wantSent:      1,
+			wantDelivered: true,
+		},
+		{
+			name:      "failure is retried",
+			sender:    &fakeSender{status: 503, err: errRefused},
+			attempts:  1,
+			wantSent:  1,
+			wantDelay: 2 * deliveryBaseBackoff,
+		},
+		{
+			name:     "last attempt is dead",
+			sender:   &fakeSender{status: 503, err: errRefused},
+			attempts: 4,
+			wantSent: 1,
+			wantDead: true,
+		},
+		{
+			name: "inactive subscription is dead",
+			subscription: func() *webhook.Subscription {
+				sub := webhook.NewSubscription("https://partner.example.com/hook", nil, "secret")
+				sub.Active = false
+				return sub
+			},
+			sender:   &fakeSender{status: 204},
+			wantDead: true,
+		},
+		{
+			name:         "deleted subscription is dead",
+			subscription: func() *webhook.Subscription { return nil },
+			sender:       &fakeSender{status: 204},
+			wantDead:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			sub := webhook.NewSubscription("https://partner.example.com/hook", nil, "secret")
+			if tt.subscription != nil {
+				sub = tt.subscription()
+			}
+			deliveries := &fakeDeliveries{}
+			worker := NewWebhookDeliveryWorker(deliveries, &fakeSubscriptions{subscription: sub}, tt.sender, time.Second, 1, 5)
+
+			delivery := webhook.NewDelivery("sub-1", "event-1", "product.created", json.RawMessage(`{}`))
+			delivery.Attempts = tt.attempts
+			before := time.Now()
+			worker.deliver(ctx, delivery)
+
+			if len(tt.sender.requests) != tt.wantSent {
+				t.Fatalf("sent %d requests, want %d", len(tt.sender.requests), tt.wantSent)
+			}
+			if tt.wantSent > 0 {
+				req := tt.sender.requests[0]
+				if req.URL != sub.URL || req.Secret != sub.Secret || req.DeliveryID != delivery.ID.Hex() {
+					t.Errorf("request = %+v, want the subscription's URL and secret for delivery %s", req, delivery.ID.Hex())
+				}
+			}
+
+			if tt.wantDelivered {
+				if len(deliveries.delivered) != 1 || deliveries.delivered[0] != tt.sender.status || len(deliveries.failed) != 0 {
+					t.Errorf("delivered %v, failed %v, want delivered with %d", deliveries.delivered, deliveries.failed, tt.sender.status)
+				}
+				return
+			}
+			if len(deliveries.failed) != 1 || len(deliveries.delivered) != 0 {
+				t.Fatalf("delivered %v, failed %v, want one failure", deliveries.delivered, deliveries.failed)
+			}
+			got := deliveries.failed[0]
+			if tt.wantSent > 0 && got.status != tt.sender.status {
+				t.Errorf("recorded status %d, want %d", got.status, tt.sender.status)
+			}
+			if got.dead != tt.wantDead {
+				t.Errorf("dead = %v, want %v", got.dead, tt.wantDead)
+			}
+			if tt.wantDelay > 0 {
+				if delay := got.nextAttemptAt.Sub(before); delay < tt.wantDelay || delay > tt.wantDelay+time.Second {
+					t.Errorf("retried after %v, want %v", delay, tt.wantDelay)
+				}
+			}
+		})
+	}
+}
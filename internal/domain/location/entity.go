@@ -0,0 +1,54 @@
+package location
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Location is a warehouse or store that holds stock.
+type Location struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Code      string             `bson:"code" json:"code"`
+	Name      string             `bson:"name" json:"name"`
+	Address   string             `bson:"address" json:"address"`
+	Active    bool               `bson:"active" json:"active"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+func NewLocation(code, name, address string) *Location {
+	return &Location{
+		ID:        primitive.NewObjectID(),
+		Code:      code,
+		Name:      name,
+		Address:   address,
+		Active:    true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+func (l *Location) Update(code, name, address string, active bool) {
+	l.Code = code
+	l.Name = name
+	l.Address = address
+	l.Active = active
+	l.UpdatedAt = time.Now()
+}
+
+func (l *Location) IsValid() bool {
+	return len(l.FieldErrors()) == 0
+}
+
+// FieldErrors describes every invalid field, keyed by its JSON name.
+func (l *Location) FieldErrors() map[string]string {
+	fields := map[string]string{}
+	if l.Code == "" {
+		fields["code"] = "is required"
+	}
+	if l.Name == "" {
+		fields["name"] = "is required"
+	}
+	return fields
+}
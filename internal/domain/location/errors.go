@@ -0,0 +1,11 @@
+package location
+
+import "errors"
+
+var (
+	ErrLocationNotFound      = errors.New("location not found")
+	ErrInvalidLocation       = errors.New("invalid location")
+	ErrLocationAlreadyExists = errors.New("location already exists")
+	ErrLocationInactive      = errors.New("location is inactive")
+	ErrLocationInUse         = errors.New("location still holds stock")
+)
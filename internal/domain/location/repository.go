@@ -0,0 +1,11 @@
+package location
+
+import "context"
+
+type Repository interface {
+	Create(context.Context, *Location) error
+	FindByID(context.Context, string) (*Location, error)
+	FindAll(context.Context) ([]*Location, error)
+	Update(context.Context, *Location) error
+	Delete(context.Context, string) error
+}
@@ -0,0 +1,46 @@
+package movement
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Type string
+
+const (
+	TypeCreate             Type = "create"
+	TypeSet                Type = "set"
+	TypeAdjust             Type = "adjust"
+	TypeReservationConfirm Type = "reservation_confirm"
+	TypeTransfer           Type = "transfer"
+)
+
+// Movement is an immutable ledger entry recording a single stock mutation.
+// StockBefore and StockAfter are the quantities at LocationID when it is set,
+// and the product's total stock otherwise.
+type Movement struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProductID   string             `bson:"product_id" json:"product_id"`
+	LocationID  string             `bson:"location_id,omitempty" json:"location_id,omitempty"`
+	Type        Type               `bson:"type" json:"type"`
+	Quantity    int                `bson:"quantity" json:"quantity"`
+	StockBefore int                `bson:"stock_before" json:"stock_before"`
+	StockAfter  int                `bson:"stock_after" json:"stock_after"`
+	Reason      string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	Reference   string             `bson:"reference,omitempty" json:"reference,omitempty"`
+	Actor       string             `bson:"actor" json:"actor"`
+	OccurredAt  time.Time          `bson:"occurred_at" json:"occurred_at"`
+}
+
+func NewMovement(productID string, movementType Type, stockBefore, stockAfter int) *Movement {
+	return &Movement{
+		ID:          primitive.NewObjectID(),
+		ProductID:   productID,
+		Type:        movementType,
+		Quantity:    stockAfter - stockBefore,
+		StockBefore: stockBefore,
+		StockAfter:  stockAfter,
+		OccurredAt:  time.Now(),
+	}
+}
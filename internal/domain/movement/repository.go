@@ -0,0 +1,24 @@
+package movement
+
+import (
+	"context"
+	"time"
+)
+
+// Filter narrows a product's movement history. Zero From/To leave that end
+// of the range open.
+type Filter struct {
+	ProductID string
+	From      time.Time
+	To        time.Time
+	Page      int
+	PageSize  int
+}
+
+// Repository is append-only: movements are never updated or deleted.
+type Repository interface {
+	Append(context.Context, *Movement) error
+	// FindByProduct returns a page of movements, newest first, and the total
+	// number of movements matching the filter.
+	FindByProduct(context.Context, Filter) ([]*Movement, int64, error)
+}
@@ -1,6 +1,7 @@
 package product
 
 import (
+	"encoding/json"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -10,10 +11,23 @@ type Product struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Name        string             `bson:"name" json:"name"`
 	Description string             `bson:"description" json:"description"`
-	Price       float64            `bson:"price" json:"price"`
-	Stock       int                `bson:"stock" json:"stock"`
-	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+	// Price and Currency are stored together as Money, which the MongoDB
+	// repository maps itself. An empty Currency is the catalog's own.
+	Price    float64 `bson:"-" json:"price"`
+	Currency string  `bson:"-" json:"currency,omitempty"`
+	Stock    int     `bson:"stock" json:"stock"`
+	Reserved int     `bson:"reserved" json:"reserved"`
+	// StockLevels holds the quantity stored at each location, keyed by
+	// location ID. Stock is the total; anything not assigned to a location
+	// is unallocated.
+	StockLevels map[string]int `bson:"stock_levels,omitempty" json:"stock_levels"`
+	// ReservedLevels holds the quantity reserved at each location, keyed by
+	// location ID. Reserved units it doesn't count are held in the
+	// unallocated stock.
+	ReservedLevels map[string]int `bson:"reserved_levels,omitempty" json:"reserved_levels,omitempty"`
+	Version        int64          `bson:"version" json:"version"`
+	CreatedAt      time.Time      `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time      `bson:"updated_at" json:"updated_at"`
 }
 
 func NewProduct(name, description string, price float64, stock int) *Product {
@@ -22,11 +36,17 @@ func NewProduct(name, description string, price float64, stock int) *Product {
 		Description: description,
 		Price:       price,
 		Stock:       stock,
+		Version:     1,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 }
 
+// Money returns the price together with its currency.
+func (p *Product) Money() Money {
+	return NewMoney(p.Price, p.Currency)
+}
+
 func (p *Product) UpdateStock(newStock int) error {
 	if newStock < 0 {
 		return ErrInvalidStock
@@ -36,6 +56,108 @@ func (p *Product) UpdateStock(newStock int) error {
 	return nil
 }
 
+// Update replaces the product's mutable fields.
+func (p *Product) Update(name, description string, price float64, stock int) {
+	p.Name = name
+	p.Description = description
+	p.Price = price
+	p.Stock = stock
+	p.UpdatedAt = time.Now()
+}
+
+// Available is the stock not held by active reservations.
+func (p *Product) Available() int {
+	if available := p.Stock - p.Reserved; available > 0 {
+		return available
+	}
+	return 0
+}
+
+// AllocatedStock is the stock assigned to locations.
+func (p *Product) AllocatedStock() int {
+	allocated := 0
+	for _, quantity := range p.StockLevels {
+		allocated += quantity
+	}
+	return allocated
+}
+
+// UnallocatedStock is the stock not assigned to any location.
+func (p *Product) UnallocatedStock() int {
+	return p.Stock - p.AllocatedStock()
+}
+
+// ReservedAt is the quantity reserved at a location, or in the unallocated
+// stock when locationID is empty.
+func (p *Product) ReservedAt(locationID string) int {
+	if locationID != "" {
+		return p.ReservedLevels[locationID]
+	}
+	unallocated := p.Reserved
+	for _, quantity := range p.ReservedLevels {
+		unallocated -= quantity
+	}
+	if unallocated > 0 {
+		return unallocated
+	}
+	return 0
+}
+
+// SetLocationStock sets the quantity held at a location, moving the total by
+// the same amount, and returns the previous quantity there.
+func (p *Product) SetLocationStock(locationID string, quantity int) int {
+	if p.StockLevels == nil {
+		p.StockLevels = map[string]int{}
+	}
+	previous := p.StockLevels[locationID]
+	p.StockLevels[locationID] = quantity
+	p.Stock += quantity - previous
+	p.UpdatedAt = time.Now()
+	return previous
+}
+
+// MarshalJSON adds the computed available and unallocated quantities to the
+// product's JSON.
+func (p Product) MarshalJSON() ([]byte, error) {
+	type product Product
+	return json.Marshal(struct {
+		product
+		Available   int `json:"available"`
+		Unallocated int `json:"unallocated"`
+	}{product(p), p.Available(), p.UnallocatedStock()})
+}
+
 func (p *Product) IsValid() bool {
-	return p.Name != "" && p.Price > 0 && p.Stock >= 0
+	return len(p.FieldErrors()) == 0
+}
+
+// FieldErrors describes every invalid field, keyed by its JSON name.
+func (p *Product) FieldErrors() map[string]string {
+	fields := map[string]string{}
+	if p.Name == "" {
+		fields["name"] = "is required"
+	}
+	if p.Price <= 0 {
+		fields["price"] = "must be greater than 0"
+	}
+	if p.Stock < 0 {
+		fields["stock"] = "must not be negative"
+	} else if p.Stock < p.Reserved {
+		fields["stock"] = "must not be below the reserved quantity"
+	} else if p.UnallocatedStock() < 0 {
+		fields["stock"] = "must not be below the quantity held at locations"
+	} else if p.UnallocatedStock() < p.ReservedAt("") {
+		fields["stock"] = "must not leave less unallocated stock than is reserved from it"
+	}
+	for locationID, quantity := range p.StockLevels {
+		if quantity < 0 {
+			fields["stock_levels."+locationID] = "must not be negative"
+		}
+	}
+	for locationID, reserved := range p.ReservedLevels {
+		if quantity := p.StockLevels[locationID]; quantity >= 0 && quantity < reserved {
+			fields["stock_levels."+locationID] = "must not be below the quantity reserved there"
+		}
+	}
+	return fields
 }
@@ -7,4 +7,8 @@ var (
 	ErrInvalidProduct       = errors.New("invalid product")
 	ErrInvalidStock         = errors.New("invalid stock value")
 	ErrProductAlreadyExists = errors.New("product already exists")
+	ErrVersionConflict      = errors.New("product was modified concurrently")
+	ErrInsufficientStock    = errors.New("insufficient stock")
+	ErrInvalidAdjustment    = errors.New("invalid stock adjustment")
+	ErrInvalidTransfer      = errors.New("invalid stock transfer")
 )
@@ -2,30 +2,73 @@ package product
 
 type Event interface {
 	GetEventType() string
+	// GetAggregateID identifies the entity the event is about.
+	GetAggregateID() string
 }
 
 type ProductCreatedEvent struct {
-	Product *Product
+	Product *Product `json:"product"`
 }
 
 func (e ProductCreatedEvent) GetEventType() string {
 	return "product.created"
 }
 
+func (e ProductCreatedEvent) GetAggregateID() string {
+	return e.Product.ID.Hex()
+}
+
+type ProductUpdatedEvent struct {
+	Product *Product `json:"product"`
+	Changes []string `json:"changes"`
+}
+
+func (e ProductUpdatedEvent) GetEventType() string {
+	return "product.updated"
+}
+
+func (e ProductUpdatedEvent) GetAggregateID() string {
+	return e.Product.ID.Hex()
+}
+
 type ProductStockUpdatedEvent struct {
-	Product  *Product
-	OldStock int
-	NewStock int
+	Product  *Product `json:"product"`
+	OldStock int      `json:"old_stock"`
+	NewStock int      `json:"new_stock"`
+	Reason   string   `json:"reason,omitempty"`
 }
 
 func (e ProductStockUpdatedEvent) GetEventType() string {
 	return "product.stock.updated"
 }
 
+func (e ProductStockUpdatedEvent) GetAggregateID() string {
+	return e.Product.ID.Hex()
+}
+
+type ProductStockTransferredEvent struct {
+	Product        *Product `json:"product"`
+	FromLocationID string   `json:"from_location_id"`
+	ToLocationID   string   `json:"to_location_id"`
+	Quantity       int      `json:"quantity"`
+}
+
+func (e ProductStockTransferredEvent) GetEventType() string {
+	return "product.stock.transferred"
+}
+
+func (e ProductStockTransferredEvent) GetAggregateID() string {
+	return e.Product.ID.Hex()
+}
+
 type ProductDeletedEvent struct {
-	ProductID string
+	ProductID string `json:"product_id"`
 }
 
 func (e ProductDeletedEvent) GetEventType() string {
 	return "product.deleted"
 }
+
+func (e ProductDeletedEvent) GetAggregateID() string {
+	return e.ProductID
+}
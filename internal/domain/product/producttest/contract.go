@@ -0,0 +1,817 @@
+// Package producttest holds the behaviour every product.Repository must share,
+// as a test suite each implementation runs against itself.
+package producttest
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+// RunRepositoryContract runs the contract against repositories made by
+// newRepo. Every subtest gets a fresh, empty repository.
+func RunRepositoryContract(t *testing.T, newRepo func(t *testing.T) product.Repository) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo product.Repository)
+	}{
+		{"CreateAndFind", testCreateAndFind},
+		{"CreateDuplicate", testCreateDuplicate},
+		{"FindByIDErrors", testFindByIDErrors},
+		{"FindAllPaging", testFindAllPaging},
+		{"FindAllSorting", testFindAllSorting},
+		{"FindPage", testFindPage},
+		{"Count", testCount},
+		{"Update", testUpdate},
+		{"Delete", testDelete},
+		{"Search", testSearch},
+		{"AdjustStock", testAdjustStock},
+		{"TransferStock", testTransferStock},
+		{"HasStockAt", testHasStockAt},
+		{"ReserveAndRelease", testReserveAndRelease},
+		{"ReservedAtLocation", testReservedAtLocation},
+		{"ConfirmAllocatedReservation", testConfirmAllocatedReservation},
+		{"ConcurrentReserve", testConcurrentReserve},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.run(t, newRepo(t))
+		})
+	}
+}
+
+func testCreateAndFind(t *testing.T, repo product.Repository) {
+	ctx := context.Background()
+	p := product.NewProduct("Widget", "A widget", 9.5, 4)
+	p.Version = 0
+
+	if err := repo.Create(ctx, p); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if p.ID.IsZero() {
+		t.Fatal("Create left the ID unset")
+	}
+	if p.Version != 1 {
+		t.Errorf("version = %d, want 1", p.Version)
+	}
+
+	found, err := repo.FindByID(ctx, p.ID.Hex())
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if found.ID != p.ID || found.Name != "Widget" || found.Description != "A widget" ||
+		found.Price != 9.5 || found.Stock != 4 || found.Version != 1 {
+		t.Errorf("found %+v, want %+v", found, p)
+	}
+	if d := found.CreatedAt.Sub(p.CreatedAt); d < -time.Millisecond || d > time.Millisecond {
+		t.Errorf("created_at = %v, want %v", found.CreatedAt, p.CreatedAt)
+	}
+
+	// Changing a returned product must not change the stored one
+	found.Name = "Changed"
+	again, err := repo.FindByID(ctx, p.ID.Hex())
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if again.Name != "Widget" {
+		t.Errorf("name = %q after editing a returned copy, want %q", again.Name, "Widget")
+	}
+}
+
+func testCreateDuplicate(t *testing.T, repo product.Repository) {
+	ctx := context.Background()
+	p := create(t, repo, "Widget", 1, 1)
+
+	duplicate := product.NewProduct("Other", "", 2, 2)
+	duplicate.ID = p.ID
+	expectCode(t, repo.Create(ctx, duplicate), errors.ECONFLICT)
+
+	// Names are unique too
+	sameName := product.NewProduct("Widget", "", 2, 2)
+	expectAlreadyExists(t, repo.Create(ctx, sameName))
+}
+
+func testFindByIDErrors(t *testing.T, repo product.Repository) {
+	ctx := context.Background()
+
+	_, err := repo.FindByID(ctx, "not-an-id")
+	expectCode(t, err, errors.EINVALID)
+
+	_, err = repo.FindByID(ctx, primitive.NewObjectID().Hex())
+	expectCode(t, err, errors.ENOTFOUND)
+}
+
+func testFindAllPaging(t *testing.T, repo product.Repository) {
+	ctx := context.Background()
+
+	products, total, err := repo.FindAll(ctx, 1, 10, "", "")
+	if err != nil {
+		t.Fatalf("FindAll: %v", err)
+	}
+	if total != 0 || len(products) != 0 {
+		t.Errorf("empty repository: got %d products, total %d", len(products), total)
+	}
+
+	var ids []primitive.ObjectID
+	for i := 0; i < 7; i++ {
+		ids = append(ids, create(t, repo, fmt.Sprintf("product-%d", i), float64(i+1), i).ID)
+	}
+
+	// Without a sort field pages follow the ID
+	pages := [][]primitive.ObjectID{ids[0:3], ids[3:6], ids[6:7], nil}
+	for i, want := range pages {
+		page := i + 1
+		products, total, err := repo.FindAll(ctx, page, 3, "", "")
+		if err != nil {
+			t.Fatalf("FindAll page %d: %v", page, err)
+		}
+		if total != 7 {
+			t.Errorf("page %d: total = %d, want 7", page, total)
+		}
+		if len(products) != len(want) {
+			t.Fatalf("page %d: got %d products, want %d", page, len(products), len(want))
+		}
+		for j, p := range products {
+			if p.ID != want[j] {
+				t.Errorf("page %d, item %d: got %s, want %s", page, j, p.ID.Hex(), want[j].Hex())
+			}
+		}
+	}
+
+	// An unknown sort field still pages through every product
+	products, total, err = repo.FindAll(ctx, 1, 10, "color", "asc")
+	if err != nil {
+		t.Fatalf("FindAll unknown sort: %v", err)
+	}
+	if total != 7 || len(products) != 7 {
+		t.Errorf("unknown sort: got %d products, total %d, want 7", len(products), total)
+	}
+}
+
+func testFindAllSorting(t *testing.T, repo product.Repository) {
+	ctx := context.Background()
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	for i, spec := range []struct {
+		name  string
+		price float64
+		stock int
+	}{
+		{"banana", 3, 10},
+		{"apple", 1, 30},
+		{"cherry", 2, 20},
+	} {
+		p := product.NewProduct(spec.name, "", spec.price, spec.stock)
+		p.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		if err := repo.Create(ctx, p); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	tests := []struct {
+		sortBy, sortDir string
+		want            []string
+	}{
+		{"name", "asc", []string{"apple", "banana", "cherry"}},
+		{"name", "desc", []string{"cherry", "banana", "apple"}},
+		{"name", "", []string{"apple", "banana", "cherry"}},
+		{"price", "asc", []string{"apple", "cherry", "banana"}},
+		{"price", "desc", []string{"banana", "cherry", "apple"}},
+		{"stock", "asc", []string{"banana", "cherry", "apple"}},
+		{"stock", "DESC", []string{"apple", "cherry", "banana"}},
+		{"created_at", "asc", []string{"banana", "apple", "cherry"}},
+		{"created_at", "desc", []string{"cherry", "apple", "banana"}},
+	}
+
+	for _, tt := range tests {
+		products, _, err := repo.FindAll(ctx, 1, 10, tt.sortBy, tt.sortDir)
+		if err != nil {
+			t.Fatalf("FindAll %s %s: %v", tt.sortBy, tt.sortDir, err)
+		}
+		if got := names(products); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("sort %s %s: got %v, want %v", tt.sortBy, tt.sortDir, got, tt.want)
+		}
+	}
+
+	products, _, err := repo.FindAll(ctx, 2, 2, "name", "asc")
+	if err != nil {
+		t.Fatalf("FindAll: %v", err)
+	}
+	if got := names(products); fmt.Sprint(got) != "[cherry]" {
+		t.Errorf("second page by name: got %v, want [cherry]", got)
+	}
+}
+
+func testFindPage(t *testing.T, repo product.Repository) {
+	ctx := context.Background()
+
+	// Prices, stock and creation times repeat, so only the ID can tell the
+	// tied products apart
+	base := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	var all []*product.Product
+	for i, spec := range []struct {
+		name    string
+		price   float64
+		stock   int
+		created int
+	}{
+		{"fig", 2, 5, 0},
+		{"apple", 1, 5, 1},
+		{"date", 2, 0, 1},
+		{"banana", 3, 5, 0},
+		{"elder", 2, 0, 2},
+		{"cherry", 1, 9, 2},
+		{"grape", 2, 5, 1},
+	} {
+		p := product.NewProduct(spec.name, "", spec.price, spec.stock)
+		p.CreatedAt = base.Add(time.Duration(spec.created) * time.Minute)
+		p.UpdatedAt = base.Add(time.Duration(i) * time.Minute)
+		if err := repo.Create(ctx, p); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		all = append(all, p)
+	}
+
+	byField := map[string]func(a, b *product.Product) int{
+		"name":  func(a, b *product.Product) int { return strings.Compare(a.Name, b.Name) },
+		"price": func(a, b *product.Product) int { return compareOrdered(a.Price, b.Price) },
+		"stock": func(a, b *product.Product) int { return compareOrdered(a.Stock, b.Stock) },
+		"created_at": func(a, b *product.Product) int {
+			return a.CreatedAt.Compare(b.CreatedAt)
+		},
+	}
+
+	for _, sortBy := range []string{"", "name", "price", "stock", "created_at", "color"} {
+		for _, sortDir := range []string{"asc", "desc"} {
+			want := make([]*product.Product, len(all))
+			copy(want, all)
+			sort.Slice(want, func(i, j int) bool {
+				c := 0
+				if compare, ok := byField[sortBy]; ok {
+					c = compare(want[i], want[j])
+				}
+				if c == 0 {
+					c = bytes.Compare(want[i].ID[:], want[j].ID[:])
+				}
+				if sortDir == "desc" {
+					return c > 0
+				}
+				return c < 0
+			})
+
+			// Walk the listing three at a time, each page starting after the
+			// last product of the one before
+			var got []string
+			var after *product.Keyset
+			for pages := 0; pages < len(all); pages++ {
+				page, err := repo.FindPage(ctx, sortBy, sortDir, after, 3)
+				if err != nil {
+					t.Fatalf("FindPage %s %s: %v", sortBy, sortDir, err)
+				}
+				if len(page) > 3 {
+					t.Fatalf("FindPage %s %s returned %d products, want at most 3", sortBy, sortDir, len(page))
+				}
+				if len(page) == 0 {
+					break
+				}
+				got = append(got, names(page)...)
+
+				last := page[len(page)-1]
+				value, _ := product.SortValue(last, sortBy)
+				after = &product.Keyset{Value: value, ID: last.ID}
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(names(want)) {
+				t.Errorf("pages by %q %s: got %v, want %v", sortBy, sortDir, got, names(want))
+			}
+		}
+	}
+
+	products, err := repo.FindPage(ctx, "name", "asc", nil, 0)
+	if err != nil {
+		t.Fatalf("FindPage without limit: %v", err)
+	}
+	if len(products) != len(all) {
+		t.Errorf("FindPage without limit returned %d products, want %d", len(products), len(all))
+	}
+}
+
+func testCount(t *testing.T, repo product.Repository) {
+	ctx := context.Background()
+
+	total, err := repo.Count(ctx)
+	if err != nil {
+		t.Fatalf("Count: %v", err)
+	}
+	if total != 0 {
+		t.Errorf("Count = %d on an empty repository, want 0", total)
+	}
+
+	for i := 0; i < 4; i++ {
+		create(t, repo, fmt.Sprintf("product-%d", i), 1, 1)
+	}
+	if total, err = repo.Count(ctx); err != nil || total != 4 {
+		t.Errorf("Count = %d, %v, want 4", total, err)
+	}
+}
+
+func testUpdate(t *testing.T, repo product.Repository) {
+	ctx := context.Background()
+	p := create(t, repo, "Widget", 1, 1)
+	stale := *p
+
+	p.Update("Gadget", "Renamed", 2, 5)
+	if err := repo.Update(ctx, p); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if p.Version != 2 {
+		t.Errorf("version = %d after update, want 2", p.Version)
+	}
+
+	found := find(t, repo, p.ID)
+	if found.Name != "Gadget" || found.Price != 2 || found.Stock != 5 || found.Version != 2 {
+		t.Errorf("found %+v after update", found)
+	}
+
+	stale.Name = "Lost"
+	expectCode(t, repo.Update(ctx, &stale), errors.ECONFLICT)
+	if stale.Version != 1 {
+		t.Errorf("version = %d after a conflict, want 1", stale.Version)
+	}
+
+	missing := product.NewProduct("Missing", "", 1, 1)
+	missing.ID = primitive.NewObjectID()
+	expectCode(t, repo.Update(ctx, missing), errors.ENOTFOUND)
+
+	// Taking another product's name is refused, keeping its own isn't
+	other := create(t, repo, "Widget", 1, 1)
+	other.Update("Gadget", "", 1, 1)
+	expectAlreadyExists(t, repo.Update(ctx, other))
+	if other.Version != 1 {
+		t.Errorf("version = %d after a refused rename, want 1", other.Version)
+	}
+	other.Update("Widget", "Unchanged name", 3, 1)
+	if err := repo.Update(ctx, other); err != nil {
+		t.Fatalf("Update keeping the name: %v", err)
+	}
+}
+
+func testDelete(t *testing.T, repo product.Repository) {
+	ctx := context.Background()
+	p := create(t, repo, "Widget", 1, 1)
+
+	stale := *p
+	stale.Version = 7
+	expectCode(t, repo.Delete(ctx, &stale), errors.ECONFLICT)
+
+	if err := repo.Delete(ctx, p); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	_, err := repo.FindByID(ctx, p.ID.Hex())
+	expectCode(t, err, errors.ENOTFOUND)
+	expectCode(t, repo.Delete(ctx, p), errors.ENOTFOUND)
+
+	products, total, err := repo.FindAll(ctx, 1, 10, "", "")
+	if err != nil {
+		t.Fatalf("FindAll: %v", err)
+	}
+	if total != 0 || len(products) != 0 {
+		t.Errorf("after delete: got %d products, total %d", len(products), total)
+	}
+}
+
+func testSearch(t *testing.T, repo product.Repository) {
+	ctx := context.Background()
+	create(t, repo, "Red Chair", 50, 1)
+	create(t, repo, "Blue chair", 80, 1)
+	create(t, repo, "Table", 120, 1)
+	create(t, repo, "Armchair", 200, 1)
+
+	tests := []struct {
+		name               string
+		minPrice, maxPrice float64
+		want               []string
+	}{
+		{"", 0, 0, []string{"Red Chair", "Blue chair", "Table", "Armchair"}},
+		{"chair", 0, 0, []string{"Red Chair", "Blue chair", "Armchair"}},
+		{"CHAIR", 0, 0, []string{"Red Chair", "Blue chair", "Armchair"}},
+		{"^blue", 0, 0, []string{"Blue chair"}},
+		{"", 80, 0, []string{"Blue chair", "Table", "Armchair"}},
+		{"", 0, 80, []string{"Red Chair", "Blue chair"}},
+		{"chair", 60, 150, []string{"Blue chair"}},
+		{"sofa", 0, 0, nil},
+	}
+
+	for _, tt := range tests {
+		products, err := repo.Search(ctx, tt.name, tt.minPrice, tt.maxPrice)
+		if err != nil {
+			t.Fatalf("Search %q %v-%v: %v", tt.name, tt.minPrice, tt.maxPrice, err)
+		}
+		if got := names(products); !sameNames(got, tt.want) {
+			t.Errorf("Search %q %v-%v: got %v, want %v", tt.name, tt.minPrice, tt.maxPrice, got, tt.want)
+		}
+	}
+}
+
+func testAdjustStock(t *testing.T, repo product.Repository) {
+	ctx := context.Background()
+	p := create(t, repo, "Widget", 1, 10)
+	locationID := primitive.NewObjectID().Hex()
+
+	updated, err := repo.AdjustStock(ctx, p.ID.Hex(), locationID, 4)
+	if err != nil {
+		t.Fatalf("AdjustStock into location: %v", err)
+	}
+	if updated.Stock != 14 || updated.StockLevels[locationID] != 4 || updated.UnallocatedStock() != 10 {
+		t.Errorf("after adding 4 at location: stock %d, level %d", updated.Stock, updated.StockLevels[locationID])
+	}
+	if updated.Version != p.Version+1 {
+		t.Errorf("version = %d, want %d", updated.Version, p.Version+1)
+	}
+
+	updated, err = repo.AdjustStock(ctx, p.ID.Hex(), "", -10)
+	if err != nil {
+		t.Fatalf("AdjustStock unallocated: %v", err)
+	}
+	if updated.Stock != 4 || updated.UnallocatedStock() != 0 {
+		t.Errorf("after taking 10 unallocated: stock %d, unallocated %d", updated.Stock, updated.UnallocatedStock())
+	}
+
+	// Neither the unallocated pool nor the location may go negative
+	_, err = repo.AdjustStock(ctx, p.ID.Hex(), "", -1)
+	expectCode(t, err, errors.EVALIDATION)
+	_, err = repo.AdjustStock(ctx, p.ID.Hex(), locationID, -5)
+	expectCode(t, err, errors.EVALIDATION)
+
+	// Reserved units can't be taken either
+	if _, err := repo.Reserve(ctx, p.ID.Hex(), locationID, 3); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	_, err = repo.AdjustStock(ctx, p.ID.Hex(), locationID, -2)
+	expectCode(t, err, errors.EVALIDATION)
+
+	found := find(t, repo, p.ID)
+	if found.Stock != 4 || found.StockLevels[locationID] != 4 {
+		t.Errorf("failed adjustments changed stock: stock %d, level %d", found.Stock, found.StockLevels[locationID])
+	}
+
+	_, err = repo.AdjustStock(ctx, p.ID.Hex(), "not-an-id", 1)
+	expectCode(t, err, errors.EINVALID)
+	_, err = repo.AdjustStock(ctx, "not-an-id", "", 1)
+	expectCode(t, err, errors.EINVALID)
+	_, err = repo.AdjustStock(ctx, primitive.NewObjectID().Hex(), "", 1)
+	expectCode(t, err, errors.ENOTFOUND)
+}
+
+func testTransferStock(t *testing.T, repo product.Repository) {
+	ctx := context.Background()
+	p := create(t, repo, "Widget", 1, 10)
+	from := primitive.NewObjectID().Hex()
+	to := primitive.NewObjectID().Hex()
+
+	updated, err := repo.TransferStock(ctx, p.ID.Hex(), "", from, 6)
+	if err != nil {
+		t.Fatalf("TransferStock from unallocated: %v", err)
+	}
+	if updated.Stock != 10 || updated.StockLevels[from] != 6 || updated.UnallocatedStock() != 4 {
+		t.Errorf("after allocating 6: stock %d, level %d, unallocated %d", updated.Stock, updated.StockLevels[from], updated.UnallocatedStock())
+	}
+
+	updated, err = repo.TransferStock(ctx, p.ID.Hex(), from, to, 4)
+	if err != nil {
+		t.Fatalf("TransferStock between locations: %v", err)
+	}
+	if updated.Stock != 10 || updated.StockLevels[from] != 2 || updated.StockLevels[to] != 4 {
+		t.Errorf("after moving 4: stock %d, levels %v", updated.Stock, updated.StockLevels)
+	}
+
+	updated, err = repo.TransferStock(ctx, p.ID.Hex(), to, "", 4)
+	if err != nil {
+		t.Fatalf("TransferStock to unallocated: %v", err)
+	}
+	if updated.Stock != 10 || updated.StockLevels[to] != 0 || updated.UnallocatedStock() != 8 {
+		t.Errorf("after deallocating 4: stock %d, levels %v", updated.Stock, updated.StockLevels)
+	}
+
+	_, err = repo.TransferStock(ctx, p.ID.Hex(), from, to, 3)
+	expectCode(t, err, errors.EVALIDATION)
+	_, err = repo.TransferStock(ctx, p.ID.Hex(), "", to, 9)
+	expectCode(t, err, errors.EVALIDATION)
+	_, err = repo.TransferStock(ctx, p.ID.Hex(), "", "not-an-id", 1)
+	expectCode(t, err, errors.EINVALID)
+	_, err = repo.TransferStock(ctx, primitive.NewObjectID().Hex(), "", to, 1)
+	expectCode(t, err, errors.ENOTFOUND)
+}
+
+func testHasStockAt(t *testing.T, repo product.Repository) {
+	ctx := context.Background()
+	p := create(t, repo, "Widget", 1, 5)
+	locationID := primitive.NewObjectID().Hex()
+
+	expectStockAt(t, repo, locationID, false)
+
+	if _, err := repo.TransferStock(ctx, p.ID.Hex(), "", locationID, 2); err != nil {
+		t.Fatalf("TransferStock: %v", err)
+	}
+	expectStockAt(t, repo, locationID, true)
+
+	// A level that dropped to zero holds nothing
+	if _, err := repo.TransferStock(ctx, p.ID.Hex(), locationID, "", 2); err != nil {
+		t.Fatalf("TransferStock: %v", err)
+	}
+	expectStockAt(t, repo, locationID, false)
+
+	_, err := repo.HasStockAt(ctx, "not-an-id")
+	expectCode(t, err, errors.EINVALID)
+}
+
+func testReserveAndRelease(t *testing.T, repo product.Repository) {
+	ctx := context.Background()
+	p := create(t, repo, "Widget", 1, 5)
+
+	updated, err := repo.Reserve(ctx, p.ID.Hex(), "", 3)
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if updated.Reserved != 3 || updated.Available() != 2 {
+		t.Errorf("after reserving 3: reserved %d, available %d", updated.Reserved, updated.Available())
+	}
+
+	_, err = repo.Reserve(ctx, p.ID.Hex(), "", 3)
+	expectCode(t, err, errors.EVALIDATION)
+
+	updated, err = repo.ReleaseReserved(ctx, p.ID.Hex(), "", 1, false)
+	if err != nil {
+		t.Fatalf("ReleaseReserved: %v", err)
+	}
+	if updated.Reserved != 2 || updated.Stock != 5 {
+		t.Errorf("after releasing 1: reserved %d, stock %d", updated.Reserved, updated.Stock)
+	}
+
+	updated, err = repo.ReleaseReserved(ctx, p.ID.Hex(), "", 2, true)
+	if err != nil {
+		t.Fatalf("ReleaseReserved consume: %v", err)
+	}
+	if updated.Reserved != 0 || updated.Stock != 3 {
+		t.Errorf("after consuming 2: reserved %d, stock %d", updated.Reserved, updated.Stock)
+	}
+
+	_, err = repo.ReleaseReserved(ctx, p.ID.Hex(), "", 1, false)
+	expectCode(t, err, errors.EVALIDATION)
+	_, err = repo.Reserve(ctx, primitive.NewObjectID().Hex(), "", 1)
+	expectCode(t, err, errors.ENOTFOUND)
+}
+
+// testReservedAtLocation checks that units reserved at a location are
+// counted there: they can't be reserved twice, moved or taken away.
+func testReservedAtLocation(t *testing.T, repo product.Repository) {
+	ctx := context.Background()
+	p := create(t, repo, "Widget", 1, 10)
+	locationID := primitive.NewObjectID().Hex()
+
+	if _, err := repo.TransferStock(ctx, p.ID.Hex(), "", locationID, 5); err != nil {
+		t.Fatalf("TransferStock: %v", err)
+	}
+	updated, err := repo.Reserve(ctx, p.ID.Hex(), locationID, 5)
+	if err != nil {
+		t.Fatalf("Reserve at location: %v", err)
+	}
+	if updated.ReservedAt(locationID) != 5 || updated.ReservedAt("") != 0 {
+		t.Errorf("after reserving 5 at location: reserved there %d, unallocated %d", updated.ReservedAt(locationID), updated.ReservedAt(""))
+	}
+
+	// Five units are still available, but none of them at the location
+	_, err = repo.Reserve(ctx, p.ID.Hex(), locationID, 5)
+	expectCode(t, err, errors.EVALIDATION)
+	_, err = repo.TransferStock(ctx, p.ID.Hex(), locationID, "", 1)
+	expectCode(t, err, errors.EVALIDATION)
+	_, err = repo.AdjustStock(ctx, p.ID.Hex(), locationID, -1)
+	expectCode(t, err, errors.EVALIDATION)
+
+	// Stock added to the location is free to go again
+	if _, err := repo.AdjustStock(ctx, p.ID.Hex(), locationID, 2); err != nil {
+		t.Fatalf("AdjustStock: %v", err)
+	}
+	if _, err := repo.TransferStock(ctx, p.ID.Hex(), locationID, "", 2); err != nil {
+		t.Fatalf("TransferStock: %v", err)
+	}
+
+	// The unallocated stock is reserved separately
+	if _, err := repo.Reserve(ctx, p.ID.Hex(), "", 7); err != nil {
+		t.Fatalf("Reserve unallocated: %v", err)
+	}
+	_, err = repo.TransferStock(ctx, p.ID.Hex(), "", locationID, 1)
+	expectCode(t, err, errors.EVALIDATION)
+
+	updated, err = repo.ReleaseReserved(ctx, p.ID.Hex(), locationID, 5, true)
+	if err != nil {
+		t.Fatalf("ReleaseReserved at location: %v", err)
+	}
+	if updated.Stock != 7 || updated.Reserved != 7 || updated.StockLevels[locationID] != 0 ||
+		updated.ReservedAt(locationID) != 0 || updated.ReservedAt("") != 7 {
+		t.Errorf("after confirming 5 at location: stock %d, reserved %d, level %d, reserved there %d, unallocated %d",
+			updated.Stock, updated.Reserved, updated.StockLevels[locationID], updated.ReservedAt(locationID), updated.ReservedAt(""))
+	}
+	if fields := updated.FieldErrors(); len(fields) != 0 {
+		t.Errorf("product is invalid after confirming: %v", fields)
+	}
+}
+
+// testConfirmAllocatedReservation walks a reservation through its stock
+// being allocated to a location before it is confirmed, which must leave the
+// product valid enough to be updated afterwards.
+func testConfirmAllocatedReservation(t *testing.T, repo product.Repository) {
+	ctx := context.Background()
+	p := create(t, repo, "Widget", 1, 10)
+	locationID := primitive.NewObjectID().Hex()
+
+	// Nothing is held at the location yet
+	_, err := repo.Reserve(ctx, p.ID.Hex(), locationID, 3)
+	expectCode(t, err, errors.EVALIDATION)
+
+	if _, err := repo.Reserve(ctx, p.ID.Hex(), "", 2); err != nil {
+		t.Fatalf("Reserve unallocated: %v", err)
+	}
+	if _, err := repo.TransferStock(ctx, p.ID.Hex(), "", locationID, 6); err != nil {
+		t.Fatalf("TransferStock: %v", err)
+	}
+	if _, err := repo.Reserve(ctx, p.ID.Hex(), locationID, 3); err != nil {
+		t.Fatalf("Reserve at location: %v", err)
+	}
+
+	// Confirming takes the units from where they were held
+	updated, err := repo.ReleaseReserved(ctx, p.ID.Hex(), locationID, 3, true)
+	if err != nil {
+		t.Fatalf("ReleaseReserved at location: %v", err)
+	}
+	if updated.Stock != 7 || updated.Reserved != 2 || updated.StockLevels[locationID] != 3 || updated.UnallocatedStock() != 4 {
+		t.Errorf("after confirming 3 at location: stock %d, reserved %d, level %d, unallocated %d",
+			updated.Stock, updated.Reserved, updated.StockLevels[locationID], updated.UnallocatedStock())
+	}
+
+	// Unallocated stock that is reserved can't be moved to a location, so
+	// the hold can still be confirmed
+	_, err = repo.TransferStock(ctx, p.ID.Hex(), "", locationID, 3)
+	expectCode(t, err, errors.EVALIDATION)
+	if _, err := repo.TransferStock(ctx, p.ID.Hex(), "", locationID, 2); err != nil {
+		t.Fatalf("TransferStock: %v", err)
+	}
+	updated, err = repo.ReleaseReserved(ctx, p.ID.Hex(), "", 2, true)
+	if err != nil {
+		t.Fatalf("ReleaseReserved unallocated: %v", err)
+	}
+	if updated.Stock != 5 || updated.Reserved != 0 || updated.UnallocatedStock() != 0 {
+		t.Errorf("after confirming 2 unallocated: stock %d, reserved %d, unallocated %d", updated.Stock, updated.Reserved, updated.UnallocatedStock())
+	}
+
+	if fields := updated.FieldErrors(); len(fields) != 0 {
+		t.Fatalf("product is invalid after confirming: %v", fields)
+	}
+	updated.Description = "Still editable"
+	if err := repo.Update(ctx, updated); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if found := find(t, repo, p.ID); found.Description != "Still editable" || found.StockLevels[locationID] != 5 {
+		t.Errorf("found %+v after update", found)
+	}
+}
+
+func testConcurrentReserve(t *testing.T, repo product.Repository) {
+	const (
+		stock   = 10
+		callers = 50
+	)
+	p := create(t, repo, "Widget", 1, stock)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		reserved int
+	)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.Reserve(context.Background(), p.ID.Hex(), "", 1)
+			if err == nil {
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+				return
+			}
+			if appErr, ok := errors.RootAppError(err); !ok || appErr.Code != errors.EVALIDATION {
+				t.Errorf("Reserve: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if reserved != stock {
+		t.Errorf("%d reservations succeeded, want %d", reserved, stock)
+	}
+	found := find(t, repo, p.ID)
+	if found.Reserved != stock {
+		t.Errorf("reserved = %d, want %d", found.Reserved, stock)
+	}
+	if found.Version != p.Version+stock {
+		t.Errorf("version = %d, want %d", found.Version, p.Version+stock)
+	}
+}
+
+func create(t *testing.T, repo product.Repository, name string, price float64, stock int) *product.Product {
+	t.Helper()
+	p := product.NewProduct(name, "", price, stock)
+	if err := repo.Create(context.Background(), p); err != nil {
+		t.Fatalf("Create %s: %v", name, err)
+	}
+	return p
+}
+
+func find(t *testing.T, repo product.Repository, id primitive.ObjectID) *product.Product {
+	t.Helper()
+	p, err := repo.FindByID(context.Background(), id.Hex())
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	return p
+}
+
+func expectStockAt(t *testing.T, repo product.Repository, locationID string, want bool) {
+	t.Helper()
+	got, err := repo.HasStockAt(context.Background(), locationID)
+	if err != nil {
+		t.Fatalf("HasStockAt: %v", err)
+	}
+	if got != want {
+		t.Errorf("HasStockAt = %v, want %v", got, want)
+	}
+}
+
+// expectCode fails unless err carries the application error code.
+func expectCode(t *testing.T, err error, code string) {
+	t.Helper()
+	if err == nil {
+		t.Errorf("got no error, want %s", code)
+		return
+	}
+	if appErr, ok := errors.RootAppError(err); !ok || appErr.Code != code {
+		t.Errorf("got %v, want %s", err, code)
+	}
+}
+
+// expectAlreadyExists fails unless err reports a product that already exists.
+func expectAlreadyExists(t *testing.T, err error) {
+	t.Helper()
+	expectCode(t, err, errors.ECONFLICT)
+	if !stderrors.Is(err, product.ErrProductAlreadyExists) {
+		t.Errorf("got %v, want %v", err, product.ErrProductAlreadyExists)
+	}
+}
+
+func compareOrdered[T int | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func names(products []*product.Product) []string {
+	var names []string
+	for _, p := range products {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// sameNames compares names regardless of order, since searches don't sort.
+func sameNames(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	counts := map[string]int{}
+	for _, name := range got {
+		counts[name]++
+	}
+	for _, name := range want {
+		if counts[name] == 0 {
+			return false
+		}
+		counts[name]--
+	}
+	return true
+}
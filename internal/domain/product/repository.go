@@ -1,12 +1,75 @@
 package product
 
-import "context"
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type Repository interface {
 	Create(context.Context, *Product) error
 	FindByID(context.Context, string) (*Product, error)
 	FindAll(ctx context.Context, page, pageSize int, sortBy, sortDir string) ([]*Product, int64, error)
+	// FindPage returns up to limit products ordered by sortBy and then by ID,
+	// both in sortDir, starting after the keyset when one is given. An empty
+	// or unknown sortBy orders by ID alone. Unlike FindAll it never skips
+	// over earlier products, so deep pages cost as little as the first.
+	FindPage(ctx context.Context, sortBy, sortDir string, after *Keyset, limit int) ([]*Product, error)
+	// Count returns how many products there are, which backends may estimate
+	// when counting exactly would mean scanning them all.
+	Count(ctx context.Context) (int64, error)
+	// Update replaces the product only if the stored version still matches
+	// p.Version, returning ErrVersionConflict otherwise. On success p.Version
+	// is advanced to the newly stored version.
 	Update(context.Context, *Product) error
-	Delete(context.Context, string) error
+	// Delete removes the product only if the stored version still matches
+	// p.Version, returning ErrVersionConflict otherwise.
+	Delete(context.Context, *Product) error
 	Search(context.Context, string, float64, float64) ([]*Product, error)
+	// AdjustStock atomically adds delta to the product's stock at locationID
+	// (or to its unallocated stock when locationID is empty) and returns the
+	// updated product. It fails with ErrInsufficientStock rather than letting
+	// available or location stock drop below zero, or below what is reserved
+	// there.
+	AdjustStock(ctx context.Context, id, locationID string, delta int) (*Product, error)
+	// TransferStock atomically moves quantity units between two locations,
+	// leaving the total unchanged. An empty location stands for the
+	// unallocated stock. Units reserved at the source can't be moved.
+	TransferStock(ctx context.Context, id, fromLocationID, toLocationID string, quantity int) (*Product, error)
+	// HasStockAt reports whether any product holds stock at the location.
+	HasStockAt(ctx context.Context, locationID string) (bool, error)
+	// Reserve atomically holds quantity units of available stock, which must
+	// also be held at locationID (or be unallocated when it is empty) and not
+	// already reserved there. The hold is counted against that location.
+	Reserve(ctx context.Context, id, locationID string, quantity int) (*Product, error)
+	// ReleaseReserved returns quantity units held at locationID. When consume
+	// is true the units leave stock as well, settling a confirmed
+	// reservation, and are taken from locationID's level (or the unallocated
+	// stock when it is empty), failing with ErrInsufficientStock if it no
+	// longer holds them.
+	ReleaseReserved(ctx context.Context, id, locationID string, quantity int, consume bool) (*Product, error)
+}
+
+// Keyset is the position of a product in a listing: its value of the field
+// being sorted on, as returned by SortValue, and its ID, which breaks ties.
+type Keyset struct {
+	Value interface{}
+	ID    primitive.ObjectID
+}
+
+// SortValue returns p's value of a field products can be listed by: a
+// string for name, a float64 for price, an int for stock and a time.Time for
+// created_at. It returns false for any other field.
+func SortValue(p *Product, field string) (interface{}, bool) {
+	switch field {
+	case "name":
+		return p.Name, true
+	case "price":
+		return p.Price, true
+	case "stock":
+		return p.Stock, true
+	case "created_at":
+		return p.CreatedAt, true
+	}
+	return nil, false
 }
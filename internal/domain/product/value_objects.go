@@ -1,8 +1,8 @@
 package product
 
 type Money struct {
-	Amount   float64
-	Currency string
+	Amount   float64 `bson:"amount" json:"amount"`
+	Currency string  `bson:"currency,omitempty" json:"currency,omitempty"`
 }
 
 func NewMoney(amount float64, currency string) Money {
@@ -23,3 +23,22 @@ func NewStock(quantity int, unit string) Stock {
 		Unit:     unit,
 	}
 }
+
+// StockAdjustmentReason explains why stock was adjusted by a relative amount.
+type StockAdjustmentReason string
+
+const (
+	ReasonSale       StockAdjustmentReason = "sale"
+	ReasonReturn     StockAdjustmentReason = "return"
+	ReasonRestock    StockAdjustmentReason = "restock"
+	ReasonDamage     StockAdjustmentReason = "damage"
+	ReasonCorrection StockAdjustmentReason = "correction"
+)
+
+func (r StockAdjustmentReason) IsValid() bool {
+	switch r {
+	case ReasonSale, ReasonReturn, ReasonRestock, ReasonDamage, ReasonCorrection:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package reservation
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Status string
+
+const (
+	StatusActive    Status = "active"
+	StatusConfirmed Status = "confirmed"
+	StatusReleased  Status = "released"
+	StatusExpired   Status = "expired"
+)
+
+// Reservation holds stock for an owner (typically a checkout) until it is
+// confirmed, released or expires.
+type Reservation struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProductID string             `bson:"product_id" json:"product_id"`
+	// LocationID is where the held units are and what confirming the
+	// reservation takes them from. Empty means the unallocated stock.
+	LocationID string    `bson:"location_id,omitempty" json:"location_id,omitempty"`
+	Quantity   int       `bson:"quantity" json:"quantity"`
+	OwnerRef   string    `bson:"owner_ref" json:"owner_ref"`
+	Status     Status    `bson:"status" json:"status"`
+	ExpiresAt  time.Time `bson:"expires_at" json:"expires_at"`
+	CreatedAt  time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+func NewReservation(productID, locationID string, quantity int, ownerRef string, ttl time.Duration) *Reservation {
+	now := time.Now()
+	return &Reservation{
+		ID:         primitive.NewObjectID(),
+		ProductID:  productID,
+		LocationID: locationID,
+		Quantity:   quantity,
+		OwnerRef:   ownerRef,
+		Status:     StatusActive,
+		ExpiresAt:  now.Add(ttl),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+func (r *Reservation) IsExpired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
+
+func (r *Reservation) IsValid() bool {
+	return r.ProductID != "" && r.Quantity > 0 && r.OwnerRef != ""
+}
@@ -0,0 +1,10 @@
+package reservation
+
+import "errors"
+
+var (
+	ErrReservationNotFound  = errors.New("reservation not found")
+	ErrInvalidReservation   = errors.New("invalid reservation")
+	ErrReservationNotActive = errors.New("reservation is no longer active")
+	ErrReservationExpired   = errors.New("reservation has expired")
+)
@@ -0,0 +1,49 @@
+package reservation
+
+type ReservationCreatedEvent struct {
+	Reservation *Reservation `json:"reservation"`
+}
+
+func (e ReservationCreatedEvent) GetEventType() string {
+	return "reservation.created"
+}
+
+func (e ReservationCreatedEvent) GetAggregateID() string {
+	return e.Reservation.ID.Hex()
+}
+
+type ReservationConfirmedEvent struct {
+	Reservation *Reservation `json:"reservation"`
+}
+
+func (e ReservationConfirmedEvent) GetEventType() string {
+	return "reservation.confirmed"
+}
+
+func (e ReservationConfirmedEvent) GetAggregateID() string {
+	return e.Reservation.ID.Hex()
+}
+
+type ReservationReleasedEvent struct {
+	Reservation *Reservation `json:"reservation"`
+}
+
+func (e ReservationReleasedEvent) GetEventType() string {
+	return "reservation.released"
+}
+
+func (e ReservationReleasedEvent) GetAggregateID() string {
+	return e.Reservation.ID.Hex()
+}
+
+type ReservationExpiredEvent struct {
+	Reservation *Reservation `json:"reservation"`
+}
+
+func (e ReservationExpiredEvent) GetEventType() string {
+	return "reservation.expired"
+}
+
+func (e ReservationExpiredEvent) GetAggregateID() string {
+	return e.Reservation.ID.Hex()
+}
@@ -0,0 +1,18 @@
+package reservation
+
+import (
+	"context"
+	"time"
+)
+
+type Repository interface {
+	Create(context.Context, *Reservation) error
+	FindByID(context.Context, string) (*Reservation, error)
+	// Transition moves a reservation from one status to another and returns
+	// it. It fails with ErrReservationNotActive if the reservation is no
+	// longer in the from status, so only one caller can settle a hold.
+	Transition(ctx context.Context, id string, from, to Status) (*Reservation, error)
+	// FindExpired returns up to limit active reservations that expired at or
+	// before now.
+	FindExpired(ctx context.Context, now time.Time, limit int) ([]*Reservation, error)
+}
@@ -0,0 +1,184 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EventOutOfStock is derived from stock changes that take a product's stock
+// to zero. It has no product.Event of its own.
+const EventOutOfStock = "product.out_of_stock"
+
+// SupportedEventTypes lists the event types a subscription may filter on.
+var SupportedEventTypes = []string{
+	"product.created",
+	"product.updated",
+	"product.deleted",
+	"product.stock.updated",
+	"product.stock.transferred",
+	EventOutOfStock,
+}
+
+// minSecretLength keeps signing secrets long enough to resist guessing.
+const minSecretLength = 16
+
+// Subscription registers a partner endpoint for product lifecycle events. An
+// empty EventTypes filter matches every supported event.
+type Subscription struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	URL        string             `bson:"url" json:"url"`
+	EventTypes []string           `bson:"event_types" json:"event_types"`
+	// Secret signs deliveries. It is only ever returned when the
+	// subscription is created.
+	Secret    string    `bson:"secret" json:"-"`
+	Active    bool      `bson:"active" json:"active"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+func NewSubscription(url string, eventTypes []string, secret string) *Subscription {
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	return &Subscription{
+		ID:         primitive.NewObjectID(),
+		URL:        url,
+		EventTypes: eventTypes,
+		Secret:     secret,
+		Active:     true,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+}
+
+// Update replaces the subscription's settings. An empty secret keeps the
+// current one.
+func (s *Subscription) Update(url string, eventTypes []string, secret string, active bool) {
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	s.URL = url
+	s.EventTypes = eventTypes
+	if secret != "" {
+		s.Secret = secret
+	}
+	s.Active = active
+	s.UpdatedAt = time.Now()
+}
+
+// Matches reports whether the subscription wants events of eventType.
+func (s *Subscription) Matches(eventType string) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Subscription) IsValid() bool {
+	return len(s.FieldErrors()) == 0
+}
+
+// FieldErrors describes every invalid field, keyed by its JSON name.
+func (s *Subscription) FieldErrors() map[string]string {
+	fields := map[string]string{}
+	if s.URL == "" {
+		fields["url"] = "is required"
+	} else if u, err := url.Parse(s.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		fields["url"] = "must be an absolute http or https URL"
+	} else if !isPublicHost(u.Hostname()) {
+		fields["url"] = "must not point at a private, loopback or link-local address"
+	}
+	for _, t := range s.EventTypes {
+		if !isSupported(t) {
+			fields["event_types"] = "must only contain supported event types"
+			break
+		}
+	}
+	if len(s.Secret) < minSecretLength {
+		fields["secret"] = "must be at least 16 characters"
+	}
+	return fields
+}
+
+// isPublicHost rejects hosts that are plainly internal. Names are only
+// resolved when a delivery is dialled, where the sender checks the address
+// again.
+func isPublicHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return IsPublicIP(ip)
+	}
+	return true
+}
+
+// IsPublicIP reports whether ip may be the target of a delivery. Private,
+// loopback, link-local, multicast and unspecified addresses would let a
+// subscription reach services behind the firewall.
+func IsPublicIP(ip net.IP) bool {
+	return !(ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+func isSupported(eventType string) bool {
+	for _, t := range SupportedEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryDead parks a delivery that exhausted its attempts or whose
+	// subscription went away. It is only retried when redelivered by hand.
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// Delivery is one event to be sent to one subscription. Payload is the
+// CloudEvent document posted to the endpoint.
+type Delivery struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SubscriptionID string             `bson:"subscription_id" json:"subscription_id"`
+	EventID        string             `bson:"event_id" json:"event_id"`
+	EventType      string             `bson:"event_type" json:"event_type"`
+	Payload        json.RawMessage    `bson:"payload" json:"payload"`
+	Status         DeliveryStatus     `bson:"status" json:"status"`
+	Attempts       int                `bson:"attempts" json:"attempts"`
+	ResponseStatus int                `bson:"response_status,omitempty" json:"response_status,omitempty"`
+	LastError      string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	NextAttemptAt  time.Time          `bson:"next_attempt_at" json:"next_attempt_at"`
+	LockedUntil    time.Time          `bson:"locked_until,omitempty" json:"-"`
+	DeliveredAt    time.Time          `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+}
+
+func NewDelivery(subscriptionID, eventID, eventType string, payload json.RawMessage) *Delivery {
+	now := time.Now()
+	return &Delivery{
+		ID:             primitive.NewObjectID(),
+		SubscriptionID: subscriptionID,
+		EventID:        eventID,
+		EventType:      eventType,
+		Payload:        payload,
+		Status:         DeliveryPending,
+		NextAttemptAt:  now,
+		CreatedAt:      now,
+	}
+}
@@ -0,0 +1,11 @@
+package webhook
+
+import "errors"
+
+var (
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrInvalidSubscription  = errors.New("invalid webhook subscription")
+	ErrSubscriptionInactive = errors.New("webhook subscription is inactive")
+	ErrDeliveryNotFound     = errors.New("webhook delivery not found")
+	ErrDeliveryNotDead      = errors.New("only dead webhook deliveries can be redelivered")
+)
@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"context"
+	"time"
+)
+
+type SubscriptionRepository interface {
+	Create(context.Context, *Subscription) error
+	FindByID(context.Context, string) (*Subscription, error)
+	FindAll(context.Context) ([]*Subscription, error)
+	// FindActiveByEventType returns the active subscriptions whose filter
+	// matches eventType.
+	FindActiveByEventType(ctx context.Context, eventType string) ([]*Subscription, error)
+	Update(context.Context, *Subscription) error
+	Delete(context.Context, string) error
+}
+
+// DeliveryFilter narrows a subscription's delivery log. An empty Status
+// matches every status.
+type DeliveryFilter struct {
+	SubscriptionID string
+	Status         DeliveryStatus
+	Page           int
+	PageSize       int
+}
+
+type DeliveryRepository interface {
+	// Enqueue stores deliveries, skipping any whose subscription already has
+	// a delivery for the same event so redelivered events aren't sent twice.
+	Enqueue(ctx context.Context, deliveries ...*Delivery) error
+	FindByID(context.Context, string) (*Delivery, error)
+	// FindBySubscription returns a page of deliveries, newest first, and the
+	// total number of deliveries matching the filter.
+	FindBySubscription(context.Context, DeliveryFilter) ([]*Delivery, int64, error)
+	// Claim leases up to limit pending deliveries that are due at now, so
+	// that concurrent workers don't send the same delivery at the same time.
+	Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*Delivery, error)
+	MarkDelivered(ctx context.Context, id string, responseStatus int) error
+	// MarkFailed records a failed attempt and schedules the next one, or
+	// parks the delivery as dead when dead is set.
+	MarkFailed(ctx context.Context, id string, responseStatus int, lastError string, nextAttemptAt time.Time, dead bool) error
+	// Requeue makes a dead delivery pending again with a fresh attempt count.
+	Requeue(ctx context.Context, id string) (*Delivery, error)
+}
@@ -0,0 +1,416 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"go-microservice-product-porto/pkg/errors"
+	"go-microservice-product-porto/pkg/logger"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Pinger is implemented by caches that can report whether their backing store
+// is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type BreakerConfig struct {
+	// FailureThreshold is how many calls in a row must fail before the
+	// breaker opens.
+	FailureThreshold int
+	// RetryInterval is how often an open breaker checks whether the cache is
+	// back.
+	RetryInterval time.Duration
+}
+
+// BreakerStats counts what a BreakerCache has seen since it started.
+type BreakerStats struct {
+	Open bool `json:"open"`
+	// Failures counts calls the cache failed.
+	Failures int64 `json:"failures"`
+	// Rejected counts calls skipped because the breaker was open.
+	Rejected int64 `json:"rejected"`
+	// Trips counts how often the breaker opened.
+	Trips int64 `json:"trips"`
+	// Pending counts the keys, patterns and tags still to be invalidated
+	// once the cache is back.
+	Pending int `json:"pending"`
+}
+
+// BreakerCache makes a cache optional. Failed reads are logged and then
+// treated like the cache wasn't there: they miss, as do fills. After enough
+// failures in a row the breaker opens and stops calling the cache at all,
+// until a background check finds it reachable again.
+//
+// Writes and invalidations can't fail open like that, since skipping one
+// leaves a stale entry behind. When one fails, or is skipped, it returns the
+// error and what it would have replaced or removed is recorded. A failure
+// opens the breaker at once, so nothing stale is read, and the breaker only
+// closes again once the recorded entries have been deleted.
+type BreakerCache struct {
+	next CacheService
+	cfg  BreakerConfig
+
+	mu       sync.Mutex
+	open     bool
+	failures int
+	missed   missedWrites
+
+	failed   atomic.Int64
+	rejected atomic.Int64
+	trips    atomic.Int64
+}
+
+func NewBreakerCache(next CacheService, cfg BreakerConfig) *BreakerCache {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = 5 * time.Second
+	}
+
+	return &BreakerCache{
+		next: next,
+		cfg:  cfg,
+	}
+}
+
+// Trip opens the breaker straight away, e.g. when the cache is known to be
+// unreachable at startup.
+func (c *BreakerCache) Trip(reason error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.trip(reason)
+}
+
+// trip opens the breaker and starts checking for the cache to come back.
+// c.mu must be held.
+func (c *BreakerCache) trip(reason error) {
+	if c.open {
+		return
+	}
+
+	c.open = true
+	c.trips.Add(1)
+	logger.Warn().
+		Err(reason).
+		Dur("retry_interval", c.cfg.RetryInterval).
+		Msg("cache unavailable, continuing without it")
+
+	go c.reconnect()
+}
+
+// reconnect waits for the cache to answer again, then closes the breaker.
+// Caches that can't be pinged are simply given another chance each interval.
+func (c *BreakerCache) reconnect() {
+	ticker := time.NewTicker(c.cfg.RetryInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if pinger, ok := c.next.(Pinger); ok {
+			ctx, cancel := context.WithTimeout(context.Background(), c.cfg.RetryInterval)
+			err := pinger.Ping(ctx)
+			cancel()
+			if err != nil {
+				continue
+			}
+		}
+
+		if err := c.replay(); err != nil {
+			logger.Warn().
+				Err(err).
+				Msg("failed to replay missed cache invalidations")
+			continue
+		}
+
+		// Writes skipped while replaying are left for the next tick
+		c.mu.Lock()
+		if c.missed.size() > 0 {
+			c.mu.Unlock()
+			continue
+		}
+		c.open = false
+		c.failures = 0
+		c.mu.Unlock()
+
+		logger.Info().Msg("cache reachable again")
+		return
+	}
+}
+
+// replay deletes what the writes and invalidations missed while the cache was
+// unreachable would have replaced or removed.
+func (c *BreakerCache) replay() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.cfg.RetryInterval)
+	defer cancel()
+
+	c.mu.Lock()
+	inv := c.missed.invalidation()
+	c.mu.Unlock()
+
+	if len(inv.Keys) > 0 {
+		if err := c.next.Delete(ctx, inv.Keys...); err != nil {
+			return err
+		}
+	}
+	for _, pattern := range inv.Patterns {
+		if _, err := c.next.DeletePattern(ctx, pattern); err != nil {
+			return err
+		}
+	}
+	if len(inv.Tags) > 0 {
+		if _, err := c.next.InvalidateTags(ctx, inv.Tags...); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	c.missed.remove(inv)
+	c.mu.Unlock()
+	return nil
+}
+
+// allow reports whether calls should reach the cache, counting those that
+// don't.
+func (c *BreakerCache) allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.open {
+		c.rejected.Add(1)
+		return false
+	}
+	return true
+}
+
+// done records the outcome of a call. Misses are answers, and a caller
+// giving up says nothing about the cache, so neither counts as a failure.
+func (c *BreakerCache) done(ctx context.Context, op string, err error) {
+	switch {
+	case err == nil || IsMiss(err):
+		c.mu.Lock()
+		c.failures = 0
+		c.mu.Unlock()
+		return
+	case ctx.Err() != nil:
+		return
+	}
+
+	c.failed.Add(1)
+	logger.Warn().
+		Err(err).
+		Str("op", op).
+		Msg("cache call failed")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failures++
+	if c.failures >= c.cfg.FailureThreshold {
+		c.trip(fmt.Errorf("%d cache calls failed in a row, last: %w", c.failures, err))
+	}
+}
+
+// write runs a write or invalidation. When it is skipped or fails, the
+// entries named by stale may be left out of date, so they are recorded for
+// replay and an error is returned.
+func (c *BreakerCache) write(ctx context.Context, op string, stale Invalidation, call func() error) error {
+	c.mu.Lock()
+	if c.open {
+		c.rejected.Add(1)
+		c.missed.add(stale)
+		c.mu.Unlock()
+		return errors.StandardError(errors.ECACHE, fmt.Errorf("cache %s deferred, cache unavailable", op))
+	}
+	c.mu.Unlock()
+
+	err := call()
+	if err == nil {
+		c.done(ctx, op, nil)
+		return nil
+	}
+
+	// Even a caller giving up may have left the entry stale
+	c.failed.Add(1)
+	logger.Warn().
+		Err(err).
+		Str("op", op).
+		Msg("cache write failed, deferred until the cache is back")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.missed.add(stale)
+	c.trip(fmt.Errorf("cache %s failed: %w", op, err))
+	return errors.StandardError(errors.ECACHE, fmt.Errorf("cache %s deferred: %w", op, err))
+}
+
+// miss is what reads return when the cache is skipped or failed.
+func miss(key string, err error) error {
+	if IsMiss(err) {
+		return err
+	}
+	return errors.StandardError(errors.ENOTFOUND, fmt.Errorf("key %s not read, cache unavailable: %w", key, ErrMiss))
+}
+
+// Set skips fills like reads when the cache is unavailable, since they
+// replace nothing that is newer.
+func (c *BreakerCache) Set(ctx context.Context, key string, value interface{}, opts ...Option) error {
+	if ApplyOptions(0, opts).Fill {
+		if c.allow() {
+			c.done(ctx, "set", c.next.Set(ctx, key, value, opts...))
+		}
+		return nil
+	}
+
+	return c.write(ctx, "set", Invalidation{Keys: []string{key}}, func() error {
+		return c.next.Set(ctx, key, value, opts...)
+	})
+}
+
+func (c *BreakerCache) Get(ctx context.Context, key string, target interface{}) error {
+	if !c.allow() {
+		return miss(key, nil)
+	}
+
+	err := c.next.Get(ctx, key, target)
+	c.done(ctx, "get", err)
+	if err != nil {
+		return miss(key, err)
+	}
+	return nil
+}
+
+func (c *BreakerCache) Inspect(ctx context.Context, key string) (*Entry, error) {
+	if !c.allow() {
+		return nil, miss(key, nil)
+	}
+
+	entry, err := c.next.Inspect(ctx, key)
+	c.done(ctx, "inspect", err)
+	if err != nil {
+		return nil, miss(key, err)
+	}
+	return entry, nil
+}
+
+func (c *BreakerCache) Delete(ctx context.Context, keys ...string) error {
+	return c.write(ctx, "delete", Invalidation{Keys: keys}, func() error {
+		return c.next.Delete(ctx, keys...)
+	})
+}
+
+func (c *BreakerCache) MSet(ctx context.Context, items map[string]interface{}, opts ...Option) error {
+	if ApplyOptions(0, opts).Fill {
+		if c.allow() {
+			c.done(ctx, "mset", c.next.MSet(ctx, items, opts...))
+		}
+		return nil
+	}
+
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	return c.write(ctx, "mset", Invalidation{Keys: keys}, func() error {
+		return c.next.MSet(ctx, items, opts...)
+	})
+}
+
+// MGet leaves target as it was when the cache is skipped or failed, so every
+// key reads as a miss.
+func (c *BreakerCache) MGet(ctx context.Context, keys []string, target interface{}) error {
+	if c.allow() {
+		c.done(ctx, "mget", c.next.MGet(ctx, keys, target))
+	}
+	return nil
+}
+
+func (c *BreakerCache) DeletePattern(ctx context.Context, pattern string) (int64, error) {
+	var deleted int64
+	err := c.write(ctx, "delete_pattern", Invalidation{Patterns: []string{pattern}}, func() (err error) {
+		deleted, err = c.next.DeletePattern(ctx, pattern)
+		return err
+	})
+	return deleted, err
+}
+
+func (c *BreakerCache) InvalidateTags(ctx context.Context, tags ...string) (int64, error) {
+	var removed int64
+	err := c.write(ctx, "invalidate_tags", Invalidation{Tags: tags}, func() (err error) {
+		removed, err = c.next.InvalidateTags(ctx, tags...)
+		return err
+	})
+	return removed, err
+}
+
+func (c *BreakerCache) Stats() BreakerStats {
+	c.mu.Lock()
+	open, pending := c.open, c.missed.size()
+	c.mu.Unlock()
+
+	return BreakerStats{
+		Open:     open,
+		Failures: c.failed.Load(),
+		Rejected: c.rejected.Load(),
+		Trips:    c.trips.Load(),
+		Pending:  pending,
+	}
+}
+
+// missedWrites collects the keys, patterns and tags to invalidate once the
+// cache is back. Each is kept once however often it was missed.
+type missedWrites struct {
+	keys     map[string]struct{}
+	patterns map[string]struct{}
+	tags     map[string]struct{}
+}
+
+func (m *missedWrites) add(inv Invalidation) {
+	if m.keys == nil {
+		m.keys = make(map[string]struct{})
+		m.patterns = make(map[string]struct{})
+		m.tags = make(map[string]struct{})
+	}
+	for _, key := range inv.Keys {
+		m.keys[key] = struct{}{}
+	}
+	for _, pattern := range inv.Patterns {
+		m.patterns[pattern] = struct{}{}
+	}
+	for _, tag := range inv.Tags {
+		m.tags[tag] = struct{}{}
+	}
+}
+
+// remove forgets inv once it has been replayed.
+func (m *missedWrites) remove(inv Invalidation) {
+	for _, key := range inv.Keys {
+		delete(m.keys, key)
+	}
+	for _, pattern := range inv.Patterns {
+		delete(m.patterns, pattern)
+	}
+	for _, tag := range inv.Tags {
+		delete(m.tags, tag)
+	}
+}
+
+func (m *missedWrites) size() int {
+	return len(m.keys) + len(m.patterns) + len(m.tags)
+}
+
+func (m *missedWrites) invalidation() Invalidation {
+	return Invalidation{
+		Keys:     setMembers(m.keys),
+		Patterns: setMembers(m.patterns),
+		Tags:     setMembers(m.tags),
+	}
+}
+
+func setMembers(set map[string]struct{}) []string {
+	members := make([]string, 0, len(set))
+	for member := range set {
+		members = append(members, member)
+	}
+	return members
+}
@@ -0,0 +1,177 @@
+package cache_test
+
+import (
+	"context"
+	stderrors "errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-microservice-product-porto/internal/infrastructure/cache"
+)
+
+var errUnreachable = stderrors.New("connection refused")
+
+// flakyCache is a memory cache whose reads and pings fail while it is down.
+type flakyCache struct {
+	*cache.MemoryCache
+	down  atomic.Bool
+	calls atomic.Int64
+}
+
+func (f *flakyCache) Get(ctx context.Context, key string, target interface{}) error {
+	f.calls.Add(1)
+	if f.down.Load() {
+		return errUnreachable
+	}
+	return f.MemoryCache.Get(ctx, key, target)
+}
+
+func (f *flakyCache) Delete(ctx context.Context, keys ...string) error {
+	if f.down.Load() {
+		return errUnreachable
+	}
+	return f.MemoryCache.Delete(ctx, keys...)
+}
+
+func (f *flakyCache) Ping(ctx context.Context) error {
+	if f.down.Load() {
+		return errUnreachable
+	}
+	return nil
+}
+
+func TestBreakerOpensAndCloses(t *testing.T) {
+	ctx := context.Background()
+	next := &flakyCache{MemoryCache: cache.NewMemoryCache(cache.MemoryConfig{})}
+	breaker := cache.NewBreakerCache(next, cache.BreakerConfig{FailureThreshold: 2, RetryInterval: 10 * time.Millisecond})
+
+	// A miss is an answer, not a failure
+	if err := breaker.Get(ctx, "absent", new(string)); !cache.IsMiss(err) {
+		t.Fatalf("Get(absent) = %v, want a miss", err)
+	}
+	if stats := breaker.Stats(); stats.Failures != 0 || stats.Open {
+		t.Fatalf("after a miss: %+v, want no failures and closed", stats)
+	}
+
+	// Failures read as misses until enough in a row open the breaker
+	next.down.Store(true)
+	for i := 1; i <= 2; i++ {
+		if err := breaker.Get(ctx, "k", new(string)); !cache.IsMiss(err) {
+			t.Fatalf("Get %d = %v, want a miss", i, err)
+		}
+	}
+	if stats := breaker.Stats(); !stats.Open || stats.Failures != 2 || stats.Trips != 1 {
+		t.Fatalf("after 2 failures: %+v, want open after 2 failures and 1 trip", stats)
+	}
+
+	// While open the cache isn't called at all
+	calls := next.calls.Load()
+	if err := breaker.Get(ctx, "k", new(string)); !cache.IsMiss(err) {
+		t.Fatalf("Get while open = %v, want a miss", err)
+	}
+	if next.calls.Load() != calls {
+		t.Error("open breaker called the cache")
+	}
+	if stats := breaker.Stats(); stats.Rejected != 1 {
+		t.Errorf("rejected = %d, want 1", stats.Rejected)
+	}
+
+	// Failed pings keep it open
+	time.Sleep(50 * time.Millisecond)
+	if !breaker.Stats().Open {
+		t.Fatal("breaker closed while the cache was still down")
+	}
+
+	// The first successful ping closes it with a clean slate
+	next.down.Store(false)
+	waitUntil(t, "breaker to close", func() bool { return !breaker.Stats().Open })
+
+	if err := breaker.Set(ctx, "k", "v"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	var got string
+	if err := breaker.Get(ctx, "k", &got); err != nil || got != "v" {
+		t.Fatalf("Get after closing = %q, %v; want v", got, err)
+	}
+
+	next.down.Store(true)
+	_ = breaker.Get(ctx, "k", new(string))
+	if stats := breaker.Stats(); stats.Open || stats.Trips != 1 {
+		t.Errorf("one failure after closing: %+v, want still closed", stats)
+	}
+}
+
+func TestBreakerTrip(t *testing.T) {
+	next := &flakyCache{MemoryCache: cache.NewMemoryCache(cache.MemoryConfig{})}
+	next.down.Store(true)
+	breaker := cache.NewBreakerCache(next, cache.BreakerConfig{RetryInterval: 10 * time.Millisecond})
+
+	breaker.Trip(errUnreachable)
+	breaker.Trip(errUnreachable)
+	if stats := breaker.Stats(); !stats.Open || stats.Trips != 1 {
+		t.Fatalf("after tripping twice: %+v, want open with 1 trip", stats)
+	}
+
+	next.down.Store(false)
+	waitUntil(t, "breaker to close", func() bool { return !breaker.Stats().Open })
+}
+
+func TestBreakerReplaysMissedInvalidations(t *testing.T) {
+	ctx := context.Background()
+	next := &flakyCache{MemoryCache: cache.NewMemoryCache(cache.MemoryConfig{})}
+	breaker := cache.NewBreakerCache(next, cache.BreakerConfig{FailureThreshold: 5, RetryInterval: 10 * time.Millisecond})
+	for _, key := range []string{"product:1", "product:2", "list:1"} {
+		if err := breaker.Set(ctx, key, "old", cache.WithTags("lists")); err != nil {
+			t.Fatalf("Set(%s): %v", key, err)
+		}
+	}
+
+	// One failed invalidation opens the breaker, so the stale entry isn't read
+	next.down.Store(true)
+	if err := breaker.Delete(ctx, "product:1"); err == nil {
+		t.Fatal("failed Delete returned no error")
+	}
+	if stats := breaker.Stats(); !stats.Open || stats.Pending != 1 {
+		t.Fatalf("after a failed delete: %+v, want open with 1 pending", stats)
+	}
+	if err := breaker.Get(ctx, "product:1", new(string)); !cache.IsMiss(err) {
+		t.Fatalf("Get while open = %v, want a miss", err)
+	}
+
+	// Skipped writes are recorded too, fills aren't
+	if err := breaker.Set(ctx, "product:2", "new"); err == nil {
+		t.Error("skipped Set returned no error")
+	}
+	if _, err := breaker.InvalidateTags(ctx, "lists"); err == nil {
+		t.Error("skipped InvalidateTags returned no error")
+	}
+	if err := breaker.Set(ctx, "product:3", "loaded", cache.AsFill()); err != nil {
+		t.Errorf("skipped fill = %v, want nil", err)
+	}
+	if stats := breaker.Stats(); stats.Pending != 3 {
+		t.Fatalf("pending = %d, want 3", stats.Pending)
+	}
+
+	next.down.Store(false)
+	waitUntil(t, "breaker to close", func() bool { return !breaker.Stats().Open })
+	if stats := breaker.Stats(); stats.Pending != 0 {
+		t.Errorf("pending = %d after closing, want 0", stats.Pending)
+	}
+	for _, key := range []string{"product:1", "product:2", "list:1"} {
+		if err := next.MemoryCache.Get(ctx, key, new(string)); !cache.IsMiss(err) {
+			t.Errorf("Get(%s) after replay = %v, want a miss", key, err)
+		}
+	}
+}
+
+func waitUntil(t *testing.T, what string, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -1,13 +1,78 @@
 package cache
 
-import "go-microservice-product-porto/internal/infrastructure/persistence/redis"
+import (
+	"context"
+	stderrors "errors"
+	"time"
+)
 
+// ErrMiss is wrapped by the error Get returns when the key isn't cached.
+var ErrMiss = stderrors.New("cache miss")
+
+// IsMiss reports whether err means the key wasn't cached, as opposed to the
+// cache failing.
+func IsMiss(err error) bool {
+	return stderrors.Is(err, ErrMiss)
+}
+
+// CacheService stores values by key. Every call honours ctx, so request
+// cancellation and deadlines reach the backing store.
 type CacheService interface {
-	Set(key string, value interface{}) error
-	Get(key string) (interface{}, error)
-	Delete(key string) error
+	Set(ctx context.Context, key string, value interface{}, opts ...Option) error
+	// Get decodes the value stored under key into target, which must be a
+	// pointer. It fails with an error wrapping ErrMiss when key isn't cached.
+	Get(ctx context.Context, key string, target interface{}) error
+	Delete(ctx context.Context, keys ...string) error
+	// MSet stores every item with the same options.
+	MSet(ctx context.Context, items map[string]interface{}, opts ...Option) error
+	// MGet decodes the values of the keys that were found into target, which
+	// must be a pointer to a map keyed by string.
+	MGet(ctx context.Context, keys []string, target interface{}) error
+	// DeletePattern removes every key matching a glob-style pattern, e.g.
+	// "products_list_*", and returns how many were removed.
+	DeletePattern(ctx context.Context, pattern string) (int64, error)
+	// InvalidateTags atomically removes every entry registered under any of
+	// tags, and the tags themselves, and returns how many entries went.
+	InvalidateTags(ctx context.Context, tags ...string) (int64, error)
+	// Inspect describes the entry stored under key without needing its type.
+	// It fails with an error wrapping ErrMiss when key isn't cached.
+	Inspect(ctx context.Context, key string) (*Entry, error)
+}
+
+// Entry describes a stored value.
+type Entry struct {
+	Key string `json:"key"`
+	// Size is the encoded size in bytes.
+	Size int `json:"size"`
+	// TTL is how long the entry has left. Zero means it never expires.
+	TTL time.Duration `json:"-"`
+	// Value is the entry decoded into generic maps and slices, or nil when
+	// the codec can't decode without knowing the type, as with gob.
+	Value interface{} `json:"value,omitempty"`
+}
+
+// NewEntry describes the encoded value data stored under key.
+func NewEntry(codec Codec, key string, data []byte, ttl time.Duration) *Entry {
+	entry := &Entry{Key: key, Size: len(data), TTL: ttl}
+
+	var value interface{}
+	if err := codec.Unmarshal(data, &value); err == nil {
+		entry.Value = value
+	}
+	return entry
+}
+
+// Get returns the value cached under key decoded as T, e.g.
+// Get[*product.Product](ctx, c, id).
+func Get[T any](ctx context.Context, c CacheService, key string) (T, error) {
+	var value T
+	err := c.Get(ctx, key, &value)
+	return value, err
 }
 
-func NewCacheService(redisConfig redis.RedisConfig) (CacheService, error) {
-	return redis.NewRedisCache(redisConfig)
+// MGet returns the cached values of keys decoded as T, leaving out misses.
+func MGet[T any](ctx context.Context, c CacheService, keys ...string) (map[string]T, error) {
+	values := make(map[string]T, len(keys))
+	err := c.MGet(ctx, keys, &values)
+	return values, err
 }
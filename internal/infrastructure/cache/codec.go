@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec turns values into bytes for storage and back.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	// JSON is readable with redis-cli and the safest default.
+	JSON Codec = jsonCodec{}
+	// MessagePack is the most compact and usually the fastest to decode.
+	MessagePack Codec = msgpackCodec{}
+	// Gob round-trips Go types exactly but is only readable from Go.
+	Gob Codec = gobCodec{}
+)
+
+// CodecByName resolves a codec from its configuration name.
+func CodecByName(name string) (Codec, error) {
+	switch name {
+	case "json":
+		return JSON, nil
+	case "msgpack":
+		return MessagePack, nil
+	case "gob":
+		return Gob, nil
+	}
+	return nil, fmt.Errorf("unknown cache codec %q", name)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// DecodeEntries decodes raw values keyed by cache key into target, which must
+// be a pointer to a map keyed by string. Implementations of MGet share it so
+// they all accept the same targets.
+func DecodeEntries(codec Codec, entries map[string][]byte, target interface{}) error {
+	ptr := reflect.ValueOf(target)
+	if ptr.Kind() != reflect.Pointer || ptr.Elem().Kind() != reflect.Map || ptr.Elem().Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("MGet target must be a pointer to a map keyed by string, got %T", target)
+	}
+
+	m := ptr.Elem()
+	if m.IsNil() {
+		m.Set(reflect.MakeMapWithSize(m.Type(), len(entries)))
+	}
+
+	elemType := m.Type().Elem()
+	for key, data := range entries {
+		value := reflect.New(elemType)
+		if err := codec.Unmarshal(data, value.Interface()); err != nil {
+			return fmt.Errorf("failed to decode %s: %v", key, err)
+		}
+		m.SetMapIndex(reflect.ValueOf(key).Convert(m.Type().Key()), value.Elem())
+	}
+	return nil
+}
+
+// lookupEntry returns the value under key in target, a pointer to a map
+// keyed by string as MGet takes, or the zero Value when there is none.
+func lookupEntry(target interface{}, key string) reflect.Value {
+	m := reflect.ValueOf(target).Elem()
+	return m.MapIndex(reflect.ValueOf(key).Convert(m.Type().Key()))
+}
@@ -0,0 +1,107 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/infrastructure/cache"
+)
+
+var codecs = map[string]cache.Codec{
+	"json":    cache.JSON,
+	"msgpack": cache.MessagePack,
+	"gob":     cache.Gob,
+}
+
+func sampleProduct() *product.Product {
+	p := product.NewProduct("Widget", "A widget", 9.99, 42)
+	p.ID = primitive.NewObjectID()
+	p.Reserved = 2
+	p.StockLevels = map[string]int{"warehouse-a": 30}
+	p.Version = 7
+	p.CreatedAt = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	p.UpdatedAt = time.Date(2024, 6, 7, 8, 9, 10, 0, time.UTC)
+	return p
+}
+
+func TestCodecsRoundTripProducts(t *testing.T) {
+	want := sampleProduct()
+
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			data, err := codec.Marshal(want)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+
+			var got *product.Product
+			if err := codec.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+
+			if got.ID != want.ID || got.Name != want.Name || got.Price != want.Price ||
+				got.Stock != want.Stock || got.Reserved != want.Reserved || got.Version != want.Version {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+			if !got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) {
+				t.Errorf("timestamps = %v/%v, want %v/%v", got.CreatedAt, got.UpdatedAt, want.CreatedAt, want.UpdatedAt)
+			}
+			if got.StockLevels["warehouse-a"] != 30 {
+				t.Errorf("stock levels = %v, want warehouse-a: 30", got.StockLevels)
+			}
+		})
+	}
+}
+
+func TestDecodeEntries(t *testing.T) {
+	data, err := cache.JSON.Marshal(sampleProduct())
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var got map[string]*product.Product
+	if err := cache.DecodeEntries(cache.JSON, map[string][]byte{"a": data}, &got); err != nil {
+		t.Fatalf("DecodeEntries: %v", err)
+	}
+	if len(got) != 1 || got["a"].Name != "Widget" {
+		t.Errorf("got %v, want one entry for a", got)
+	}
+
+	if err := cache.DecodeEntries(cache.JSON, nil, got); err == nil {
+		t.Error("DecodeEntries accepted a non-pointer target")
+	}
+}
+
+func BenchmarkCodecs(b *testing.B) {
+	p := sampleProduct()
+
+	for name, codec := range codecs {
+		data, err := codec.Marshal(p)
+		if err != nil {
+			b.Fatalf("Marshal: %v", err)
+		}
+
+		b.Run(name+"/marshal", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := codec.Marshal(p); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(name+"/unmarshal", func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				var out *product.Product
+				if err := codec.Unmarshal(data, &out); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"context"
+	"go-microservice-product-porto/pkg/logger"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// refreshTimeout bounds a background refresh, which no request waits on.
+const refreshTimeout = 30 * time.Second
+
+// Loader reads through a cache, loading a missing value at most once at a
+// time per key however many requests miss together.
+//
+// Values are stored with the time they go stale. A stale value is still
+// served for a grace period while a single goroutine refreshes it in the
+// background, so an expiring hot key never sends its traffic to the loader.
+// Keys read through a Loader must only be written through it.
+type Loader struct {
+	cache CacheService
+	grace time.Duration
+	group singleflight.Group
+}
+
+// NewLoader returns a Loader over c that serves values for up to grace past
+// their TTL. Zero grace only coalesces misses.
+func NewLoader(c CacheService, grace time.Duration) *Loader {
+	return &Loader{
+		cache: c,
+		grace: grace,
+	}
+}
+
+// LoadFunc produces the value for a missing or stale key, along with the
+// options it is cached with.
+type LoadFunc[T any] func(ctx context.Context) (T, []Option, error)
+
+// loaded is how a Loader stores a value.
+type loaded[T any] struct {
+	Value   T         `json:"value" msgpack:"value"`
+	StaleAt time.Time `json:"stale_at" msgpack:"stale_at"`
+}
+
+// Fetch returns the value cached under key, calling load when it is missing
+// or stale. Callers that miss together share one call to load, and its error.
+func Fetch[T any](ctx context.Context, l *Loader, key string, load LoadFunc[T]) (T, error) {
+	cached, err := Get[loaded[T]](ctx, l.cache, key)
+	if err == nil {
+		if time.Now().After(cached.StaleAt) {
+			l.refresh(key, fill(l, key, load))
+		}
+		return cached.Value, nil
+	}
+
+	// The shared call must outlive whichever caller started it, since the
+	// others wait on it too
+	detached := context.WithoutCancel(ctx)
+	fillKey := fill(l, key, load)
+	result := l.group.DoChan(key, func() (interface{}, error) {
+		return fillKey(detached)
+	})
+
+	var zero T
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case res := <-result:
+		if res.Err != nil {
+			return zero, res.Err
+		}
+		return res.Val.(T), nil
+	}
+}
+
+// fill returns a function that loads key and caches the value. Callers that
+// missed just before a previous fill finished find its value in the cache
+// rather than loading again.
+func fill[T any](l *Loader, key string, load LoadFunc[T]) func(context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		if cached, err := Get[loaded[T]](ctx, l.cache, key); err == nil && time.Now().Before(cached.StaleAt) {
+			return cached.Value, nil
+		}
+
+		value, opts, err := load(ctx)
+		if err != nil {
+			return value, err
+		}
+
+		// Keep the entry around for the grace period past its TTL, during
+		// which it is served stale
+		options := ApplyOptions(0, opts)
+		entry := loaded[T]{Value: value, StaleAt: time.Now().Add(options.TTL)}
+		if err := l.cache.Set(ctx, key, entry, WithTTL(options.TTL+l.grace), WithTags(options.Tags...), AsFill()); err != nil {
+			logger.Warn().
+				Err(err).
+				Str("key", key).
+				Msg("failed to cache loaded value")
+		}
+
+		return value, nil
+	}
+}
+
+// refresh reloads key in the background, unless a load of it is running
+// already.
+func (l *Loader) refresh(key string, fillKey func(context.Context) (interface{}, error)) {
+	l.group.DoChan(key, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+		defer cancel()
+
+		value, err := fillKey(ctx)
+		if err != nil {
+			logger.Warn().
+				Err(err).
+				Str("key", key).
+				Msg("failed to refresh stale cache entry")
+		}
+		return value, err
+	})
+}
@@ -0,0 +1,36 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-microservice-product-porto/internal/infrastructure/cache"
+)
+
+// recordingCache is a memory cache that remembers the options of each Set.
+type recordingCache struct {
+	*cache.MemoryCache
+	sets []cache.Options
+}
+
+func (r *recordingCache) Set(ctx context.Context, key string, value interface{}, opts ...cache.Option) error {
+	r.sets = append(r.sets, cache.ApplyOptions(0, opts))
+	return r.MemoryCache.Set(ctx, key, value, opts...)
+}
+
+func TestLoaderMarksWritesAsFills(t *testing.T) {
+	ctx := context.Background()
+	c := &recordingCache{MemoryCache: cache.NewMemoryCache(cache.MemoryConfig{})}
+	loader := cache.NewLoader(c, time.Minute)
+
+	value, err := cache.Fetch(ctx, loader, "list:1", func(context.Context) (string, []cache.Option, error) {
+		return "loaded", []cache.Option{cache.WithTTL(time.Minute)}, nil
+	})
+	if err != nil || value != "loaded" {
+		t.Fatalf("Fetch = %q, %v; want loaded", value, err)
+	}
+	if len(c.sets) != 1 || !c.sets[0].Fill {
+		t.Errorf("sets = %+v, want one fill", c.sets)
+	}
+}
@@ -0,0 +1,260 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"go-microservice-product-porto/pkg/errors"
+	"path"
+	"sync"
+	"time"
+)
+
+type MemoryConfig struct {
+	// MaxEntries bounds the cache. Once full, the least recently used entry
+	// is evicted to make room. Zero means unbounded.
+	MaxEntries int
+	// DefaultTTL applies to writes that don't set a TTL of their own. Zero
+	// means such entries only leave through eviction.
+	DefaultTTL time.Duration
+	// Codec encodes stored values. It defaults to JSON.
+	Codec Codec
+}
+
+// MemoryCache is an in-process LRU cache. Values are stored encoded, like in
+// Redis, so callers never share memory with a cached value and either store
+// can back the other.
+type MemoryCache struct {
+	maxEntries int
+	defaultTTL time.Duration
+	codec      Codec
+
+	mu      sync.Mutex
+	entries *list.List
+	keys    map[string]*list.Element
+	tags    map[string]map[string]struct{}
+}
+
+type memoryEntry struct {
+	key       string
+	data      []byte
+	tags      []string
+	expiresAt time.Time
+}
+
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+func NewMemoryCache(cfg MemoryConfig) *MemoryCache {
+	codec := cfg.Codec
+	if codec == nil {
+		codec = JSON
+	}
+
+	return &MemoryCache{
+		maxEntries: cfg.MaxEntries,
+		defaultTTL: cfg.DefaultTTL,
+		codec:      codec,
+		entries:    list.New(),
+		keys:       make(map[string]*list.Element),
+		tags:       make(map[string]map[string]struct{}),
+	}
+}
+
+func (c *MemoryCache) Set(ctx context.Context, key string, value interface{}, opts ...Option) error {
+	options := ApplyOptions(c.defaultTTL, opts)
+
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		return errors.StandardError(errors.ECACHE, fmt.Errorf("failed to marshal value: %v", err))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, data, options, time.Now())
+	return nil
+}
+
+// set stores one encoded entry and evicts from the back of the list until
+// the cache fits. c.mu must be held.
+func (c *MemoryCache) set(key string, data []byte, options Options, now time.Time) {
+	if elem, ok := c.keys[key]; ok {
+		c.remove(elem)
+	}
+
+	entry := &memoryEntry{key: key, data: data, tags: options.Tags}
+	if options.TTL > 0 {
+		entry.expiresAt = now.Add(options.TTL)
+	}
+	c.keys[key] = c.entries.PushFront(entry)
+
+	for _, tag := range options.Tags {
+		members, ok := c.tags[tag]
+		if !ok {
+			members = make(map[string]struct{})
+			c.tags[tag] = members
+		}
+		members[key] = struct{}{}
+	}
+
+	for c.maxEntries > 0 && c.entries.Len() > c.maxEntries {
+		c.remove(c.entries.Back())
+	}
+}
+
+// remove drops elem from the list, the key index and its tags. c.mu must be
+// held.
+func (c *MemoryCache) remove(elem *list.Element) {
+	entry := c.entries.Remove(elem).(*memoryEntry)
+	delete(c.keys, entry.key)
+
+	for _, tag := range entry.tags {
+		members := c.tags[tag]
+		delete(members, entry.key)
+		if len(members) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
+
+// lookup returns the live entry under key, marking it as recently used and
+// dropping it if it expired. c.mu must be held.
+func (c *MemoryCache) lookup(key string, now time.Time) (*memoryEntry, bool) {
+	elem, ok := c.keys[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*memoryEntry)
+	if entry.expired(now) {
+		c.remove(elem)
+		return nil, false
+	}
+
+	c.entries.MoveToFront(elem)
+	return entry, true
+}
+
+func (c *MemoryCache) Get(ctx context.Context, key string, target interface{}) error {
+	c.mu.Lock()
+	entry, ok := c.lookup(key, time.Now())
+	c.mu.Unlock()
+	if !ok {
+		return errors.StandardError(errors.ENOTFOUND, fmt.Errorf("key %s not found in memory cache: %w", key, ErrMiss))
+	}
+
+	// Entries are never modified once stored, so decoding outside the lock
+	// is safe
+	if err := c.codec.Unmarshal(entry.data, target); err != nil {
+		return errors.StandardError(errors.ECACHE, fmt.Errorf("failed to unmarshal value from memory cache: %v", err))
+	}
+
+	return nil
+}
+
+func (c *MemoryCache) Inspect(ctx context.Context, key string) (*Entry, error) {
+	c.mu.Lock()
+	now := time.Now()
+	entry, ok := c.lookup(key, now)
+	c.mu.Unlock()
+	if !ok {
+		return nil, errors.StandardError(errors.ENOTFOUND, fmt.Errorf("key %s not found in memory cache: %w", key, ErrMiss))
+	}
+
+	var ttl time.Duration
+	if !entry.expiresAt.IsZero() {
+		ttl = entry.expiresAt.Sub(now)
+	}
+	return NewEntry(c.codec, key, entry.data, ttl), nil
+}
+
+func (c *MemoryCache) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if elem, ok := c.keys[key]; ok {
+			c.remove(elem)
+		}
+	}
+	return nil
+}
+
+func (c *MemoryCache) MSet(ctx context.Context, items map[string]interface{}, opts ...Option) error {
+	options := ApplyOptions(c.defaultTTL, opts)
+
+	encoded := make(map[string][]byte, len(items))
+	for key, value := range items {
+		data, err := c.codec.Marshal(value)
+		if err != nil {
+			return errors.StandardError(errors.ECACHE, fmt.Errorf("failed to marshal value for %s: %v", key, err))
+		}
+		encoded[key] = data
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for key, data := range encoded {
+		c.set(key, data, options, now)
+	}
+	return nil
+}
+
+func (c *MemoryCache) MGet(ctx context.Context, keys []string, target interface{}) error {
+	entries := make(map[string][]byte, len(keys))
+
+	c.mu.Lock()
+	now := time.Now()
+	for _, key := range keys {
+		if entry, ok := c.lookup(key, now); ok {
+			entries[key] = entry.data
+		}
+	}
+	c.mu.Unlock()
+
+	if err := DecodeEntries(c.codec, entries, target); err != nil {
+		return errors.StandardError(errors.ECACHE, fmt.Errorf("failed to unmarshal values from memory cache: %v", err))
+	}
+
+	return nil
+}
+
+// DeletePattern matches keys with path.Match, which agrees with Redis' glob
+// syntax for keys without slashes.
+func (c *MemoryCache) DeletePattern(ctx context.Context, pattern string) (int64, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return 0, errors.StandardError(errors.EINVALID, fmt.Errorf("invalid key pattern %q: %v", pattern, err))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var deleted int64
+	for key, elem := range c.keys {
+		if matched, _ := path.Match(pattern, key); matched {
+			c.remove(elem)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (c *MemoryCache) InvalidateTags(ctx context.Context, tags ...string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var removed int64
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			if elem, ok := c.keys[key]; ok {
+				c.remove(elem)
+				removed++
+			}
+		}
+		delete(c.tags, tag)
+	}
+	return removed, nil
+}
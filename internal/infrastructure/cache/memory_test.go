@@ -0,0 +1,67 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+
+	"go-microservice-product-porto/internal/infrastructure/cache"
+)
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMemoryCache(cache.MemoryConfig{MaxEntries: 3})
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := c.Set(ctx, key, key); err != nil {
+			t.Fatalf("Set(%s): %v", key, err)
+		}
+	}
+	// Reading a makes b the least recently used
+	if _, err := cache.Get[string](ctx, c, "a"); err != nil {
+		t.Fatalf("Get(a): %v", err)
+	}
+	if err := c.Set(ctx, "d", "d"); err != nil {
+		t.Fatalf("Set(d): %v", err)
+	}
+	// Overwriting c refreshes it too, so a goes next
+	if err := c.Set(ctx, "c", "c2"); err != nil {
+		t.Fatalf("Set(c): %v", err)
+	}
+	if err := c.Set(ctx, "e", "e"); err != nil {
+		t.Fatalf("Set(e): %v", err)
+	}
+
+	for key, want := range map[string]bool{"a": false, "b": false, "c": true, "d": true, "e": true} {
+		_, err := cache.Get[string](ctx, c, key)
+		if got := err == nil; got != want {
+			t.Errorf("key %s cached = %t, want %t (err %v)", key, got, want, err)
+		}
+		if err != nil && !cache.IsMiss(err) {
+			t.Errorf("Get(%s) failed with %v, want a miss", key, err)
+		}
+	}
+}
+
+func TestMemoryCacheEvictionDropsTags(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMemoryCache(cache.MemoryConfig{MaxEntries: 1})
+
+	if err := c.Set(ctx, "a", "a", cache.WithTags("t")); err != nil {
+		t.Fatalf("Set(a): %v", err)
+	}
+	if err := c.Set(ctx, "b", "b"); err != nil {
+		t.Fatalf("Set(b): %v", err)
+	}
+
+	// a was evicted, so its tag has nothing left to invalidate
+	removed, err := c.InvalidateTags(ctx, "t")
+	if err != nil {
+		t.Fatalf("InvalidateTags: %v", err)
+	}
+	if removed != 0 {
+		t.Errorf("InvalidateTags removed %d entries, want 0", removed)
+	}
+	if _, err := cache.Get[string](ctx, c, "b"); err != nil {
+		t.Errorf("Get(b): %v", err)
+	}
+}
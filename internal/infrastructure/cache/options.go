@@ -0,0 +1,62 @@
+package cache
+
+import "time"
+
+// Options tune a single write.
+type Options struct {
+	// TTL is how long the entry lives. Zero means the store's default.
+	TTL time.Duration
+	// Tags register the entry for InvalidateTags.
+	Tags []string
+	// Fill marks a read-through fill: a value just loaded from the
+	// repository, which leaves other instances' copies no staler than they
+	// were, so none are invalidated.
+	Fill bool
+}
+
+type Option func(*Options)
+
+func WithTTL(ttl time.Duration) Option {
+	return func(o *Options) {
+		o.TTL = ttl
+	}
+}
+
+// WithTags registers the entry under tags, so it is dropped whenever any of
+// them is invalidated.
+func WithTags(tags ...string) Option {
+	return func(o *Options) {
+		o.Tags = append(o.Tags, tags...)
+	}
+}
+
+// AsFill marks the write as a read-through fill.
+func AsFill() Option {
+	return func(o *Options) {
+		o.Fill = true
+	}
+}
+
+// ApplyOptions resolves opts, falling back to defaultTTL when no TTL was set.
+func ApplyOptions(defaultTTL time.Duration, opts []Option) Options {
+	options := Options{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.TTL <= 0 {
+		options.TTL = defaultTTL
+	}
+	return options
+}
+
+// TTLPolicy sets how long each family of keys lives. Lists and searches go
+// stale as soon as any product changes, so they should expire much sooner
+// than single products, which are refreshed on every write.
+type TTLPolicy struct {
+	Product time.Duration
+	List    time.Duration
+	Search  time.Duration
+	// Stale is how long past their TTL lists and searches are still served
+	// while they refresh.
+	Stale time.Duration
+}
@@ -0,0 +1,172 @@
+package cache
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// FamilyStats counts the calls made for one family of keys.
+type FamilyStats struct {
+	Family  string  `json:"family"`
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	Errors  int64   `json:"errors"`
+	Writes  int64   `json:"writes"`
+	Deletes int64   `json:"deletes"`
+	HitRate float64 `json:"hit_rate"`
+}
+
+type familyCounters struct {
+	hits, misses, errors, writes, deletes atomic.Int64
+}
+
+// StatsCache counts hits, misses and writes per family of keys, such as
+// single products or list pages, so each family's cache can be tuned on its
+// own. family maps a key to the family it belongs to.
+//
+// Pattern deletes and tag invalidations can't be attributed to a family and
+// aren't counted.
+type StatsCache struct {
+	next   CacheService
+	family func(key string) string
+
+	mu       sync.RWMutex
+	families map[string]*familyCounters
+}
+
+func NewStatsCache(next CacheService, family func(key string) string) *StatsCache {
+	return &StatsCache{
+		next:     next,
+		family:   family,
+		families: make(map[string]*familyCounters),
+	}
+}
+
+func (c *StatsCache) counters(key string) *familyCounters {
+	name := c.family(key)
+
+	c.mu.RLock()
+	counters, ok := c.families[name]
+	c.mu.RUnlock()
+	if ok {
+		return counters
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if counters, ok = c.families[name]; !ok {
+		counters = &familyCounters{}
+		c.families[name] = counters
+	}
+	return counters
+}
+
+// read counts the outcome of reading key.
+func (c *StatsCache) read(key string, err error) {
+	counters := c.counters(key)
+	switch {
+	case err == nil:
+		counters.hits.Add(1)
+	case IsMiss(err):
+		counters.misses.Add(1)
+	default:
+		counters.errors.Add(1)
+	}
+}
+
+func (c *StatsCache) Set(ctx context.Context, key string, value interface{}, opts ...Option) error {
+	err := c.next.Set(ctx, key, value, opts...)
+	if err != nil {
+		c.counters(key).errors.Add(1)
+	} else {
+		c.counters(key).writes.Add(1)
+	}
+	return err
+}
+
+func (c *StatsCache) Get(ctx context.Context, key string, target interface{}) error {
+	err := c.next.Get(ctx, key, target)
+	c.read(key, err)
+	return err
+}
+
+func (c *StatsCache) Delete(ctx context.Context, keys ...string) error {
+	err := c.next.Delete(ctx, keys...)
+	for _, key := range keys {
+		if err != nil {
+			c.counters(key).errors.Add(1)
+		} else {
+			c.counters(key).deletes.Add(1)
+		}
+	}
+	return err
+}
+
+func (c *StatsCache) MSet(ctx context.Context, items map[string]interface{}, opts ...Option) error {
+	err := c.next.MSet(ctx, items, opts...)
+	for key := range items {
+		if err != nil {
+			c.counters(key).errors.Add(1)
+		} else {
+			c.counters(key).writes.Add(1)
+		}
+	}
+	return err
+}
+
+func (c *StatsCache) MGet(ctx context.Context, keys []string, target interface{}) error {
+	err := c.next.MGet(ctx, keys, target)
+	for _, key := range keys {
+		switch {
+		case err != nil:
+			c.counters(key).errors.Add(1)
+		case lookupEntry(target, key).IsValid():
+			c.counters(key).hits.Add(1)
+		default:
+			c.counters(key).misses.Add(1)
+		}
+	}
+	return err
+}
+
+func (c *StatsCache) DeletePattern(ctx context.Context, pattern string) (int64, error) {
+	return c.next.DeletePattern(ctx, pattern)
+}
+
+func (c *StatsCache) InvalidateTags(ctx context.Context, tags ...string) (int64, error) {
+	return c.next.InvalidateTags(ctx, tags...)
+}
+
+// Inspect isn't counted, so looking at entries doesn't skew their hit rate.
+func (c *StatsCache) Inspect(ctx context.Context, key string) (*Entry, error) {
+	return c.next.Inspect(ctx, key)
+}
+
+// Stats returns the counts of every family seen so far, ordered by name.
+func (c *StatsCache) Stats() []FamilyStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	stats := make([]FamilyStats, 0, len(c.families))
+	for name, counters := range c.families {
+		s := FamilyStats{
+			Family:  name,
+			Hits:    counters.hits.Load(),
+			Misses:  counters.misses.Load(),
+			Errors:  counters.errors.Load(),
+			Writes:  counters.writes.Load(),
+			Deletes: counters.deletes.Load(),
+		}
+		if reads := s.Hits + s.Misses; reads > 0 {
+			s.HitRate = float64(s.Hits) / float64(reads)
+		}
+		stats = append(stats, s)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Family < stats[j].Family
+	})
+	return stats
+}
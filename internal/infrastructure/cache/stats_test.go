@@ -0,0 +1,60 @@
+package cache_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go-microservice-product-porto/internal/infrastructure/cache"
+)
+
+// prefixFamily files keys under whatever comes before their first colon.
+func prefixFamily(key string) string {
+	family, _, _ := strings.Cut(key, ":")
+	return family
+}
+
+func TestStatsCacheCountsPerFamily(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewStatsCache(cache.NewMemoryCache(cache.MemoryConfig{}), prefixFamily)
+
+	mustSet := func(key string) {
+		t.Helper()
+		if err := c.Set(ctx, key, key); err != nil {
+			t.Fatalf("Set(%s): %v", key, err)
+		}
+	}
+	mustSet("product:1")
+	mustSet("product:2")
+	if err := c.MSet(ctx, map[string]interface{}{"list:a": "a", "list:b": "b"}); err != nil {
+		t.Fatalf("MSet: %v", err)
+	}
+
+	_ = c.Get(ctx, "product:1", new(string))
+	_ = c.Get(ctx, "product:1", new(string))
+	_ = c.Get(ctx, "product:3", new(string))
+	if _, err := cache.MGet[string](ctx, c, "list:a", "list:c"); err != nil {
+		t.Fatalf("MGet: %v", err)
+	}
+	if err := c.Delete(ctx, "product:2"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	// Inspecting doesn't count as a read
+	if _, err := c.Inspect(ctx, "product:1"); err != nil {
+		t.Fatalf("Inspect: %v", err)
+	}
+
+	want := []cache.FamilyStats{
+		{Family: "list", Hits: 1, Misses: 1, Writes: 2, HitRate: 0.5},
+		{Family: "product", Hits: 2, Misses: 1, Writes: 2, Deletes: 1, HitRate: 2.0 / 3},
+	}
+	got := c.Stats()
+	if len(got) != len(want) {
+		t.Fatalf("Stats() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Stats()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package cache
+
+const (
+	// TagProductLists covers every cached page of the product list.
+	TagProductLists = "products:lists"
+	// TagProductSearches covers every cached product search.
+	TagProductSearches = "products:searches"
+)
+
+// ProductTag covers every cached list page and search that includes the
+// product, so changes to it alone don't flush unrelated pages.
+func ProductTag(id string) string {
+	return "product:" + id
+}
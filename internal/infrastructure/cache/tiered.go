@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// Invalidation names entries one instance changed, so the others can drop
+// their local copies.
+type Invalidation struct {
+	// Origin identifies the publishing instance, which skips its own
+	// messages.
+	Origin   string   `json:"origin"`
+	Keys     []string `json:"keys,omitempty"`
+	Patterns []string `json:"patterns,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// TieredCache serves reads from a local cache and falls back to a shared one,
+// copying what it finds there into the local tier. Writes go to the shared
+// cache first, so a failed write never leaves only this instance updated.
+//
+// Other instances learn about writes through Evict. Until an invalidation
+// arrives a local copy can lag behind the shared cache, which localTTL bounds.
+type TieredCache struct {
+	local    CacheService
+	remote   CacheService
+	localTTL time.Duration
+}
+
+func NewTieredCache(local, remote CacheService, localTTL time.Duration) *TieredCache {
+	return &TieredCache{
+		local:    local,
+		remote:   remote,
+		localTTL: localTTL,
+	}
+}
+
+// localOptions caps opts at the local TTL, keeping its tags.
+func (c *TieredCache) localOptions(opts []Option) []Option {
+	options := ApplyOptions(c.localTTL, opts)
+	if options.TTL > c.localTTL {
+		options.TTL = c.localTTL
+	}
+	return []Option{WithTTL(options.TTL), WithTags(options.Tags...)}
+}
+
+func (c *TieredCache) Set(ctx context.Context, key string, value interface{}, opts ...Option) error {
+	if err := c.remote.Set(ctx, key, value, opts...); err != nil {
+		_ = c.local.Delete(ctx, key)
+		return err
+	}
+	return c.local.Set(ctx, key, value, c.localOptions(opts)...)
+}
+
+func (c *TieredCache) Get(ctx context.Context, key string, target interface{}) error {
+	if err := c.local.Get(ctx, key, target); err == nil {
+		return nil
+	}
+
+	if err := c.remote.Get(ctx, key, target); err != nil {
+		return err
+	}
+
+	// The shared cache doesn't say which tags the entry carries, so the copy
+	// relies on invalidations naming its key
+	_ = c.local.Set(ctx, key, reflect.ValueOf(target).Elem().Interface(), WithTTL(c.localTTL))
+	return nil
+}
+
+// Inspect describes the shared entry, which is the one every instance agrees
+// on, and falls back to the local one only while the shared cache misses.
+func (c *TieredCache) Inspect(ctx context.Context, key string) (*Entry, error) {
+	entry, err := c.remote.Inspect(ctx, key)
+	if err != nil && IsMiss(err) {
+		if local, localErr := c.local.Inspect(ctx, key); localErr == nil {
+			return local, nil
+		}
+	}
+	return entry, err
+}
+
+func (c *TieredCache) Delete(ctx context.Context, keys ...string) error {
+	_ = c.local.Delete(ctx, keys...)
+	return c.remote.Delete(ctx, keys...)
+}
+
+func (c *TieredCache) MSet(ctx context.Context, items map[string]interface{}, opts ...Option) error {
+	if err := c.remote.MSet(ctx, items, opts...); err != nil {
+		keys := make([]string, 0, len(items))
+		for key := range items {
+			keys = append(keys, key)
+		}
+		_ = c.local.Delete(ctx, keys...)
+		return err
+	}
+	return c.local.MSet(ctx, items, c.localOptions(opts)...)
+}
+
+func (c *TieredCache) MGet(ctx context.Context, keys []string, target interface{}) error {
+	if err := c.local.MGet(ctx, keys, target); err != nil {
+		return err
+	}
+
+	missing := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !lookupEntry(target, key).IsValid() {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	// DecodeEntries adds to the map rather than replacing it, so the remote
+	// hits join the local ones
+	if err := c.remote.MGet(ctx, missing, target); err != nil {
+		return err
+	}
+
+	backfill := make(map[string]interface{}, len(missing))
+	for _, key := range missing {
+		if value := lookupEntry(target, key); value.IsValid() {
+			backfill[key] = value.Interface()
+		}
+	}
+	_ = c.local.MSet(ctx, backfill, WithTTL(c.localTTL))
+	return nil
+}
+
+func (c *TieredCache) DeletePattern(ctx context.Context, pattern string) (int64, error) {
+	_, _ = c.local.DeletePattern(ctx, pattern)
+	return c.remote.DeletePattern(ctx, pattern)
+}
+
+func (c *TieredCache) InvalidateTags(ctx context.Context, tags ...string) (int64, error) {
+	_, _ = c.local.InvalidateTags(ctx, tags...)
+	return c.remote.InvalidateTags(ctx, tags...)
+}
+
+// Evict drops the local copies another instance invalidated. The shared
+// cache is left alone, the publisher already changed it.
+func (c *TieredCache) Evict(ctx context.Context, inv Invalidation) {
+	if len(inv.Keys) > 0 {
+		_ = c.local.Delete(ctx, inv.Keys...)
+	}
+	for _, pattern := range inv.Patterns {
+		_, _ = c.local.DeletePattern(ctx, pattern)
+	}
+	if len(inv.Tags) > 0 {
+		_, _ = c.local.InvalidateTags(ctx, inv.Tags...)
+	}
+}
@@ -0,0 +1,138 @@
+package nats
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"go-microservice-product-porto/internal/application/events"
+	"go-microservice-product-porto/pkg/errors"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+// flushTimeout bounds how long Publish waits for the server to acknowledge
+// the message when ctx has no deadline of its own.
+const flushTimeout = 5 * time.Second
+
+type NatsConfig struct {
+	URL string
+	// SubjectPrefix namespaces subjects, e.g. "products" publishes
+	// product.stock.updated on "products.product.stock.updated".
+	SubjectPrefix string
+}
+
+// EventBus publishes events as structured-mode CloudEvents JSON over NATS, so
+// any CloudEvents-aware consumer can read them without this service's types.
+type EventBus struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+func NewEventBus(cfg NatsConfig) (*EventBus, error) {
+	conn, err := nats.Connect(cfg.URL,
+		nats.Name("product-service"),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			logger.Warn().Err(err).Msg("disconnected from NATS")
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			logger.Info().Str("url", conn.ConnectedUrl()).Msg("reconnected to NATS")
+		}),
+	)
+	if err != nil {
+		return nil, errors.StandardError(errors.EINTERNAL, fmt.Errorf("failed to connect to NATS: %v", err))
+	}
+
+	return &EventBus{
+		conn:   conn,
+		prefix: cfg.SubjectPrefix,
+	}, nil
+}
+
+func (b *EventBus) Publish(ctx context.Context, envelope *events.Envelope) error {
+	data, err := events.MarshalCloudEvent(envelope)
+	if err != nil {
+		return errors.StandardError(errors.EINTERNAL, err)
+	}
+
+	msg := nats.NewMsg(b.subject(envelope.Type))
+	msg.Header.Set("Content-Type", "application/cloudevents+json")
+	msg.Data = data
+
+	if err := b.conn.PublishMsg(msg); err != nil {
+		return errors.StandardError(errors.EINTERNAL, fmt.Errorf("failed to publish %s: %v", envelope.Type, err))
+	}
+
+	// Publishing only buffers the message; flushing makes sure the server has
+	// it before the outbox marks it published
+	if _, ok := ctx.Deadline(); ok {
+		err = b.conn.FlushWithContext(ctx)
+	} else {
+		err = b.conn.FlushTimeout(flushTimeout)
+	}
+	if err != nil {
+		return errors.StandardError(errors.ETIMEOUT, fmt.Errorf("failed to flush %s: %v", envelope.Type, err))
+	}
+
+	return nil
+}
+
+// Subscribe fans every event out to the handler unless the subscription
+// joins a queue group, in which case the server hands each event to one
+// member of the group across every connection.
+func (b *EventBus) Subscribe(eventType string, handler events.Handler, opts ...events.SubscribeOption) (events.Subscription, error) {
+	options := events.NewSubscribeOptions(opts...)
+
+	callback := func(msg *nats.Msg) {
+		envelope, err := events.UnmarshalCloudEvent(msg.Data)
+		if err != nil {
+			logger.Error().
+				Str("subject", msg.Subject).
+				Err(err).
+				Msg("dropping malformed event")
+			return
+		}
+
+		if err := handler(context.Background(), envelope); err != nil {
+			logger.Error().
+				Str("event_id", envelope.ID).
+				Str("event_type", envelope.Type).
+				Err(err).
+				Msg("event handler failed")
+		}
+	}
+
+	subject := b.subject(eventType)
+
+	var (
+		sub *nats.Subscription
+		err error
+	)
+	if options.Queue != "" {
+		sub, err = b.conn.QueueSubscribe(subject, options.Queue, callback)
+	} else {
+		sub, err = b.conn.Subscribe(subject, callback)
+	}
+	if err != nil {
+		return nil, errors.StandardError(errors.EINTERNAL, fmt.Errorf("failed to subscribe to %s: %v", subject, err))
+	}
+
+	return sub, nil
+}
+
+// Close delivers any buffered messages before closing the connection.
+func (b *EventBus) Close() error {
+	return b.conn.Drain()
+}
+
+func (b *EventBus) subject(eventType string) string {
+	if eventType == events.AllEvents {
+		eventType = ">"
+	}
+	if b.prefix == "" {
+		return eventType
+	}
+	return b.prefix + "." + eventType
+}
@@ -0,0 +1,157 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+
+	"go-microservice-product-porto/internal/application/events"
+)
+
+// runServer starts an embedded NATS server on a random port.
+func runServer(t *testing.T) string {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: server.RANDOM_PORT, NoLog: true, NoSigs: true})
+	if err != nil {
+		t.Fatalf("start NATS server: %v", err)
+	}
+	go srv.Start()
+	if !srv.ReadyForConnections(5 * time.Second) {
+		t.Fatal("NATS server did not start")
+	}
+	t.Cleanup(srv.Shutdown)
+	return srv.ClientURL()
+}
+
+func newBus(t *testing.T, url string) *EventBus {
+	t.Helper()
+
+	bus, err := NewEventBus(NatsConfig{URL: url, SubjectPrefix: "products"})
+	if err != nil {
+		t.Fatalf("NewEventBus: %v", err)
+	}
+	t.Cleanup(func() { bus.Close() })
+	return bus
+}
+
+// counter records the envelopes a handler was given.
+type counter struct {
+	mu   sync.Mutex
+	seen []*events.Envelope
+}
+
+func (c *counter) handle(ctx context.Context, envelope *events.Envelope) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seen = append(c.seen, envelope)
+	return nil
+}
+
+func (c *counter) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.seen)
+}
+
+func waitFor(t *testing.T, what string, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPublishAndSubscribe(t *testing.T) {
+	bus := newBus(t, runServer(t))
+
+	var all, stock, deleted counter
+	for eventType, c := range map[string]*counter{
+		events.AllEvents:        &all,
+		"product.stock.updated": &stock,
+		"product.deleted":       &deleted,
+	} {
+		if _, err := bus.Subscribe(eventType, c.handle); err != nil {
+			t.Fatalf("Subscribe(%s): %v", eventType, err)
+		}
+	}
+
+	sent := &events.Envelope{
+		ID:      "evt-1",
+		Type:    "product.stock.updated",
+		Source:  "/product-service",
+		Subject: "p-1",
+		Time:    time.Now().UTC().Truncate(time.Millisecond),
+		Data:    json.RawMessage(`{"stock":3}`),
+	}
+	if err := bus.Publish(context.Background(), sent); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	waitFor(t, "deliveries", func() bool { return all.count() == 1 && stock.count() == 1 })
+	if deleted.count() != 0 {
+		t.Errorf("product.deleted subscriber got %d events, want 0", deleted.count())
+	}
+
+	got := stock.seen[0]
+	if got.ID != sent.ID || got.Type != sent.Type || got.Source != sent.Source || got.Subject != sent.Subject ||
+		!got.Time.Equal(sent.Time) || string(got.Data) != string(sent.Data) {
+		t.Errorf("received %+v, want %+v", got, sent)
+	}
+}
+
+// TestQueueGroups runs two buses as two instances would: subscribers without
+// a queue group each see every event, while a queue group shares them out.
+func TestQueueGroups(t *testing.T) {
+	const published = 20
+	url := runServer(t)
+	instances := []*EventBus{newBus(t, url), newBus(t, url)}
+
+	var (
+		loggers []*counter
+		workers []*counter
+	)
+	for _, bus := range instances {
+		logger, worker := &counter{}, &counter{}
+		if _, err := bus.Subscribe(events.AllEvents, logger.handle); err != nil {
+			t.Fatalf("Subscribe: %v", err)
+		}
+		if _, err := bus.Subscribe(events.AllEvents, worker.handle, events.WithQueue("webhooks")); err != nil {
+			t.Fatalf("Subscribe with queue: %v", err)
+		}
+		loggers = append(loggers, logger)
+		workers = append(workers, worker)
+	}
+	// Subscriptions travel to the server asynchronously
+	for _, bus := range instances {
+		if err := bus.conn.Flush(); err != nil {
+			t.Fatalf("Flush: %v", err)
+		}
+	}
+
+	for i := 0; i < published; i++ {
+		envelope := &events.Envelope{ID: fmt.Sprintf("evt-%d", i), Type: "product.created", Source: "/product-service", Time: time.Now()}
+		if err := instances[0].Publish(context.Background(), envelope); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	waitFor(t, "deliveries", func() bool {
+		return loggers[0].count() == published && loggers[1].count() == published &&
+			workers[0].count()+workers[1].count() == published
+	})
+
+	// Nothing extra arrives once everything has been handled
+	time.Sleep(50 * time.Millisecond)
+	if shared := workers[0].count() + workers[1].count(); shared != published {
+		t.Errorf("queue group handled %d events, want %d", shared, published)
+	}
+}
@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+
+	"go-microservice-product-porto/internal/domain/movement"
+)
+
+// MovementRepository keeps the stock ledger in memory, ordered like the
+// MongoDB repository's: newest first.
+type MovementRepository struct {
+	mu        sync.RWMutex
+	movements map[string][]*movement.Movement
+}
+
+func NewMovementRepository() *MovementRepository {
+	return &MovementRepository{
+		movements: make(map[string][]*movement.Movement),
+	}
+}
+
+func (r *MovementRepository) Append(ctx context.Context, m *movement.Movement) error {
+	if err := ctx.Err(); err != nil {
+		return storageError("failed to append stock movement", err)
+	}
+
+	stored := *m
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.movements[m.ProductID] = append(r.movements[m.ProductID], &stored)
+	return nil
+}
+
+func (r *MovementRepository) FindByProduct(ctx context.Context, filter movement.Filter) ([]*movement.Movement, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, storageError("failed to find stock movements", err)
+	}
+
+	r.mu.RLock()
+	var matched []*movement.Movement
+	for _, m := range r.movements[filter.ProductID] {
+		if !filter.From.IsZero() && m.OccurredAt.Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && m.OccurredAt.After(filter.To) {
+			continue
+		}
+		matched = append(matched, m)
+	}
+	r.mu.RUnlock()
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		if !matched[i].OccurredAt.Equal(matched[j].OccurredAt) {
+			return matched[i].OccurredAt.After(matched[j].OccurredAt)
+		}
+		return bytes.Compare(matched[i].ID[:], matched[j].ID[:]) > 0
+	})
+
+	start := (filter.Page - 1) * filter.PageSize
+	if start < 0 {
+		start = 0
+	}
+	if start > len(matched) {
+		start = len(matched)
+	}
+	end := len(matched)
+	if filter.PageSize > 0 && start+filter.PageSize < end {
+		end = start + filter.PageSize
+	}
+
+	movements := make([]*movement.Movement, 0, end-start)
+	for _, m := range matched[start:end] {
+		c := *m
+		movements = append(movements, &c)
+	}
+	return movements, int64(len(matched)), nil
+}
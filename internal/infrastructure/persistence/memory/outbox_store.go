@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-microservice-product-porto/internal/application/outbox"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+// OutboxStore stages events in memory for the relay. It can't share a
+// transaction with anything, so pair it with outbox.NoTransaction. Published
+// messages are dropped, since nothing reads them again.
+type OutboxStore struct {
+	mu       sync.Mutex
+	messages map[primitive.ObjectID]*outbox.Message
+}
+
+func NewOutboxStore() *OutboxStore {
+	return &OutboxStore{
+		messages: make(map[primitive.ObjectID]*outbox.Message),
+	}
+}
+
+func (s *OutboxStore) Add(ctx context.Context, messages ...*outbox.Message) error {
+	if err := ctx.Err(); err != nil {
+		return storageError("failed to add outbox messages", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, message := range messages {
+		stored := *message
+		if stored.ID.IsZero() {
+			stored.ID = primitive.NewObjectID()
+		}
+		s.messages[stored.ID] = &stored
+	}
+	return nil
+}
+
+func (s *OutboxStore) Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*outbox.Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, storageError("failed to claim outbox message", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*outbox.Message
+	for _, message := range s.messages {
+		if message.Status != outbox.StatusPending || message.NextAttemptAt.After(now) || message.LockedUntil.After(now) {
+			continue
+		}
+		due = append(due, message)
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].OccurredAt.Before(due[j].OccurredAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]*outbox.Message, len(due))
+	for i, message := range due {
+		message.LockedUntil = now.Add(lease)
+		c := *message
+		claimed[i] = &c
+	}
+	return claimed, nil
+}
+
+func (s *OutboxStore) MarkPublished(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.StandardError(errors.EINVALID, fmt.Errorf("invalid outbox message ID: %v", err))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.messages, objectID)
+	return nil
+}
+
+func (s *OutboxStore) MarkFailed(ctx context.Context, id string, lastError string, nextAttemptAt time.Time, dead bool) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.StandardError(errors.EINVALID, fmt.Errorf("invalid outbox message ID: %v", err))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	message, ok := s.messages[objectID]
+	if !ok {
+		return nil
+	}
+	message.Status = outbox.StatusPending
+	if dead {
+		message.Status = outbox.StatusFailed
+	}
+	message.Attempts++
+	message.LastError = lastError
+	message.NextAttemptAt = nextAttemptAt
+	message.LockedUntil = time.Time{}
+	return nil
+}
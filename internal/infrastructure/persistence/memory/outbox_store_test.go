@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-microservice-product-porto/internal/application/outbox"
+	"go-microservice-product-porto/internal/domain/product"
+)
+
+func TestOutboxStoreClaimLifecycle(t *testing.T) {
+	ctx := context.Background()
+	store := NewOutboxStore()
+
+	var ids []string
+	for i, id := range []string{"p-1", "p-2"} {
+		message, err := outbox.NewMessage(product.ProductDeletedEvent{ProductID: id})
+		if err != nil {
+			t.Fatalf("NewMessage: %v", err)
+		}
+		message.OccurredAt = message.OccurredAt.Add(time.Duration(i) * time.Millisecond)
+		if err := store.Add(ctx, message); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		ids = append(ids, message.ID.Hex())
+	}
+
+	now := time.Now()
+	claimed, err := store.Claim(ctx, now, 1, time.Minute)
+	if err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].ID.Hex() != ids[0] {
+		t.Fatalf("Claim returned %v, want the oldest message", claimed)
+	}
+
+	// The lease hides the first message from other relays
+	claimed, _ = store.Claim(ctx, now, 10, time.Minute)
+	if len(claimed) != 1 || claimed[0].ID.Hex() != ids[1] {
+		t.Fatalf("second Claim returned %v, want only the unleased message", claimed)
+	}
+
+	if err := store.MarkPublished(ctx, ids[0]); err != nil {
+		t.Fatalf("MarkPublished: %v", err)
+	}
+	retryAt := now.Add(time.Second)
+	if err := store.MarkFailed(ctx, ids[1], "bus down", retryAt, false); err != nil {
+		t.Fatalf("MarkFailed: %v", err)
+	}
+
+	if claimed, _ = store.Claim(ctx, now, 10, time.Minute); len(claimed) != 0 {
+		t.Fatalf("Claim before the retry returned %v, want nothing", claimed)
+	}
+	claimed, _ = store.Claim(ctx, retryAt, 10, time.Minute)
+	if len(claimed) != 1 || claimed[0].ID.Hex() != ids[1] || claimed[0].Attempts != 1 || claimed[0].LastError != "bus down" {
+		t.Fatalf("Claim at the retry returned %+v, want the failed message after 1 attempt", claimed)
+	}
+
+	if err := store.MarkFailed(ctx, ids[1], "bus down", retryAt, true); err != nil {
+		t.Fatalf("MarkFailed dead: %v", err)
+	}
+	if claimed, _ = store.Claim(ctx, retryAt.Add(time.Hour), 10, time.Minute); len(claimed) != 0 {
+		t.Fatalf("Claim after giving up returned %v, want nothing", claimed)
+	}
+}
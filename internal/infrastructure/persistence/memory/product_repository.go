@@ -0,0 +1,470 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/infrastructure/persistence/stock"
+	"go-microservice-product-porto/pkg/errors"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+type storedProduct struct {
+	product *product.Product
+	// seq is the insertion order, which stands in for MongoDB's natural
+	// order when no sort applies.
+	seq uint64
+}
+
+// ProductRepository keeps products in memory. It answers exactly like the
+// MongoDB repository, including its error codes, so the service and its
+// handlers can run without a database. Products are copied on the way in
+// and out, so callers never share state with the store.
+type ProductRepository struct {
+	mu       sync.RWMutex
+	products map[primitive.ObjectID]*storedProduct
+	seq      uint64
+}
+
+func NewProductRepository() *ProductRepository {
+	return &ProductRepository{
+		products: make(map[primitive.ObjectID]*storedProduct),
+	}
+}
+
+func (r *ProductRepository) Create(ctx context.Context, prod *product.Product) error {
+	if err := ctx.Err(); err != nil {
+		return storageError("failed to create product", err)
+	}
+
+	if prod.ID.IsZero() {
+		prod.ID = primitive.NewObjectID()
+	}
+	if prod.Version == 0 {
+		prod.Version = 1
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.products[prod.ID]; exists {
+		logger.Error().
+			Str("product_name", prod.Name).
+			Msg("product already exists")
+		return errors.StandardError(errors.ECONFLICT, product.ErrProductAlreadyExists)
+	}
+	if err := r.checkNameFree(prod); err != nil {
+		return err
+	}
+
+	r.seq++
+	r.products[prod.ID] = &storedProduct{product: clone(prod), seq: r.seq}
+	return nil
+}
+
+func (r *ProductRepository) FindByID(ctx context.Context, id string) (*product.Product, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, storageError("failed to find product", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.products[objectID]
+	if !ok {
+		return nil, errors.StandardError(errors.ENOTFOUND, product.ErrProductNotFound)
+	}
+	return clone(stored.product), nil
+}
+
+func (r *ProductRepository) FindAll(ctx context.Context, page, pageSize int, sortBy, sortDir string) ([]*product.Product, int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, storageError("failed to find products", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	all := r.ordered()
+
+	// Same rules as MongoDB: no field means ID order, an unknown field means
+	// no particular order
+	desc := strings.ToLower(sortDir) == "desc"
+	var less func(a, b *product.Product) bool
+	switch sortBy {
+	case "":
+		desc = false
+		less = func(a, b *product.Product) bool { return bytes.Compare(a.ID[:], b.ID[:]) < 0 }
+	case "name":
+		less = func(a, b *product.Product) bool { return a.Name < b.Name }
+	case "price":
+		less = func(a, b *product.Product) bool { return a.Price < b.Price }
+	case "stock":
+		less = func(a, b *product.Product) bool { return a.Stock < b.Stock }
+	case "created_at":
+		less = func(a, b *product.Product) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	}
+	if less != nil {
+		sort.SliceStable(all, func(i, j int) bool {
+			if desc {
+				return less(all[j], all[i])
+			}
+			return less(all[i], all[j])
+		})
+	}
+
+	start := (page - 1) * pageSize
+	if start < 0 {
+		start = 0
+	}
+	if start > len(all) {
+		start = len(all)
+	}
+	end := len(all)
+	if pageSize > 0 && start+pageSize < end {
+		end = start + pageSize
+	}
+
+	products := make([]*product.Product, 0, end-start)
+	for _, p := range all[start:end] {
+		products = append(products, clone(p))
+	}
+	return products, int64(len(all)), nil
+}
+
+func (r *ProductRepository) FindPage(ctx context.Context, sortBy, sortDir string, after *product.Keyset, limit int) ([]*product.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, storageError("failed to find products", err)
+	}
+	if _, ok := product.SortValue(&product.Product{}, sortBy); !ok {
+		sortBy = ""
+	}
+	desc := strings.ToLower(sortDir) == "desc"
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	all := r.ordered()
+	sort.Slice(all, func(i, j int) bool {
+		c := comparePosition(all[i], sortBy, keysetOf(all[j], sortBy))
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	products := []*product.Product{}
+	for _, p := range all {
+		if limit > 0 && len(products) == limit {
+			break
+		}
+		if after != nil {
+			c := comparePosition(p, sortBy, *after)
+			if (!desc && c <= 0) || (desc && c >= 0) {
+				continue
+			}
+		}
+		products = append(products, clone(p))
+	}
+	return products, nil
+}
+
+func (r *ProductRepository) Count(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, storageError("failed to count products", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return int64(len(r.products)), nil
+}
+
+func keysetOf(p *product.Product, sortBy string) product.Keyset {
+	value, _ := product.SortValue(p, sortBy)
+	return product.Keyset{Value: value, ID: p.ID}
+}
+
+// comparePosition orders p against a keyset by the sort field and then by
+// ID, returning -1, 0 or 1.
+func comparePosition(p *product.Product, sortBy string, k product.Keyset) int {
+	value, _ := product.SortValue(p, sortBy)
+	switch v := value.(type) {
+	case string:
+		if c := strings.Compare(v, k.Value.(string)); c != 0 {
+			return c
+		}
+	case float64:
+		if other := k.Value.(float64); v != other {
+			if v < other {
+				return -1
+			}
+			return 1
+		}
+	case int:
+		if other := k.Value.(int); v != other {
+			if v < other {
+				return -1
+			}
+			return 1
+		}
+	case time.Time:
+		if c := v.Compare(k.Value.(time.Time)); c != 0 {
+			return c
+		}
+	}
+	return bytes.Compare(p.ID[:], k.ID[:])
+}
+
+func (r *ProductRepository) Update(ctx context.Context, prod *product.Product) error {
+	if err := ctx.Err(); err != nil {
+		return storageError("failed to update product", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, err := r.versioned(prod.ID, prod.Version)
+	if err != nil {
+		return err
+	}
+	if err := r.checkNameFree(prod); err != nil {
+		return err
+	}
+
+	replacement := clone(prod)
+	replacement.Version = prod.Version + 1
+	stored.product = replacement
+
+	prod.Version = replacement.Version
+	return nil
+}
+
+func (r *ProductRepository) Delete(ctx context.Context, prod *product.Product) error {
+	if err := ctx.Err(); err != nil {
+		return storageError("failed to delete product", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.versioned(prod.ID, prod.Version); err != nil {
+		return err
+	}
+	delete(r.products, prod.ID)
+	return nil
+}
+
+// Search matches names as a case-insensitive regular expression, the way the
+// MongoDB repository does, and returns matches in insertion order.
+func (r *ProductRepository) Search(ctx context.Context, name string, minPrice, maxPrice float64) ([]*product.Product, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, storageError("failed to search products", err)
+	}
+
+	var pattern *regexp.Regexp
+	if name != "" {
+		var err error
+		if pattern, err = regexp.Compile("(?i)" + name); err != nil {
+			return nil, storageError("failed to search products", err)
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	products := []*product.Product{}
+	for _, p := range r.ordered() {
+		if pattern != nil && !pattern.MatchString(p.Name) {
+			continue
+		}
+		if minPrice > 0 && p.Price < minPrice {
+			continue
+		}
+		if maxPrice > 0 && p.Price > maxPrice {
+			continue
+		}
+		products = append(products, clone(p))
+	}
+	return products, nil
+}
+
+func (r *ProductRepository) AdjustStock(ctx context.Context, id, locationID string, delta int) (*product.Product, error) {
+	change, err := stock.Adjust(locationID, delta)
+	if err != nil {
+		return nil, err
+	}
+	return r.guardedUpdate(ctx, id, change, "adjust product stock")
+}
+
+func (r *ProductRepository) TransferStock(ctx context.Context, id, fromLocationID, toLocationID string, quantity int) (*product.Product, error) {
+	change, err := stock.Transfer(fromLocationID, toLocationID, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return r.guardedUpdate(ctx, id, change, "transfer product stock")
+}
+
+func (r *ProductRepository) HasStockAt(ctx context.Context, locationID string) (bool, error) {
+	if err := stock.ValidateLocationID(locationID); err != nil {
+		return false, err
+	}
+	if err := ctx.Err(); err != nil {
+		return false, storageError("failed to check stock at location", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, stored := range r.products {
+		if stored.product.StockLevels[locationID] > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *ProductRepository) Reserve(ctx context.Context, id, locationID string, quantity int) (*product.Product, error) {
+	change, err := stock.Reserve(locationID, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return r.guardedUpdate(ctx, id, change, "reserve product stock")
+}
+
+func (r *ProductRepository) ReleaseReserved(ctx context.Context, id, locationID string, quantity int, consume bool) (*product.Product, error) {
+	change, err := stock.Release(locationID, quantity, consume)
+	if err != nil {
+		return nil, err
+	}
+	return r.guardedUpdate(ctx, id, change, "release reserved product stock")
+}
+
+// guardedUpdate applies change to the product only while it is allowed,
+// under the write lock so concurrent writers see each other's changes.
+func (r *ProductRepository) guardedUpdate(ctx context.Context, id string, change *stock.Change, op string) (*product.Product, error) {
+	objectID, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, storageError("failed to "+op, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.products[objectID]
+	if !ok {
+		return nil, errors.StandardError(errors.ENOTFOUND, product.ErrProductNotFound)
+	}
+	if !change.Allowed(stored.product) {
+		return nil, errors.StandardError(errors.EVALIDATION, product.ErrInsufficientStock)
+	}
+
+	updated := clone(stored.product)
+	change.Apply(updated)
+	updated.Version++
+	updated.UpdatedAt = time.Now()
+	stored.product = updated
+
+	return clone(updated), nil
+}
+
+// versioned returns the stored product if it is at version, or explains why
+// not. Version 0 matches products stored without one. r.mu must be held.
+func (r *ProductRepository) versioned(id primitive.ObjectID, version int64) (*storedProduct, error) {
+	stored, ok := r.products[id]
+	if !ok {
+		return nil, errors.StandardError(errors.ENOTFOUND, product.ErrProductNotFound)
+	}
+	if stored.product.Version != version {
+		logger.Warn().
+			Str("product_id", id.Hex()).
+			Msg("product version conflict")
+		return nil, errors.StandardError(errors.ECONFLICT, product.ErrVersionConflict)
+	}
+	return stored, nil
+}
+
+// checkNameFree refuses a name another product already has, as the unique
+// name index does in the other backends. r.mu must be held.
+func (r *ProductRepository) checkNameFree(prod *product.Product) error {
+	for id, stored := range r.products {
+		if id != prod.ID && stored.product.Name == prod.Name {
+			logger.Error().
+				Str("product_name", prod.Name).
+				Msg("product already exists")
+			return errors.StandardError(errors.ECONFLICT, product.ErrProductAlreadyExists)
+		}
+	}
+	return nil
+}
+
+// ordered returns the stored products in insertion order. r.mu must be held.
+func (r *ProductRepository) ordered() []*product.Product {
+	stored := make([]*storedProduct, 0, len(r.products))
+	for _, s := range r.products {
+		stored = append(stored, s)
+	}
+	sort.Slice(stored, func(i, j int) bool {
+		return stored[i].seq < stored[j].seq
+	})
+
+	products := make([]*product.Product, len(stored))
+	for i, s := range stored {
+		products[i] = s.product
+	}
+	return products
+}
+
+// clone copies a product deeply enough that neither copy sees changes to the
+// other.
+func clone(p *product.Product) *product.Product {
+	c := *p
+	c.StockLevels = cloneLevels(p.StockLevels)
+	c.ReservedLevels = cloneLevels(p.ReservedLevels)
+	return &c
+}
+
+func cloneLevels(levels map[string]int) map[string]int {
+	if levels == nil {
+		return nil
+	}
+	c := make(map[string]int, len(levels))
+	for locationID, quantity := range levels {
+		c[locationID] = quantity
+	}
+	return c
+}
+
+func parseID(id string) (primitive.ObjectID, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, errors.StandardError(errors.EINVALID, fmt.Errorf("invalid product ID: %v", err))
+	}
+	return objectID, nil
+}
+
+// storageError wraps a failure with the code the MongoDB repository reports
+// for it, so callers can't tell the two apart.
+func storageError(msg string, err error) *errors.AppError {
+	code := errors.EREPOSITORY
+	if stderrors.Is(err, context.DeadlineExceeded) {
+		code = errors.ETIMEOUT
+	}
+	return errors.StandardError(code, fmt.Errorf("%s: %v", msg, err))
+}
@@ -0,0 +1,14 @@
+package memory
+
+import (
+	"testing"
+
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/domain/product/producttest"
+)
+
+func TestProductRepositoryContract(t *testing.T) {
+	producttest.RunRepositoryContract(t, func(t *testing.T) product.Repository {
+		return NewProductRepository()
+	})
+}
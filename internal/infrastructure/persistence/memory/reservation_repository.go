@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-microservice-product-porto/internal/domain/reservation"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+// ReservationRepository keeps reservations in memory and answers like the
+// MongoDB repository, including its error codes.
+type ReservationRepository struct {
+	mu           sync.RWMutex
+	reservations map[primitive.ObjectID]*reservation.Reservation
+}
+
+func NewReservationRepository() *ReservationRepository {
+	return &ReservationRepository{
+		reservations: make(map[primitive.ObjectID]*reservation.Reservation),
+	}
+}
+
+func (r *ReservationRepository) Create(ctx context.Context, res *reservation.Reservation) error {
+	if err := ctx.Err(); err != nil {
+		return storageError("failed to create reservation", err)
+	}
+
+	if res.ID.IsZero() {
+		res.ID = primitive.NewObjectID()
+	}
+
+	stored := *res
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reservations[res.ID] = &stored
+	return nil
+}
+
+func (r *ReservationRepository) FindByID(ctx context.Context, id string) (*reservation.Reservation, error) {
+	objectID, err := parseReservationID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, storageError("failed to find reservation", err)
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	stored, ok := r.reservations[objectID]
+	if !ok {
+		return nil, errors.StandardError(errors.ENOTFOUND, reservation.ErrReservationNotFound)
+	}
+	res := *stored
+	return &res, nil
+}
+
+func (r *ReservationRepository) Transition(ctx context.Context, id string, from, to reservation.Status) (*reservation.Reservation, error) {
+	objectID, err := parseReservationID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, storageError("failed to transition reservation", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.reservations[objectID]
+	if !ok {
+		return nil, errors.StandardError(errors.ENOTFOUND, reservation.ErrReservationNotFound)
+	}
+	if stored.Status != from {
+		return nil, errors.StandardError(errors.ECONFLICT, reservation.ErrReservationNotActive)
+	}
+
+	stored.Status = to
+	stored.UpdatedAt = time.Now()
+	res := *stored
+	return &res, nil
+}
+
+func (r *ReservationRepository) FindExpired(ctx context.Context, now time.Time, limit int) ([]*reservation.Reservation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, storageError("failed to find expired reservations", err)
+	}
+
+	r.mu.RLock()
+	var expired []*reservation.Reservation
+	for _, stored := range r.reservations {
+		if stored.Status == reservation.StatusActive && stored.IsExpired(now) {
+			res := *stored
+			expired = append(expired, &res)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(expired, func(i, j int) bool {
+		if !expired[i].ExpiresAt.Equal(expired[j].ExpiresAt) {
+			return expired[i].ExpiresAt.Before(expired[j].ExpiresAt)
+		}
+		return bytes.Compare(expired[i].ID[:], expired[j].ID[:]) < 0
+	})
+	if limit > 0 && len(expired) > limit {
+		expired = expired[:limit]
+	}
+	return expired, nil
+}
+
+func parseReservationID(id string) (primitive.ObjectID, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, errors.StandardError(errors.EINVALID, fmt.Errorf("invalid reservation ID: %v", err))
+	}
+	return objectID, nil
+}
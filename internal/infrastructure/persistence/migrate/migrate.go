@@ -0,0 +1,245 @@
+// Package migrate applies versioned migrations to a store and records which
+// ones ran in its schema_migrations collection or table. It knows nothing
+// about any particular database: each backend supplies a Store and the
+// migrations it needs, written against whatever handle T that store hands
+// them.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go-microservice-product-porto/pkg/errors"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+// Migration is one step in the evolution of a store. Down may be nil for a
+// step that cannot be undone, such as a backfill; reverting past it fails.
+type Migration[T any] struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, target T) error
+	Down    func(ctx context.Context, target T) error
+}
+
+// Record is an applied migration as the store remembers it.
+type Record struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
+
+// Store keeps the migration history and runs migrations against the data.
+type Store[T any] interface {
+	// Lock keeps other processes from migrating until unlock is called.
+	Lock(ctx context.Context) (unlock func(), err error)
+	// Applied returns every recorded migration.
+	Applied(ctx context.Context) ([]Record, error)
+	// Run calls fn and then records the migration, or forgets it when up is
+	// false. Stores that have transactions do both in one; the others must
+	// be given migrations that are safe to run again.
+	Run(ctx context.Context, m Record, up bool, fn func(ctx context.Context, target T) error) error
+}
+
+// Status is a migration and whether it has been applied.
+type Status struct {
+	Version int
+	Name    string
+	Applied bool
+	// AppliedAt is zero for migrations that have not run.
+	AppliedAt time.Time
+	// Unknown marks a recorded migration this build does not declare,
+	// usually one applied by a newer release.
+	Unknown bool
+}
+
+// Migrator applies a fixed, ordered set of migrations to a store.
+type Migrator[T any] struct {
+	store      Store[T]
+	migrations []Migration[T]
+}
+
+// New checks that every migration has a distinct, positive version and an Up
+// step, and orders them by version.
+func New[T any](store Store[T], migrations []Migration[T]) (*Migrator[T], error) {
+	sorted := make([]Migration[T], len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	for i, m := range sorted {
+		if m.Version <= 0 {
+			return nil, errors.StandardError(errors.EINVALID, fmt.Errorf("migration %s has version %d, want a positive version", m.Name, m.Version))
+		}
+		if m.Up == nil {
+			return nil, errors.StandardError(errors.EINVALID, fmt.Errorf("migration %d %s has no up step", m.Version, m.Name))
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, errors.StandardError(errors.EINVALID, fmt.Errorf("migrations %s and %s share version %d", sorted[i-1].Name, m.Name, m.Version))
+		}
+	}
+
+	return &Migrator[T]{store: store, migrations: sorted}, nil
+}
+
+// Latest is the highest declared version, or 0 when there are none.
+func (m *Migrator[T]) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies every pending migration up to and including version, in order,
+// and returns the ones it applied. It stops at the first failure, leaving
+// the ones before it applied.
+func (m *Migrator[T]) Up(ctx context.Context, version int) ([]Record, error) {
+	unlock, err := m.store.Lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Record
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		record := Record{Version: migration.Version, Name: migration.Name}
+		if err := m.run(ctx, migration, record, true); err != nil {
+			return done, err
+		}
+		done = append(done, record)
+	}
+	return done, nil
+}
+
+// Down reverts every applied migration above version, newest first, and
+// returns the ones it reverted.
+func (m *Migrator[T]) Down(ctx context.Context, version int) ([]Record, error) {
+	unlock, err := m.store.Lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	declared := make(map[int]bool, len(m.migrations))
+	for _, migration := range m.migrations {
+		declared[migration.Version] = true
+	}
+	for _, record := range applied {
+		if record.Version > version && !declared[record.Version] {
+			return nil, errors.StandardError(errors.EINVALID, fmt.Errorf("migration %d %s was applied by another release and cannot be reverted by this one", record.Version, record.Name))
+		}
+	}
+
+	var done []Record
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version <= version {
+			break
+		}
+		record, ok := applied[migration.Version]
+		if !ok {
+			continue
+		}
+		if migration.Down == nil {
+			return done, errors.StandardError(errors.EINVALID, fmt.Errorf("migration %d %s cannot be reverted", migration.Version, migration.Name))
+		}
+
+		if err := m.run(ctx, migration, record, false); err != nil {
+			return done, err
+		}
+		done = append(done, record)
+	}
+	return done, nil
+}
+
+// Status lists the declared migrations and any recorded ones this build does
+// not know, by version.
+func (m *Migrator[T]) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if record, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = record.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range applied {
+		statuses = append(statuses, Status{
+			Version:   record.Version,
+			Name:      record.Name,
+			Applied:   true,
+			AppliedAt: record.AppliedAt,
+			Unknown:   true,
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+func (m *Migrator[T]) applied(ctx context.Context) (map[int]Record, error) {
+	records, err := m.store.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int]Record, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+func (m *Migrator[T]) run(ctx context.Context, migration Migration[T], record Record, up bool) error {
+	step, direction := migration.Up, "up"
+	if !up {
+		step, direction = migration.Down, "down"
+	}
+
+	start := time.Now()
+	if err := m.store.Run(ctx, record, up, step); err != nil {
+		logger.Error().
+			Int("version", migration.Version).
+			Str("name", migration.Name).
+			Str("direction", direction).
+			Err(err).
+			Msg("failed to run migration")
+		return fmt.Errorf("migration %d %s (%s): %w", migration.Version, migration.Name, direction, err)
+	}
+
+	logger.Info().
+		Int("version", migration.Version).
+		Str("name", migration.Name).
+		Str("direction", direction).
+		Dur("took", time.Since(start)).
+		Msg("migration ran")
+	return nil
+}
@@ -0,0 +1,221 @@
+package migrate
+
+import (
+	"context"
+	stderrors "errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeStore keeps its history in memory and hands migrations a log of the
+// steps they ran.
+type fakeStore struct {
+	mu      sync.Mutex
+	records map[int]Record
+	log     []string
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{records: map[int]Record{}}
+}
+
+func (s *fakeStore) Lock(ctx context.Context) (func(), error) {
+	s.mu.Lock()
+	return s.mu.Unlock, nil
+}
+
+func (s *fakeStore) Applied(ctx context.Context) ([]Record, error) {
+	records := make([]Record, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (s *fakeStore) Run(ctx context.Context, m Record, up bool, fn func(ctx context.Context, log *[]string) error) error {
+	if err := fn(ctx, &s.log); err != nil {
+		return err
+	}
+	if up {
+		m.AppliedAt = time.Now()
+		s.records[m.Version] = m
+	} else {
+		delete(s.records, m.Version)
+	}
+	return nil
+}
+
+func step(entry string) func(ctx context.Context, log *[]string) error {
+	return func(ctx context.Context, log *[]string) error {
+		*log = append(*log, entry)
+		return nil
+	}
+}
+
+func testMigrations() []Migration[*[]string] {
+	return []Migration[*[]string]{
+		{Version: 3, Name: "three", Up: step("up 3"), Down: step("down 3")},
+		{Version: 1, Name: "one", Up: step("up 1"), Down: step("down 1")},
+		{Version: 2, Name: "two", Up: step("up 2"), Down: step("down 2")},
+	}
+}
+
+func versions(records []Record) []int {
+	result := make([]int, len(records))
+	for i, record := range records {
+		result[i] = record.Version
+	}
+	return result
+}
+
+func TestUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	migrator, err := New[*[]string](store, testMigrations())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if migrator.Latest() != 3 {
+		t.Errorf("Latest() = %d, want 3", migrator.Latest())
+	}
+
+	applied, err := migrator.Up(ctx, 2)
+	if err != nil {
+		t.Fatalf("Up(2): %v", err)
+	}
+	if got := versions(applied); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("Up(2) applied %v, want [1 2]", got)
+	}
+
+	applied, err = migrator.Up(ctx, migrator.Latest())
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if got := versions(applied); !reflect.DeepEqual(got, []int{3}) {
+		t.Errorf("Up applied %v, want [3]", got)
+	}
+
+	applied, err = migrator.Up(ctx, migrator.Latest())
+	if err != nil || len(applied) != 0 {
+		t.Errorf("Up again = %v, %v, want nothing to do", versions(applied), err)
+	}
+
+	reverted, err := migrator.Down(ctx, 1)
+	if err != nil {
+		t.Fatalf("Down(1): %v", err)
+	}
+	if got := versions(reverted); !reflect.DeepEqual(got, []int{3, 2}) {
+		t.Errorf("Down(1) reverted %v, want [3 2]", got)
+	}
+
+	want := []string{"up 1", "up 2", "up 3", "down 3", "down 2"}
+	if !reflect.DeepEqual(store.log, want) {
+		t.Errorf("steps = %v, want %v", store.log, want)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	var applies []bool
+	for _, status := range statuses {
+		applies = append(applies, status.Applied)
+	}
+	if !reflect.DeepEqual(applies, []bool{true, false, false}) {
+		t.Errorf("applied = %v, want only 1", applies)
+	}
+}
+
+func TestUpStopsAtFailure(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	failure := stderrors.New("boom")
+
+	migrations := testMigrations()
+	migrations[2].Up = func(ctx context.Context, log *[]string) error { return failure }
+	migrator, err := New[*[]string](store, migrations)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	applied, err := migrator.Up(ctx, migrator.Latest())
+	if !stderrors.Is(err, failure) {
+		t.Errorf("Up error = %v, want %v", err, failure)
+	}
+	if got := versions(applied); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("Up applied %v, want [1]", got)
+	}
+	if _, ok := store.records[2]; ok {
+		t.Error("failed migration was recorded")
+	}
+}
+
+func TestDownRefusesIrreversible(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+
+	migrations := testMigrations()
+	migrations[1].Down = nil // version 1
+	migrator, err := New[*[]string](store, migrations)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := migrator.Up(ctx, migrator.Latest()); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	reverted, err := migrator.Down(ctx, 0)
+	if err == nil {
+		t.Fatal("Down(0) reverted an irreversible migration")
+	}
+	if got := versions(reverted); !reflect.DeepEqual(got, []int{3, 2}) {
+		t.Errorf("Down(0) reverted %v, want [3 2]", got)
+	}
+}
+
+func TestUnknownMigrations(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	store.records[9] = Record{Version: 9, Name: "from the future"}
+
+	migrator, err := New[*[]string](store, testMigrations())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if last := statuses[len(statuses)-1]; last.Version != 9 || !last.Unknown {
+		t.Errorf("last status = %+v, want unknown version 9", last)
+	}
+
+	if _, err := migrator.Down(ctx, 0); err == nil {
+		t.Error("Down(0) reverted past a migration it does not know")
+	}
+}
+
+func TestNewRejectsBadMigrations(t *testing.T) {
+	tests := []struct {
+		name       string
+		migrations []Migration[*[]string]
+	}{
+		{"duplicate version", []Migration[*[]string]{
+			{Version: 1, Name: "a", Up: step("a")},
+			{Version: 1, Name: "b", Up: step("b")},
+		}},
+		{"zero version", []Migration[*[]string]{{Name: "a", Up: step("a")}}},
+		{"no up step", []Migration[*[]string]{{Version: 1, Name: "a"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New[*[]string](newFakeStore(), tt.migrations); err == nil {
+				t.Error("New() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -2,47 +2,229 @@ package mongodb
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"net/url"
+	"os"
 	"time"
 
+	"go-microservice-product-porto/pkg/config"
 	"go-microservice-product-porto/pkg/errors"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
 )
 
+// defaultDatabase is used when neither the config nor the URI names one.
+const defaultDatabase = "products_db"
+
+// MongoConfig describes how to reach the database. URI, when set, is used as
+// is and Host, Port, User and Password are ignored; otherwise Host and Port
+// default to localhost:27017. The other settings are applied on top of it,
+// so they win over the same options in the URI.
 type MongoConfig struct {
+	URI      string
 	Host     string
 	Port     string
 	User     string
 	Password string
-	DBName   string
+	// DBName defaults to the database in the URI, then to products_db.
+	DBName     string
+	AuthSource string
+
+	ReplicaSet string
+	// ReadPreference is a mode such as primary or secondaryPreferred.
+	ReadPreference string
+	// Direct connects to the one host given without discovering the rest of
+	// its replica set.
+	Direct bool
+
+	TLS         bool
+	TLSCAFile   string
+	TLSCertFile string
+	// TLSKeyFile defaults to TLSCertFile, for PEM files holding both.
+	TLSKeyFile  string
+	TLSInsecure bool
+
+	MinPoolSize     uint64
+	MaxPoolSize     uint64
+	MaxConnIdleTime time.Duration
+
+	ConnectTimeout         time.Duration
+	ServerSelectionTimeout time.Duration
+	// Timeout bounds every operation, including retries. Zero leaves it to
+	// each caller's context.
+	Timeout time.Duration
 }
 
-func InitMongoDB(cfg MongoConfig) (*mongo.Client, error) {
-	var uri string
+// ConfigFromApp takes the MongoDB settings from the application config.
+func ConfigFromApp(cfg *config.Config) MongoConfig {
+	return MongoConfig{
+		URI:                    cfg.MongoURI,
+		Host:                   cfg.MongoHost,
+		Port:                   cfg.MongoPort,
+		User:                   cfg.MongoUser,
+		Password:               cfg.MongoPassword,
+		DBName:                 cfg.MongoDBName,
+		AuthSource:             cfg.MongoAuthSource,
+		ReplicaSet:             cfg.MongoReplicaSet,
+		ReadPreference:         cfg.MongoReadPreference,
+		Direct:                 cfg.MongoDirect,
+		TLS:                    cfg.MongoTLS,
+		TLSCAFile:              cfg.MongoTLSCAFile,
+		TLSCertFile:            cfg.MongoTLSCertFile,
+		TLSKeyFile:             cfg.MongoTLSKeyFile,
+		TLSInsecure:            cfg.MongoTLSInsecure,
+		MinPoolSize:            cfg.MongoMinPoolSize,
+		MaxPoolSize:            cfg.MongoMaxPoolSize,
+		MaxConnIdleTime:        cfg.MongoMaxConnIdleTime,
+		ConnectTimeout:         cfg.MongoConnectTimeout,
+		ServerSelectionTimeout: cfg.MongoServerSelectionTimeout,
+		Timeout:                cfg.MongoTimeout,
+	}
+}
 
-	// Build connection string based on credentials presence
-	if cfg.User != "" && cfg.Password != "" {
-		uri = fmt.Sprintf("mongodb://%s:%s@%s:%s", cfg.User, cfg.Password, cfg.Host, cfg.Port)
-	} else {
-		uri = fmt.Sprintf("mongodb://%s:%s", cfg.Host, cfg.Port)
+func (cfg MongoConfig) uri() string {
+	if cfg.URI != "" {
+		return cfg.URI
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	host, port := cfg.Host, cfg.Port
+	if host == "" {
+		host = "localhost"
+	}
+	if port == "" {
+		port = "27017"
+	}
+	u := url.URL{Scheme: "mongodb", Host: host + ":" + port}
+	if cfg.User != "" {
+		u.User = url.UserPassword(cfg.User, cfg.Password)
+	}
+	return u.String()
+}
+
+// Database is the name of the database holding the service's collections.
+func (cfg MongoConfig) Database() string {
+	if cfg.DBName != "" {
+		return cfg.DBName
+	}
+	if cs, err := connstring.Parse(cfg.uri()); err == nil && cs.Database != "" {
+		return cs.Database
+	}
+	return defaultDatabase
+}
+
+// ClientOptions turns the config into driver options.
+func (cfg MongoConfig) ClientOptions() (*options.ClientOptions, error) {
+	opts := options.Client().ApplyURI(cfg.uri()).SetRegistry(newRegistry())
+
+	if cfg.AuthSource != "" {
+		if opts.Auth == nil {
+			return nil, fmt.Errorf("auth source %q set without credentials", cfg.AuthSource)
+		}
+		opts.Auth.AuthSource = cfg.AuthSource
+	}
+	if cfg.ReplicaSet != "" {
+		opts.SetReplicaSet(cfg.ReplicaSet)
+	}
+	if cfg.ReadPreference != "" {
+		mode, err := readpref.ModeFromString(cfg.ReadPreference)
+		if err != nil {
+			return nil, fmt.Errorf("read preference: %w", err)
+		}
+		rp, err := readpref.New(mode)
+		if err != nil {
+			return nil, fmt.Errorf("read preference: %w", err)
+		}
+		opts.SetReadPreference(rp)
+	}
+	if cfg.Direct {
+		opts.SetDirect(true)
+	}
+
+	if cfg.TLS || cfg.TLSCAFile != "" || cfg.TLSCertFile != "" {
+		tlsConfig, err := cfg.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	if cfg.MinPoolSize > 0 {
+		opts.SetMinPoolSize(cfg.MinPoolSize)
+	}
+	if cfg.MaxPoolSize > 0 {
+		opts.SetMaxPoolSize(cfg.MaxPoolSize)
+	}
+	if cfg.MaxConnIdleTime > 0 {
+		opts.SetMaxConnIdleTime(cfg.MaxConnIdleTime)
+	}
+	if cfg.ConnectTimeout > 0 {
+		opts.SetConnectTimeout(cfg.ConnectTimeout)
+	}
+	if cfg.ServerSelectionTimeout > 0 {
+		opts.SetServerSelectionTimeout(cfg.ServerSelectionTimeout)
+	}
+	if cfg.Timeout > 0 {
+		opts.SetTimeout(cfg.Timeout)
+	}
+
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+func (cfg MongoConfig) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.TLSInsecure,
+	}
 
-	clientOptions := options.Client().ApplyURI(uri).SetDirect(true)
+	if cfg.TLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("TLS CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("TLS CA file %s holds no certificates", cfg.TLSCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
 
-	// Add authentication if credentials are provided
-	if cfg.User != "" && cfg.Password != "" {
-		credential := options.Credential{
-			Username: cfg.User,
-			Password: cfg.Password,
+	if cfg.TLSCertFile != "" {
+		keyFile := cfg.TLSKeyFile
+		if keyFile == "" {
+			keyFile = cfg.TLSCertFile
 		}
-		clientOptions.SetAuth(credential)
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("TLS client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
 	}
 
+	return tlsConfig, nil
+}
+
+// InitMongoDB connects to MongoDB and returns the service's database.
+func InitMongoDB(cfg MongoConfig) (*mongo.Database, error) {
+	clientOptions, err := cfg.ClientOptions()
+	if err != nil {
+		return nil, errors.StandardError(errors.EINVALID, fmt.Errorf("invalid MongoDB configuration: %v", err))
+	}
+
+	connectTimeout := cfg.ConnectTimeout
+	if connectTimeout <= 0 {
+		connectTimeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
 		return nil, errors.StandardError(errors.EREPOSITORY, fmt.Errorf("failed to connect to MongoDB: %v", err))
@@ -53,5 +235,5 @@ func InitMongoDB(cfg MongoConfig) (*mongo.Client, error) {
 		return nil, errors.StandardError(errors.ETIMEOUT, fmt.Errorf("failed to ping MongoDB: %v", err))
 	}
 
-	return client, nil
+	return client.Database(cfg.Database()), nil
 }
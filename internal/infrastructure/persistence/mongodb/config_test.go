@@ -0,0 +1,81 @@
+package mongodb
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+func TestMongoConfigDatabase(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  MongoConfig
+		want string
+	}{
+		{"default", MongoConfig{Host: "localhost", Port: "27017"}, defaultDatabase},
+		{"from URI", MongoConfig{URI: "mongodb://db:27017/catalog?authSource=admin"}, "catalog"},
+		{"configured wins", MongoConfig{URI: "mongodb://db:27017/catalog", DBName: "products"}, "products"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.Database(); got != tt.want {
+				t.Errorf("Database() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMongoConfigClientOptions(t *testing.T) {
+	opts, err := MongoConfig{
+		URI:                    "mongodb://user:p%40ss@a:27017,b:27017/catalog?replicaSet=old",
+		AuthSource:             "admin",
+		ReplicaSet:             "rs0",
+		ReadPreference:         "secondaryPreferred",
+		MaxPoolSize:            50,
+		ServerSelectionTimeout: 3 * time.Second,
+	}.ClientOptions()
+	if err != nil {
+		t.Fatalf("ClientOptions() error = %v", err)
+	}
+
+	if len(opts.Hosts) != 2 {
+		t.Errorf("hosts = %v, want both replica set members", opts.Hosts)
+	}
+	if opts.Auth == nil || opts.Auth.Username != "user" || opts.Auth.Password != "p@ss" || opts.Auth.AuthSource != "admin" {
+		t.Errorf("auth = %+v, want user/p@ss against admin", opts.Auth)
+	}
+	if opts.ReplicaSet == nil || *opts.ReplicaSet != "rs0" {
+		t.Errorf("replica set = %v, want rs0", opts.ReplicaSet)
+	}
+	if opts.ReadPreference == nil || opts.ReadPreference.Mode() != readpref.SecondaryPreferredMode {
+		t.Errorf("read preference = %v, want secondaryPreferred", opts.ReadPreference)
+	}
+	if opts.MaxPoolSize == nil || *opts.MaxPoolSize != 50 {
+		t.Errorf("max pool size = %v, want 50", opts.MaxPoolSize)
+	}
+	if opts.Direct != nil && *opts.Direct {
+		t.Error("direct connection enabled, want replica set discovery")
+	}
+}
+
+func TestMongoConfigClientOptionsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  MongoConfig
+	}{
+		{"auth source without credentials", MongoConfig{Host: "localhost", Port: "27017", AuthSource: "admin"}},
+		{"unknown read preference", MongoConfig{Host: "localhost", Port: "27017", ReadPreference: "closest"}},
+		{"missing CA file", MongoConfig{Host: "localhost", Port: "27017", TLSCAFile: "/does/not/exist.pem"}},
+		{"direct to many hosts", MongoConfig{URI: "mongodb://a:27017,b:27017", Direct: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.cfg.ClientOptions(); err == nil {
+				t.Error("ClientOptions() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"go-microservice-product-porto/internal/domain/location"
+	"go-microservice-product-porto/pkg/errors"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+type LocationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewLocationRepository(db *mongo.Database) *LocationRepository {
+	collection := db.Collection("locations")
+	return &LocationRepository{
+		collection: collection,
+	}
+}
+
+func (r *LocationRepository) Create(ctx context.Context, loc *location.Location) error {
+	logger.Debug().
+		Str("code", loc.Code).
+		Msg("attempting to create location")
+
+	if loc.ID.IsZero() {
+		loc.ID = primitive.NewObjectID()
+	}
+
+	if err := r.ensureUniqueCode(ctx, loc); err != nil {
+		return err
+	}
+
+	if _, err := r.collection.InsertOne(ctx, loc); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errors.StandardError(errors.ECONFLICT, location.ErrLocationAlreadyExists)
+		}
+		logger.Error().
+			Str("code", loc.Code).
+			Err(err).
+			Msg("failed to create location")
+		return storageError("failed to create location", err)
+	}
+
+	logger.Info().
+		Str("location_id", loc.ID.Hex()).
+		Msg("location created successfully")
+	return nil
+}
+
+func (r *LocationRepository) FindByID(ctx context.Context, id string) (*location.Location, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.StandardError(errors.EINVALID, fmt.Errorf("invalid location ID: %v", err))
+	}
+
+	var loc location.Location
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&loc)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.StandardError(errors.ENOTFOUND, location.ErrLocationNotFound)
+	}
+	if err != nil {
+		logger.Error().
+			Str("location_id", id).
+			Err(err).
+			Msg("failed to find location")
+		return nil, storageError("failed to find location", err)
+	}
+
+	return &loc, nil
+}
+
+func (r *LocationRepository) FindAll(ctx context.Context) ([]*location.Location, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "code", Value: 1}}))
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("failed to find locations")
+		return nil, storageError("failed to find locations", err)
+	}
+	defer cursor.Close(ctx)
+
+	locations := []*location.Location{}
+	if err := cursor.All(ctx, &locations); err != nil {
+		return nil, storageError("failed to decode locations", err)
+	}
+
+	return locations, nil
+}
+
+func (r *LocationRepository) Update(ctx context.Context, loc *location.Location) error {
+	if err := r.ensureUniqueCode(ctx, loc); err != nil {
+		return err
+	}
+
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": loc.ID}, loc)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errors.StandardError(errors.ECONFLICT, location.ErrLocationAlreadyExists)
+		}
+		logger.Error().
+			Str("location_id", loc.ID.Hex()).
+			Err(err).
+			Msg("failed to update location")
+		return storageError("failed to update location", err)
+	}
+	if result.MatchedCount == 0 {
+		return errors.StandardError(errors.ENOTFOUND, location.ErrLocationNotFound)
+	}
+
+	logger.Info().
+		Str("location_id", loc.ID.Hex()).
+		Msg("location updated successfully")
+	return nil
+}
+
+func (r *LocationRepository) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.StandardError(errors.EINVALID, fmt.Errorf("invalid location ID: %v", err))
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		logger.Error().
+			Str("location_id", id).
+			Err(err).
+			Msg("failed to delete location")
+		return storageError("failed to delete location", err)
+	}
+	if result.DeletedCount == 0 {
+		return errors.StandardError(errors.ENOTFOUND, location.ErrLocationNotFound)
+	}
+
+	logger.Info().
+		Str("location_id", id).
+		Msg("location deleted successfully")
+	return nil
+}
+
+// ensureUniqueCode rejects a code already used by another location.
+func (r *LocationRepository) ensureUniqueCode(ctx context.Context, loc *location.Location) error {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"code": loc.Code, "_id": bson.M{"$ne": loc.ID}})
+	if err != nil {
+		return storageError("failed to check location code", err)
+	}
+	if count > 0 {
+		return errors.StandardError(errors.ECONFLICT, location.ErrLocationAlreadyExists)
+	}
+	return nil
+}
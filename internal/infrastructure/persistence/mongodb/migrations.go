@@ -0,0 +1,199 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"go-microservice-product-porto/internal/infrastructure/persistence/migrate"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+const (
+	migrationsCollection = "schema_migrations"
+
+	// migrationLockID is the document that marks migrations as running. It
+	// sits beside the records, whose IDs are their versions.
+	migrationLockID = "lock"
+
+	// moneyPricesVersion is the migration after which every price is
+	// stored as Money.
+	moneyPricesVersion = 1
+
+	// migrationLockLease is how long a lock outlives a migrator that died
+	// without releasing it.
+	migrationLockLease = 15 * time.Minute
+)
+
+// MigrationStore records migrations in the schema_migrations collection.
+// MongoDB cannot run a migration and record it in one step, so migrations run
+// against it must be safe to run again.
+type MigrationStore struct {
+	db         *mongo.Database
+	collection *mongo.Collection
+}
+
+func NewMigrationStore(db *mongo.Database) *MigrationStore {
+	return &MigrationStore{
+		db:         db,
+		collection: db.Collection(migrationsCollection),
+	}
+}
+
+type migrationRecord struct {
+	Version   int       `bson:"_id"`
+	Name      string    `bson:"name"`
+	AppliedAt time.Time `bson:"applied_at"`
+}
+
+// Lock takes the lock document, waiting for whoever holds it to finish or
+// for their lease to run out.
+func (s *MigrationStore) Lock(ctx context.Context) (func(), error) {
+	token := primitive.NewObjectID()
+	for {
+		now := time.Now()
+		filter := bson.M{"_id": migrationLockID, "expires_at": bson.M{"$lt": now}}
+		update := bson.M{"$set": bson.M{"token": token, "expires_at": now.Add(migrationLockLease)}}
+		_, err := s.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+		if err == nil {
+			break
+		}
+		// A lock that is held and still valid makes the upsert collide
+		if !mongo.IsDuplicateKeyError(err) {
+			return nil, storageError("failed to lock migrations", err)
+		}
+
+		logger.Info().Msg("waiting for another migrator to finish")
+		select {
+		case <-ctx.Done():
+			return nil, storageError("failed to lock migrations", ctx.Err())
+		case <-time.After(time.Second):
+		}
+	}
+
+	return func() {
+		_, err := s.collection.DeleteOne(context.Background(), bson.M{"_id": migrationLockID, "token": token})
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Msg("failed to unlock migrations")
+		}
+	}, nil
+}
+
+func (s *MigrationStore) Applied(ctx context.Context) ([]migrate.Record, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{"_id": bson.M{"$type": "number"}}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, storageError("failed to read schema_migrations", err)
+	}
+	defer cursor.Close(ctx)
+
+	var stored []migrationRecord
+	if err := cursor.All(ctx, &stored); err != nil {
+		return nil, storageError("failed to read schema_migrations", err)
+	}
+
+	records := make([]migrate.Record, len(stored))
+	for i, record := range stored {
+		records[i] = migrate.Record(record)
+	}
+	return records, nil
+}
+
+func (s *MigrationStore) Run(ctx context.Context, m migrate.Record, up bool, fn func(ctx context.Context, db *mongo.Database) error) error {
+	if err := fn(ctx, s.db); err != nil {
+		return err
+	}
+
+	if !up {
+		if _, err := s.collection.DeleteOne(ctx, bson.M{"_id": m.Version}); err != nil {
+			return storageError("failed to forget migration", err)
+		}
+		return nil
+	}
+
+	record := migrationRecord{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}
+	_, err := s.collection.ReplaceOne(ctx, bson.M{"_id": m.Version}, record, options.Replace().SetUpsert(true))
+	if err != nil {
+		return storageError("failed to record migration", err)
+	}
+	return nil
+}
+
+// NewMigrator returns the migrator for the MongoDB data migrations, with
+// products in the named collection.
+func NewMigrator(db *mongo.Database, productsCollection string) (*migrate.Migrator[*mongo.Database], error) {
+	return migrate.New[*mongo.Database](NewMigrationStore(db), Migrations(productsCollection))
+}
+
+// Migrations lists the data migrations, oldest first.
+func Migrations(productsCollection string) []migrate.Migration[*mongo.Database] {
+	if productsCollection == "" {
+		productsCollection = defaultProductsCollection
+	}
+
+	return []migrate.Migration[*mongo.Database]{
+		{
+			// Prices move from a plain number to the Money value object,
+			// {amount, currency}, which is how products are written now.
+			// Products never stored a currency, so none is set.
+			Version: moneyPricesVersion,
+			Name:    "move_prices_to_money",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				_, err := db.Collection(productsCollection).UpdateMany(ctx,
+					bson.M{"price": bson.M{"$type": "number"}},
+					bson.A{bson.M{"$set": bson.M{"price": bson.M{"amount": "$price"}}}},
+				)
+				return err
+			},
+			// Prices go back to plain numbers. The validator only accepts
+			// Money prices, hence the bypass.
+			Down: func(ctx context.Context, db *mongo.Database) error {
+				_, err := db.Collection(productsCollection).UpdateMany(ctx,
+					bson.M{"price": bson.M{"$type": "object"}},
+					bson.A{bson.M{"$set": bson.M{"price": "$price.amount"}}},
+					options.Update().SetBypassDocumentValidation(true),
+				)
+				return err
+			},
+		},
+		{
+			// Products written before versioning or reservations have no
+			// version or reserved fields, which every query touching them
+			// has to allow for. Storing the 0 they are read as keeps the
+			// ETags clients already hold valid.
+			Version: 2,
+			Name:    "backfill_product_versions",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				products := db.Collection(productsCollection)
+				_, err := products.UpdateMany(ctx,
+					bson.M{"version": bson.M{"$exists": false}},
+					bson.M{"$set": bson.M{"version": int64(0)}},
+				)
+				if err != nil {
+					return err
+				}
+				_, err = products.UpdateMany(ctx,
+					bson.M{"reserved": bson.M{"$exists": false}},
+					bson.M{"$set": bson.M{"reserved": 0}},
+				)
+				return err
+			},
+			// Every write moves the version on from 0, so products still at
+			// 0 are the ones untouched since the backfill. Their reserved
+			// count is 0 too and goes with it. A backfilled reserved count
+			// on a product that had a version stays: it reads the same.
+			Down: func(ctx context.Context, db *mongo.Database) error {
+				_, err := db.Collection(productsCollection).UpdateMany(ctx,
+					bson.M{"version": int64(0)},
+					bson.M{"$unset": bson.M{"version": "", "reserved": ""}},
+				)
+				return err
+			},
+		},
+	}
+}
@@ -0,0 +1,93 @@
+package mongodb
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-microservice-product-porto/internal/domain/product"
+)
+
+// migrationTestCollection keeps the migration tests away from real products.
+const migrationTestCollection = "products_migrations"
+
+// TestMigrationsUpAndDown runs every migration's steps against legacy
+// documents in the deployment named by MONGODB_TEST_URI, and is skipped when
+// that isn't set. The steps run directly, so schema_migrations is left alone.
+func TestMigrationsUpAndDown(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI not set")
+	}
+
+	ctx := context.Background()
+	db, err := InitMongoDB(MongoConfig{URI: uri})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer db.Client().Disconnect(ctx)
+
+	collection := db.Collection(migrationTestCollection)
+	if err := collection.Drop(ctx); err != nil {
+		t.Fatalf("drop collection: %v", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	legacyID := primitive.NewObjectID()
+	_, err = collection.InsertOne(ctx, bson.M{
+		"_id": legacyID, "name": "Widget", "price": 9.5, "stock": 1, "created_at": now, "updated_at": now,
+	})
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	migrations := Migrations(migrationTestCollection)
+	for _, m := range migrations {
+		if err := m.Up(ctx, db); err != nil {
+			t.Fatalf("up %s: %v", m.Name, err)
+		}
+	}
+
+	var migrated bson.M
+	if err := collection.FindOne(ctx, bson.M{"_id": legacyID}).Decode(&migrated); err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	if price, ok := migrated["price"].(bson.M); !ok || price["amount"] != 9.5 {
+		t.Errorf("price = %v after up, want {amount: 9.5}", migrated["price"])
+	}
+
+	repo := NewProductRepository(db, migrationTestCollection)
+	prod, err := repo.FindByID(ctx, legacyID.Hex())
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if got, want := prod.Money(), product.NewMoney(9.5, ""); got != want {
+		t.Errorf("price = %+v after up, want %+v", got, want)
+	}
+	if prod.Version != 0 || prod.Reserved != 0 {
+		t.Errorf("version %d, reserved %d after up, want 0 and 0", prod.Version, prod.Reserved)
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if err := migrations[i].Down(ctx, db); err != nil {
+			t.Fatalf("down %s: %v", migrations[i].Name, err)
+		}
+	}
+
+	var reverted bson.M
+	if err := collection.FindOne(ctx, bson.M{"_id": legacyID}).Decode(&reverted); err != nil {
+		t.Fatalf("find: %v", err)
+	}
+	if reverted["price"] != 9.5 {
+		t.Errorf("price = %v after down, want 9.5", reverted["price"])
+	}
+	for _, field := range []string{"version", "reserved"} {
+		if _, ok := reverted[field]; ok {
+			t.Errorf("%s still set after down", field)
+		}
+	}
+}
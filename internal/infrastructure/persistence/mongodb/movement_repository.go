@@ -0,0 +1,88 @@
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"go-microservice-product-porto/internal/domain/movement"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+type MovementRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMovementRepository(db *mongo.Database) *MovementRepository {
+	collection := db.Collection("stock_movements")
+	return &MovementRepository{
+		collection: collection,
+	}
+}
+
+func (r *MovementRepository) Append(ctx context.Context, m *movement.Movement) error {
+	if m.ID.IsZero() {
+		m.ID = primitive.NewObjectID()
+	}
+
+	if _, err := r.collection.InsertOne(ctx, m); err != nil {
+		logger.Error().
+			Str("product_id", m.ProductID).
+			Str("type", string(m.Type)).
+			Err(err).
+			Msg("failed to append stock movement")
+		return storageError("failed to append stock movement", err)
+	}
+
+	logger.Debug().
+		Str("product_id", m.ProductID).
+		Str("type", string(m.Type)).
+		Int("quantity", m.Quantity).
+		Msg("stock movement appended")
+	return nil
+}
+
+func (r *MovementRepository) FindByProduct(ctx context.Context, filter movement.Filter) ([]*movement.Movement, int64, error) {
+	query := bson.M{"product_id": filter.ProductID}
+
+	occurred := bson.M{}
+	if !filter.From.IsZero() {
+		occurred["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		occurred["$lte"] = filter.To
+	}
+	if len(occurred) > 0 {
+		query["occurred_at"] = occurred
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "occurred_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((filter.Page - 1) * filter.PageSize)).
+		SetLimit(int64(filter.PageSize))
+
+	cursor, err := r.collection.Find(ctx, query, findOptions)
+	if err != nil {
+		logger.Error().
+			Str("product_id", filter.ProductID).
+			Err(err).
+			Msg("failed to find stock movements")
+		return nil, 0, storageError("failed to find stock movements", err)
+	}
+	defer cursor.Close(ctx)
+
+	movements := []*movement.Movement{}
+	if err := cursor.All(ctx, &movements); err != nil {
+		return nil, 0, storageError("failed to decode stock movements", err)
+	}
+
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, storageError("failed to count stock movements", err)
+	}
+
+	return movements, total, nil
+}
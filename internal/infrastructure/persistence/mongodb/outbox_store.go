@@ -0,0 +1,127 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"go-microservice-product-porto/internal/application/outbox"
+	"go-microservice-product-porto/pkg/errors"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+const outboxCollection = "outbox"
+
+type OutboxStore struct {
+	collection *mongo.Collection
+}
+
+func NewOutboxStore(db *mongo.Database) *OutboxStore {
+	collection := db.Collection(outboxCollection)
+	return &OutboxStore{
+		collection: collection,
+	}
+}
+
+func (s *OutboxStore) Add(ctx context.Context, messages ...*outbox.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, len(messages))
+	for i, message := range messages {
+		documents[i] = message
+	}
+
+	if _, err := s.collection.InsertMany(ctx, documents); err != nil {
+		logger.Error().
+			Int("count", len(messages)).
+			Err(err).
+			Msg("failed to add outbox messages")
+		return storageError("failed to add outbox messages", err)
+	}
+
+	return nil
+}
+
+func (s *OutboxStore) Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*outbox.Message, error) {
+	filter := bson.M{
+		"status":          outbox.StatusPending,
+		"next_attempt_at": bson.M{"$lte": now},
+		"$or": bson.A{
+			bson.M{"locked_until": bson.M{"$exists": false}},
+			bson.M{"locked_until": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"locked_until": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "occurred_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	// Claim one at a time so each lease is taken atomically
+	var messages []*outbox.Message
+	for len(messages) < limit {
+		var message outbox.Message
+		err := s.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&message)
+		if err == mongo.ErrNoDocuments {
+			break
+		}
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Msg("failed to claim outbox message")
+			return messages, storageError("failed to claim outbox message", err)
+		}
+		messages = append(messages, &message)
+	}
+
+	return messages, nil
+}
+
+func (s *OutboxStore) MarkPublished(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.StandardError(errors.EINVALID, fmt.Errorf("invalid outbox message ID: %v", err))
+	}
+
+	update := bson.M{
+		"$set":   bson.M{"status": outbox.StatusPublished, "published_at": time.Now()},
+		"$inc":   bson.M{"attempts": 1},
+		"$unset": bson.M{"locked_until": "", "last_error": ""},
+	}
+	if _, err := s.collection.UpdateByID(ctx, objectID, update); err != nil {
+		return storageError("failed to mark outbox message published", err)
+	}
+	return nil
+}
+
+func (s *OutboxStore) MarkFailed(ctx context.Context, id string, lastError string, nextAttemptAt time.Time, dead bool) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.StandardError(errors.EINVALID, fmt.Errorf("invalid outbox message ID: %v", err))
+	}
+
+	status := outbox.StatusPending
+	if dead {
+		status = outbox.StatusFailed
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":          status,
+			"last_error":      lastError,
+			"next_attempt_at": nextAttemptAt,
+		},
+		"$inc":   bson.M{"attempts": 1},
+		"$unset": bson.M{"locked_until": ""},
+	}
+	if _, err := s.collection.UpdateByID(ctx, objectID, update); err != nil {
+		return storageError("failed to mark outbox message failed", err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package mongodb
+
+import (
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+
+	"go-microservice-product-porto/internal/domain/product"
+)
+
+// productFields is a product as the default codec stores it, which leaves out
+// the price.
+type productFields product.Product
+
+// productDocument is how a product is stored: its fields, with the price as
+// the Money value object.
+type productDocument struct {
+	Fields productFields `bson:",inline"`
+	Price  product.Money `bson:"price"`
+}
+
+// storedProductDocument is a product as it is read back. Products written
+// before move_prices_to_money ran hold their price as a plain number.
+type storedProductDocument struct {
+	Fields productFields `bson:",inline"`
+	Price  bson.RawValue `bson:"price"`
+}
+
+var (
+	tProduct               = reflect.TypeOf(product.Product{})
+	tProductDocument       = reflect.TypeOf(productDocument{})
+	tStoredProductDocument = reflect.TypeOf(storedProductDocument{})
+)
+
+// newRegistry returns the default registry with products stored through
+// productDocument.
+func newRegistry() *bsoncodec.Registry {
+	registry := bson.NewRegistry()
+	registry.RegisterTypeEncoder(tProduct, bsoncodec.ValueEncoderFunc(encodeProduct))
+	registry.RegisterTypeDecoder(tProduct, bsoncodec.ValueDecoderFunc(decodeProduct))
+	return registry
+}
+
+func encodeProduct(ec bsoncodec.EncodeContext, vw bsonrw.ValueWriter, val reflect.Value) error {
+	if !val.IsValid() || val.Type() != tProduct {
+		return bsoncodec.ValueEncoderError{Name: "encodeProduct", Types: []reflect.Type{tProduct}, Received: val}
+	}
+
+	prod := val.Interface().(product.Product)
+	encoder, err := ec.LookupEncoder(tProductDocument)
+	if err != nil {
+		return err
+	}
+	return encoder.EncodeValue(ec, vw, reflect.ValueOf(productDocument{Fields: productFields(prod), Price: prod.Money()}))
+}
+
+func decodeProduct(dc bsoncodec.DecodeContext, vr bsonrw.ValueReader, val reflect.Value) error {
+	if !val.CanSet() || val.Type() != tProduct {
+		return bsoncodec.ValueDecoderError{Name: "decodeProduct", Types: []reflect.Type{tProduct}, Received: val}
+	}
+
+	decoder, err := dc.LookupDecoder(tStoredProductDocument)
+	if err != nil {
+		return err
+	}
+	var stored storedProductDocument
+	if err := decoder.DecodeValue(dc, vr, reflect.ValueOf(&stored).Elem()); err != nil {
+		return err
+	}
+
+	prod := product.Product(stored.Fields)
+	switch {
+	case stored.Price.Type == bsontype.EmbeddedDocument:
+		var price product.Money
+		if err := stored.Price.UnmarshalWithContext(&dc, &price); err != nil {
+			return err
+		}
+		prod.Price, prod.Currency = price.Amount, price.Currency
+	case stored.Price.IsNumber():
+		if err := stored.Price.UnmarshalWithContext(&dc, &prod.Price); err != nil {
+			return err
+		}
+	}
+	val.Set(reflect.ValueOf(prod))
+	return nil
+}
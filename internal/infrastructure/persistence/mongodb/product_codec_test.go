@@ -0,0 +1,61 @@
+package mongodb
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"go-microservice-product-porto/internal/domain/product"
+)
+
+func TestProductCodecStoresPriceAsMoney(t *testing.T) {
+	registry := newRegistry()
+	prod := product.NewProduct("Widget", "", 9.5, 3)
+	prod.Currency = "EUR"
+
+	raw, err := bson.MarshalWithRegistry(registry, prod)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var stored bson.M
+	if err := bson.Unmarshal(raw, &stored); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	price, ok := stored["price"].(bson.M)
+	if !ok || price["amount"] != 9.5 || price["currency"] != "EUR" {
+		t.Errorf("stored price = %v, want {amount: 9.5, currency: EUR}", stored["price"])
+	}
+	if _, ok := stored["currency"]; ok {
+		t.Error("currency stored beside the price")
+	}
+
+	var decoded product.Product
+	if err := bson.UnmarshalWithRegistry(registry, raw, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if decoded.Money() != prod.Money() || decoded.Name != prod.Name || decoded.Stock != prod.Stock {
+		t.Errorf("decoded %+v, want %+v", decoded, *prod)
+	}
+}
+
+func TestProductCodecReadsPlainPrices(t *testing.T) {
+	registry := newRegistry()
+	for _, price := range []interface{}{4.5, int32(4), int64(4)} {
+		raw, err := bson.Marshal(bson.M{"name": "Gadget", "price": price, "stock": 1})
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+
+		var decoded *product.Product
+		if err := bson.UnmarshalWithRegistry(registry, raw, &decoded); err != nil {
+			t.Fatalf("unmarshal %T price: %v", price, err)
+		}
+		want := 4.0
+		if _, ok := price.(float64); ok {
+			want = 4.5
+		}
+		if decoded.Price != want || decoded.Currency != "" {
+			t.Errorf("%T price decoded as %v %q, want %v", price, decoded.Price, decoded.Currency, want)
+		}
+	}
+}
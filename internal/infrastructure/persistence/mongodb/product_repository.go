@@ -2,8 +2,11 @@ package mongodb
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -15,14 +18,37 @@ import (
 	"go-microservice-product-porto/pkg/logger"
 )
 
+// defaultProductsCollection is where products live unless configured
+// otherwise.
+const (
+	defaultProductsCollection = "products"
+
+	// priceField is where a Money price keeps its amount.
+	priceField = "price.amount"
+	// mixedPriceField holds the amount worked out for each product while
+	// plain and Money prices are mixed.
+	mixedPriceField = "price_amount"
+)
+
+// mixedPriceAmount is a product's price amount, whether the price is a plain
+// number or Money.
+var mixedPriceAmount = bson.M{"$ifNull": bson.A{"$" + priceField, "$price"}}
+
 type ProductRepository struct {
 	collection *mongo.Collection
+	migrations *mongo.Collection
+	// moneyPrices is set once move_prices_to_money is known to have run.
+	moneyPrices atomic.Bool
 }
 
-func NewProductRepository(client *mongo.Client) *ProductRepository {
-	collection := client.Database("products_db").Collection("products")
+func NewProductRepository(db *mongo.Database, collectionName string) *ProductRepository {
+	if collectionName == "" {
+		collectionName = defaultProductsCollection
+	}
+	collection := db.Collection(collectionName)
 	return &ProductRepository{
 		collection: collection,
+		migrations: db.Collection(migrationsCollection),
 	}
 }
 
@@ -32,6 +58,13 @@ func (r *ProductRepository) Create(ctx context.Context, prod *product.Product) e
 		Float64("price", prod.Price).
 		Msg("attempting to create product")
 
+	if prod.ID.IsZero() {
+		prod.ID = primitive.NewObjectID()
+	}
+	if prod.Version == 0 {
+		prod.Version = 1
+	}
+
 	_, err := r.collection.InsertOne(ctx, prod)
 	if err != nil {
 		if mongo.IsDuplicateKeyError(err) {
@@ -45,7 +78,7 @@ func (r *ProductRepository) Create(ctx context.Context, prod *product.Product) e
 			Str("product_name", prod.Name).
 			Err(err).
 			Msg("failed to create product")
-		return errors.StandardError(errors.EREPOSITORY, fmt.Errorf("failed to create product: %v", err))
+		return storageError("failed to create product", err)
 	}
 	logger.Info().
 		Str("product_name", prod.Name).
@@ -82,7 +115,7 @@ func (r *ProductRepository) FindByID(ctx context.Context, id string) (*product.P
 			Str("product_id", id).
 			Err(err).
 			Msg("failed to find product")
-		return nil, errors.StandardError(errors.EREPOSITORY, fmt.Errorf("failed to find product: %v", err))
+		return nil, storageError("failed to find product", err)
 	}
 	logger.Info().
 		Str("product_id", id).
@@ -102,122 +135,572 @@ func (r *ProductRepository) FindAll(ctx context.Context, page, pageSize int, sor
 
 	sortFieldMap := map[string]string{
 		"name":       "name",
-		"price":      "price",
+		"price":      priceField,
 		"stock":      "stock",
 		"created_at": "created_at",
 	}
 
 	sortOpts := bson.D{}
+	sortValue, mixedPrices := 1, false
 	if sortBy != "" {
 		if mongoField, exists := sortFieldMap[sortBy]; exists {
-			sortValue := 1
 			if strings.ToLower(sortDir) == "desc" {
 				sortValue = -1
 			}
-			if sortBy == "price" {
-				sortOpts = bson.D{{Key: "price.amount", Value: sortValue}}
-			} else {
-				sortOpts = bson.D{{Key: mongoField, Value: sortValue}}
-			}
+			sortOpts = bson.D{{Key: mongoField, Value: sortValue}}
+			mixedPrices = sortBy == "price" && !r.pricesMigrated(ctx)
 		}
 	} else {
 		sortOpts = bson.D{{Key: "_id", Value: 1}}
 	}
 
-	findOptions := options.Find().
-		SetSkip(int64(skip)).
-		SetLimit(int64(pageSize)).
-		SetSort(sortOpts)
+	var products []*product.Product
+	if mixedPrices {
+		var err error
+		products, err = r.findByMixedPrices(ctx, bson.M{}, sortValue, int64(skip), int64(pageSize))
+		if err != nil {
+			return nil, 0, err
+		}
+	} else {
+		findOptions := options.Find().
+			SetSkip(int64(skip)).
+			SetLimit(int64(pageSize)).
+			SetSort(sortOpts)
+
+		cursor, err := r.collection.Find(ctx, bson.M{}, findOptions)
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Msg("failed to find products")
+			return nil, 0, storageError("failed to find products", err)
+		}
+		defer cursor.Close(ctx)
+
+		if err = cursor.All(ctx, &products); err != nil {
+			logger.Error().
+				Err(err).
+				Msg("failed to decode products")
+			return nil, 0, storageError("failed to decode products", err)
+		}
+	}
+
+	total, err := r.collection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("failed to count products")
+		return nil, 0, storageError("failed to count products", err)
+	}
+
+	logger.Info().
+		Int64("total", total).
+		Msg("products found successfully with total products is :")
+	return products, total, nil
+}
+
+// pricesMigrated reports whether move_prices_to_money has run. Until it has,
+// some prices may still be plain numbers, which price.amount doesn't reach.
+func (r *ProductRepository) pricesMigrated(ctx context.Context) bool {
+	if r.moneyPrices.Load() {
+		return true
+	}
+
+	err := r.migrations.FindOne(ctx, bson.M{"_id": moneyPricesVersion}).Err()
+	if err == nil {
+		r.moneyPrices.Store(true)
+		return true
+	}
+	if !stderrors.Is(err, mongo.ErrNoDocuments) {
+		logger.Warn().
+			Err(err).
+			Msg("failed to check whether prices are migrated")
+	}
+	return false
+}
+
+// findByMixedPrices finds products ordered by price, and then _id, while
+// plain and Money prices are mixed. No index orders them together, so each
+// product's amount is worked out and sorted on in the pipeline, and match
+// can filter on it as mixedPriceField.
+func (r *ProductRepository) findByMixedPrices(ctx context.Context, match bson.M, direction int, skip, limit int64) ([]*product.Product, error) {
+	pipeline := bson.A{
+		bson.M{"$addFields": bson.M{mixedPriceField: mixedPriceAmount}},
+		bson.M{"$match": match},
+		bson.M{"$sort": bson.D{{Key: mixedPriceField, Value: direction}, {Key: "_id", Value: direction}}},
+	}
+	if skip > 0 {
+		pipeline = append(pipeline, bson.M{"$skip": skip})
+	}
+	if limit > 0 {
+		pipeline = append(pipeline, bson.M{"$limit": limit})
+	}
+	pipeline = append(pipeline, bson.M{"$project": bson.M{mixedPriceField: 0}})
 
-	cursor, err := r.collection.Find(ctx, bson.M{}, findOptions)
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
 	if err != nil {
 		logger.Error().
 			Err(err).
 			Msg("failed to find products")
-		return nil, 0, errors.StandardError(errors.EREPOSITORY, fmt.Errorf("failed to find products: %v", err))
+		return nil, storageError("failed to find products", err)
 	}
 	defer cursor.Close(ctx)
 
-	var products []*product.Product
+	products := []*product.Product{}
 	if err = cursor.All(ctx, &products); err != nil {
 		logger.Error().
 			Err(err).
 			Msg("failed to decode products")
-		return nil, 0, errors.StandardError(errors.EREPOSITORY, fmt.Errorf("failed to decode products: %v", err))
+		return nil, storageError("failed to decode products", err)
 	}
+	return products, nil
+}
 
-	total, err := r.collection.CountDocuments(ctx, bson.M{})
+func (r *ProductRepository) FindPage(ctx context.Context, sortBy, sortDir string, after *product.Keyset, limit int) ([]*product.Product, error) {
+	logger.Debug().
+		Str("sort_by", sortBy).
+		Str("sort_dir", sortDir).
+		Bool("after", after != nil).
+		Int("limit", limit).
+		Msg("attempting to find a page of products")
+
+	if _, ok := product.SortValue(&product.Product{}, sortBy); !ok {
+		sortBy = ""
+	}
+	direction, past := 1, "$gt"
+	if strings.ToLower(sortDir) == "desc" {
+		direction, past = -1, "$lt"
+	}
+
+	// Each sort has a matching (field, _id) index, so the filter and the
+	// sort are both answered from it
+	field, mixedPrices := sortBy, false
+	if sortBy == "price" {
+		field = priceField
+		if !r.pricesMigrated(ctx) {
+			field, mixedPrices = mixedPriceField, true
+		}
+	}
+	sortOpts := bson.D{{Key: "_id", Value: direction}}
+	filter := bson.M{}
+	if sortBy != "" {
+		sortOpts = bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}}
+	}
+	if after != nil {
+		filter = bson.M{"_id": bson.M{past: after.ID}}
+		if sortBy != "" {
+			filter = bson.M{"$or": bson.A{
+				bson.M{field: bson.M{past: after.Value}},
+				bson.M{field: after.Value, "_id": bson.M{past: after.ID}},
+			}}
+		}
+	}
+
+	var products []*product.Product
+	if mixedPrices {
+		var err error
+		products, err = r.findByMixedPrices(ctx, filter, direction, 0, int64(limit))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		findOptions := options.Find().SetSort(sortOpts)
+		if limit > 0 {
+			findOptions.SetLimit(int64(limit))
+		}
+
+		cursor, err := r.collection.Find(ctx, filter, findOptions)
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Msg("failed to find products")
+			return nil, storageError("failed to find products", err)
+		}
+		defer cursor.Close(ctx)
+
+		products = []*product.Product{}
+		if err = cursor.All(ctx, &products); err != nil {
+			logger.Error().
+				Err(err).
+				Msg("failed to decode products")
+			return nil, storageError("failed to decode products", err)
+		}
+	}
+
+	logger.Info().
+		Int("count", len(products)).
+		Msg("page of products found successfully")
+	return products, nil
+}
+
+func (r *ProductRepository) Count(ctx context.Context) (int64, error) {
+	// The collection's metadata count is instant where counting documents
+	// would scan an index, and a listing's total does not need to be exact
+	total, err := r.collection.EstimatedDocumentCount(ctx)
 	if err != nil {
 		logger.Error().
 			Err(err).
 			Msg("failed to count products")
-		return nil, 0, errors.StandardError(errors.EREPOSITORY, fmt.Errorf("failed to count products: %v", err))
+		return 0, storageError("failed to count products", err)
 	}
-
-	logger.Info().
-		Int64("total", total).
-		Msg("products found successfully with total products is :")
-	return products, total, nil
+	return total, nil
 }
 
 func (r *ProductRepository) Update(ctx context.Context, prod *product.Product) error {
 	logger.Debug().
 		Str("product_id", prod.ID.Hex()).
+		Int64("version", prod.Version).
 		Msg("attempting to update product")
 
-	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": prod.ID}, prod)
+	replacement := *prod
+	replacement.Version = prod.Version + 1
+
+	result, err := r.collection.ReplaceOne(ctx, versionFilter(prod.ID, prod.Version), &replacement)
 	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			logger.Error().
+				Str("product_name", prod.Name).
+				Err(err).
+				Msg("product already exists")
+			return errors.StandardError(errors.ECONFLICT, product.ErrProductAlreadyExists)
+		}
 		logger.Error().
 			Str("product_id", prod.ID.Hex()).
 			Err(err).
 			Msg("failed to update product")
-		return errors.StandardError(errors.EREPOSITORY, fmt.Errorf("failed to update product: %v", err))
+		return storageError("failed to update product", err)
 	}
 	if result.MatchedCount == 0 {
+		return r.missOrConflict(ctx, prod.ID)
+	}
+
+	prod.Version = replacement.Version
+	logger.Info().
+		Str("product_id", prod.ID.Hex()).
+		Int64("version", prod.Version).
+		Msg("product updated successfully")
+	return nil
+}
+
+func (r *ProductRepository) Delete(ctx context.Context, prod *product.Product) error {
+	logger.Debug().
+		Str("product_id", prod.ID.Hex()).
+		Int64("version", prod.Version).
+		Msg("attempting to delete product")
+
+	result, err := r.collection.DeleteOne(ctx, versionFilter(prod.ID, prod.Version))
+	if err != nil {
 		logger.Error().
 			Str("product_id", prod.ID.Hex()).
-			Msg("product not found")
-		return errors.StandardError(errors.ENOTFOUND, product.ErrProductNotFound)
+			Err(err).
+			Msg("failed to delete product")
+		return storageError("failed to delete product", err)
+	}
+	if result.DeletedCount == 0 {
+		return r.missOrConflict(ctx, prod.ID)
 	}
 	logger.Info().
 		Str("product_id", prod.ID.Hex()).
-		Msg("product updated successfully")
+		Msg("product deleted successfully")
+
 	return nil
 }
 
-func (r *ProductRepository) Delete(ctx context.Context, id string) error {
+func (r *ProductRepository) AdjustStock(ctx context.Context, id, locationID string, delta int) (*product.Product, error) {
 	logger.Debug().
 		Str("product_id", id).
-		Msg("attempting to delete product")
+		Str("location_id", locationID).
+		Int("delta", delta).
+		Msg("attempting to adjust product stock")
+
+	// Reserved units are spoken for, so only available stock may be taken,
+	// and never more than the location (or unallocated pool) holds beyond
+	// what is reserved there.
+	free, err := freeAtExpr(locationID)
+	if err != nil {
+		return nil, err
+	}
+	inc := bson.M{"stock": delta}
+	if locationID != "" {
+		field, _ := levelField(locationID)
+		inc[field] = delta
+	}
+
+	guard := bson.M{"$expr": bson.M{"$and": bson.A{
+		bson.M{"$gte": bson.A{availableExpr, -delta}},
+		bson.M{"$gte": bson.A{free, -delta}},
+	}}}
+	return r.guardedUpdate(ctx, id, guard, inc, "adjust product stock")
+}
+
+func (r *ProductRepository) TransferStock(ctx context.Context, id, fromLocationID, toLocationID string, quantity int) (*product.Product, error) {
+	logger.Debug().
+		Str("product_id", id).
+		Str("from_location_id", fromLocationID).
+		Str("to_location_id", toLocationID).
+		Int("quantity", quantity).
+		Msg("attempting to transfer product stock")
+
+	// Reserved units stay where they are held
+	free, err := freeAtExpr(fromLocationID)
+	if err != nil {
+		return nil, err
+	}
+	inc := bson.M{}
+	if fromLocationID != "" {
+		field, _ := levelField(fromLocationID)
+		inc[field] = -quantity
+	}
+	if toLocationID != "" {
+		field, err := levelField(toLocationID)
+		if err != nil {
+			return nil, err
+		}
+		inc[field] = quantity
+	}
+
+	guard := bson.M{"$expr": bson.M{"$gte": bson.A{free, quantity}}}
+	return r.guardedUpdate(ctx, id, guard, inc, "transfer product stock")
+}
+
+func (r *ProductRepository) HasStockAt(ctx context.Context, locationID string) (bool, error) {
+	field, err := levelField(locationID)
+	if err != nil {
+		return false, err
+	}
+
+	count, err := r.collection.CountDocuments(ctx, bson.M{field: bson.M{"$gt": 0}}, options.Count().SetLimit(1))
+	if err != nil {
+		logger.Error().
+			Str("location_id", locationID).
+			Err(err).
+			Msg("failed to check stock at location")
+		return false, storageError("failed to check stock at location", err)
+	}
+	return count > 0, nil
+}
+
+func (r *ProductRepository) Reserve(ctx context.Context, id, locationID string, quantity int) (*product.Product, error) {
+	logger.Debug().
+		Str("product_id", id).
+		Str("location_id", locationID).
+		Int("quantity", quantity).
+		Msg("attempting to reserve product stock")
+
+	free, err := freeAtExpr(locationID)
+	if err != nil {
+		return nil, err
+	}
+	inc := bson.M{"reserved": quantity}
+	if locationID != "" {
+		inc[reservedLevelField(locationID)] = quantity
+	}
+
+	guard := bson.M{"$expr": bson.M{"$and": bson.A{
+		bson.M{"$gte": bson.A{availableExpr, quantity}},
+		bson.M{"$gte": bson.A{free, quantity}},
+	}}}
+	return r.guardedUpdate(ctx, id, guard, inc, "reserve product stock")
+}
+
+func (r *ProductRepository) ReleaseReserved(ctx context.Context, id, locationID string, quantity int, consume bool) (*product.Product, error) {
+	logger.Debug().
+		Str("product_id", id).
+		Str("location_id", locationID).
+		Int("quantity", quantity).
+		Bool("consume", consume).
+		Msg("attempting to release reserved product stock")
+
+	guard := bson.M{"reserved": bson.M{"$gte": quantity}}
+	inc := bson.M{"reserved": -quantity}
+	if locationID != "" {
+		if _, err := levelField(locationID); err != nil {
+			return nil, err
+		}
+		inc[reservedLevelField(locationID)] = -quantity
+	}
+	if consume {
+		// Consumed units leave the location they were held at, or the
+		// unallocated stock, so stock never drops below what locations hold
+		held, err := stockAtExpr(locationID)
+		if err != nil {
+			return nil, err
+		}
+		guard["$expr"] = bson.M{"$gte": bson.A{held, quantity}}
+		inc["stock"] = -quantity
+		if locationID != "" {
+			field, _ := levelField(locationID)
+			inc[field] = -quantity
+		}
+	}
+	return r.guardedUpdate(ctx, id, guard, inc, "release reserved product stock")
+}
+
+// freeAtExpr computes the stock held at a location, or unallocated when
+// locationID is empty, that isn't reserved there.
+func freeAtExpr(locationID string) (interface{}, error) {
+	held, err := stockAtExpr(locationID)
+	if err != nil {
+		return nil, err
+	}
+	reserved := interface{}(reservedUnallocatedExpr)
+	if locationID != "" {
+		reserved = bson.M{"$ifNull": bson.A{"$" + reservedLevelField(locationID), 0}}
+	}
+	return bson.M{"$subtract": bson.A{held, reserved}}, nil
+}
 
+// stockAtExpr computes the stock held at a location inside a query, or the
+// unallocated stock when locationID is empty.
+func stockAtExpr(locationID string) (interface{}, error) {
+	if locationID == "" {
+		return unallocatedExpr, nil
+	}
+	field, err := levelField(locationID)
+	if err != nil {
+		return nil, err
+	}
+	return bson.M{"$ifNull": bson.A{"$" + field, 0}}, nil
+}
+
+var (
+	// availableExpr computes stock minus reserved units inside a query.
+	availableExpr = bson.M{"$subtract": bson.A{"$stock", bson.M{"$ifNull": bson.A{"$reserved", 0}}}}
+
+	// allocatedExpr sums the stock held at every location.
+	allocatedExpr = bson.M{"$sum": bson.M{"$map": bson.M{
+		"input": bson.M{"$objectToArray": bson.M{"$ifNull": bson.A{"$stock_levels", bson.M{}}}},
+		"in":    "$$this.v",
+	}}}
+
+	// unallocatedExpr computes the stock not assigned to any location.
+	unallocatedExpr = bson.M{"$subtract": bson.A{"$stock", allocatedExpr}}
+
+	// reservedUnallocatedExpr computes the reserved units not counted at any
+	// location, which are held in the unallocated stock.
+	reservedUnallocatedExpr = bson.M{"$max": bson.A{0, bson.M{"$subtract": bson.A{
+		bson.M{"$ifNull": bson.A{"$reserved", 0}},
+		bson.M{"$sum": bson.M{"$map": bson.M{
+			"input": bson.M{"$objectToArray": bson.M{"$ifNull": bson.A{"$reserved_levels", bson.M{}}}},
+			"in":    "$$this.v",
+		}}},
+	}}}}
+)
+
+// levelField returns the document path of a location's stock level. Location
+// IDs become field names, so only well-formed IDs are accepted.
+func levelField(locationID string) (string, error) {
+	if _, err := primitive.ObjectIDFromHex(locationID); err != nil {
+		return "", errors.StandardError(errors.EINVALID, fmt.Errorf("invalid location ID: %v", err))
+	}
+	return "stock_levels." + locationID, nil
+}
+
+// reservedLevelField returns the document path of the quantity reserved at
+// a location, whose ID must already have been checked by levelField.
+func reservedLevelField(locationID string) string {
+	return "reserved_levels." + locationID
+}
+
+// guardedUpdate applies inc to the product only while guard holds. The guard
+// and the increment happen in one document update, so concurrent writers can
+// never push stock or reservations past their limits. Reserved levels stop
+// at zero, as holds placed before they were counted were never added.
+func (r *ProductRepository) guardedUpdate(ctx context.Context, id string, guard, inc bson.M, op string) (*product.Product, error) {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		logger.Error().
 			Str("product_id", id).
 			Err(err).
 			Msg("invalid product ID")
-		return errors.StandardError(errors.EINVALID, fmt.Errorf("invalid product ID: %v", err))
+		return nil, errors.StandardError(errors.EINVALID, fmt.Errorf("invalid product ID: %v", err))
+	}
+
+	filter := bson.M{"_id": objectID}
+	for key, value := range guard {
+		filter[key] = value
 	}
 
-	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	fields := bson.M{
+		"version":    bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$version", 0}}, 1}},
+		"updated_at": time.Now(),
+	}
+	for key, delta := range inc {
+		value := bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$" + key, 0}}, delta}}
+		if strings.HasPrefix(key, "reserved_levels.") {
+			value = bson.M{"$max": bson.A{0, value}}
+		}
+		fields[key] = value
+	}
+	update := bson.A{bson.M{"$set": fields}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var prod product.Product
+	err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&prod)
+	if err == mongo.ErrNoDocuments {
+		return nil, r.missOrInsufficient(ctx, objectID)
+	}
 	if err != nil {
 		logger.Error().
 			Str("product_id", id).
 			Err(err).
-			Msg("failed to delete product")
-		return errors.StandardError(errors.EREPOSITORY, fmt.Errorf("failed to delete product: %v", err))
+			Msgf("failed to %s", op)
+		return nil, storageError("failed to "+op, err)
 	}
-	if result.DeletedCount == 0 {
+
+	logger.Info().
+		Str("product_id", id).
+		Int("stock", prod.Stock).
+		Int("reserved", prod.Reserved).
+		Msgf("%s succeeded", op)
+	return &prod, nil
+}
+
+// missOrInsufficient explains why a guarded stock update matched nothing.
+func (r *ProductRepository) missOrInsufficient(ctx context.Context, id primitive.ObjectID) error {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return storageError("failed to check product existence", err)
+	}
+	if count == 0 {
 		logger.Error().
-			Str("product_id", id).
+			Str("product_id", id.Hex()).
 			Msg("product not found")
 		return errors.StandardError(errors.ENOTFOUND, product.ErrProductNotFound)
 	}
-	logger.Info().
-		Str("product_id", id).
-		Msg("product deleted successfully")
 
-	return nil
+	logger.Warn().
+		Str("product_id", id.Hex()).
+		Msg("insufficient stock")
+	return errors.StandardError(errors.EVALIDATION, product.ErrInsufficientStock)
+}
+
+// missOrConflict explains why a versioned write matched nothing: either the
+// product is gone or another writer advanced its version first.
+func (r *ProductRepository) missOrConflict(ctx context.Context, id primitive.ObjectID) error {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return storageError("failed to check product existence", err)
+	}
+	if count == 0 {
+		logger.Error().
+			Str("product_id", id.Hex()).
+			Msg("product not found")
+		return errors.StandardError(errors.ENOTFOUND, product.ErrProductNotFound)
+	}
+
+	logger.Warn().
+		Str("product_id", id.Hex()).
+		Msg("product version conflict")
+	return errors.StandardError(errors.ECONFLICT, product.ErrVersionConflict)
+}
+
+// versionFilter matches a product at an exact version. Documents written
+// before versioning was introduced have no version field and count as 0.
+func versionFilter(id primitive.ObjectID, version int64) bson.M {
+	if version == 0 {
+		return bson.M{"_id": id, "version": bson.M{"$in": bson.A{0, nil}}}
+	}
+	return bson.M{"_id": id, "version": version}
 }
 
 func (r *ProductRepository) Search(ctx context.Context, name string, minPrice, maxPrice float64) ([]*product.Product, error) {
@@ -236,7 +719,11 @@ func (r *ProductRepository) Search(ctx context.Context, name string, minPrice, m
 		}
 	}
 
+	field := priceField
 	if minPrice > 0 || maxPrice > 0 {
+		if !r.pricesMigrated(ctx) {
+			field = mixedPriceField
+		}
 		priceMatch := bson.M{}
 		if minPrice > 0 {
 			priceMatch["$gte"] = minPrice
@@ -244,12 +731,21 @@ func (r *ProductRepository) Search(ctx context.Context, name string, minPrice, m
 		if maxPrice > 0 {
 			priceMatch["$lte"] = maxPrice
 		}
-		matchStage["price"] = priceMatch
+		matchStage[field] = priceMatch
 	}
 
 	pipeline := []bson.M{
 		{"$match": matchStage},
 	}
+	// Plain and Money prices are still mixed, so the amount is worked out
+	// before it is matched, as findByMixedPrices does
+	if field == mixedPriceField {
+		pipeline = []bson.M{
+			{"$addFields": bson.M{mixedPriceField: mixedPriceAmount}},
+			{"$match": matchStage},
+			{"$project": bson.M{mixedPriceField: 0}},
+		}
+	}
 
 	cursor, err := r.collection.Aggregate(ctx, pipeline)
 	if err != nil {
@@ -257,7 +753,7 @@ func (r *ProductRepository) Search(ctx context.Context, name string, minPrice, m
 			Err(err).
 			Msg("failed to search products")
 
-		return nil, errors.StandardError(errors.EREPOSITORY, fmt.Errorf("failed to search products: %v", err))
+		return nil, storageError("failed to search products", err)
 	}
 	defer cursor.Close(ctx)
 
@@ -266,7 +762,7 @@ func (r *ProductRepository) Search(ctx context.Context, name string, minPrice, m
 		logger.Error().
 			Err(err).
 			Msg("failed to decode search results")
-		return nil, errors.StandardError(errors.EREPOSITORY, fmt.Errorf("failed to decode search results: %v", err))
+		return nil, storageError("failed to decode search results", err)
 	}
 
 	logger.Info().
@@ -274,3 +770,13 @@ func (r *ProductRepository) Search(ctx context.Context, name string, minPrice, m
 		Msg("products found successfully")
 	return products, nil
 }
+
+// storageError wraps a driver failure, telling timeouts apart from an
+// unavailable database so callers can decide whether to retry.
+func storageError(msg string, err error) *errors.AppError {
+	code := errors.EREPOSITORY
+	if mongo.IsTimeout(err) || stderrors.Is(err, context.DeadlineExceeded) {
+		code = errors.ETIMEOUT
+	}
+	return errors.StandardError(code, fmt.Errorf("%s: %v", msg, err))
+}
@@ -0,0 +1,43 @@
+package mongodb
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/domain/product/producttest"
+)
+
+// contractCollection keeps the contract tests away from real products.
+const contractCollection = "products_contract"
+
+// TestProductRepositoryContract runs against the deployment named by
+// MONGODB_TEST_URI, in the database the URI names, and is skipped when that
+// isn't set. It empties its own collection before every case.
+func TestProductRepositoryContract(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI not set")
+	}
+
+	ctx := context.Background()
+	db, err := InitMongoDB(MongoConfig{URI: uri})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer db.Client().Disconnect(ctx)
+
+	if _, err := NewSchemaManager(db).Ensure(ctx, ProductSchema(contractCollection)); err != nil {
+		t.Fatalf("Ensure: %v", err)
+	}
+
+	producttest.RunRepositoryContract(t, func(t *testing.T) product.Repository {
+		if _, err := db.Collection(contractCollection).DeleteMany(ctx, bson.M{}); err != nil {
+			t.Fatalf("empty collection: %v", err)
+		}
+		return NewProductRepository(db, contractCollection)
+	})
+}
@@ -0,0 +1,139 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"go-microservice-product-porto/internal/domain/reservation"
+	"go-microservice-product-porto/pkg/errors"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+const reservationsCollection = "reservations"
+
+type ReservationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewReservationRepository(db *mongo.Database) *ReservationRepository {
+	collection := db.Collection(reservationsCollection)
+	return &ReservationRepository{
+		collection: collection,
+	}
+}
+
+func (r *ReservationRepository) Create(ctx context.Context, res *reservation.Reservation) error {
+	logger.Debug().
+		Str("product_id", res.ProductID).
+		Int("quantity", res.Quantity).
+		Msg("attempting to create reservation")
+
+	if res.ID.IsZero() {
+		res.ID = primitive.NewObjectID()
+	}
+
+	if _, err := r.collection.InsertOne(ctx, res); err != nil {
+		logger.Error().
+			Str("product_id", res.ProductID).
+			Err(err).
+			Msg("failed to create reservation")
+		return storageError("failed to create reservation", err)
+	}
+
+	logger.Info().
+		Str("reservation_id", res.ID.Hex()).
+		Msg("reservation created successfully")
+	return nil
+}
+
+func (r *ReservationRepository) FindByID(ctx context.Context, id string) (*reservation.Reservation, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.StandardError(errors.EINVALID, fmt.Errorf("invalid reservation ID: %v", err))
+	}
+
+	var res reservation.Reservation
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&res)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.StandardError(errors.ENOTFOUND, reservation.ErrReservationNotFound)
+	}
+	if err != nil {
+		logger.Error().
+			Str("reservation_id", id).
+			Err(err).
+			Msg("failed to find reservation")
+		return nil, storageError("failed to find reservation", err)
+	}
+
+	return &res, nil
+}
+
+func (r *ReservationRepository) Transition(ctx context.Context, id string, from, to reservation.Status) (*reservation.Reservation, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.StandardError(errors.EINVALID, fmt.Errorf("invalid reservation ID: %v", err))
+	}
+
+	filter := bson.M{"_id": objectID, "status": from}
+	update := bson.M{"$set": bson.M{"status": to, "updated_at": time.Now()}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var res reservation.Reservation
+	err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&res)
+	if err == mongo.ErrNoDocuments {
+		count, err := r.collection.CountDocuments(ctx, bson.M{"_id": objectID})
+		if err != nil {
+			return nil, storageError("failed to check reservation existence", err)
+		}
+		if count == 0 {
+			return nil, errors.StandardError(errors.ENOTFOUND, reservation.ErrReservationNotFound)
+		}
+		return nil, errors.StandardError(errors.ECONFLICT, reservation.ErrReservationNotActive)
+	}
+	if err != nil {
+		logger.Error().
+			Str("reservation_id", id).
+			Err(err).
+			Msg("failed to transition reservation")
+		return nil, storageError("failed to transition reservation", err)
+	}
+
+	logger.Info().
+		Str("reservation_id", id).
+		Str("from", string(from)).
+		Str("to", string(to)).
+		Msg("reservation transitioned successfully")
+	return &res, nil
+}
+
+func (r *ReservationRepository) FindExpired(ctx context.Context, now time.Time, limit int) ([]*reservation.Reservation, error) {
+	filter := bson.M{
+		"status":     reservation.StatusActive,
+		"expires_at": bson.M{"$lte": now},
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "expires_at", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("failed to find expired reservations")
+		return nil, storageError("failed to find expired reservations", err)
+	}
+	defer cursor.Close(ctx)
+
+	var reservations []*reservation.Reservation
+	if err := cursor.All(ctx, &reservations); err != nil {
+		return nil, storageError("failed to decode expired reservations", err)
+	}
+
+	return reservations, nil
+}
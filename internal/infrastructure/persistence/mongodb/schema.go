@@ -0,0 +1,400 @@
+package mongodb
+
+import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"go-microservice-product-porto/pkg/logger"
+)
+
+// namespaceExists is the server's code for creating a collection that is
+// already there, which happens when instances start together.
+const namespaceExists = 48
+
+// IndexSpec declares one index. Name is what ties it to the index on the
+// server, so it follows the server's own naming to match indexes that were
+// created by hand.
+type IndexSpec struct {
+	Name   string
+	Keys   bson.D
+	Unique bool
+	// Weights ranks the fields of a text index; fields left out weigh 1.
+	Weights bson.D
+}
+
+func (spec IndexSpec) model() mongo.IndexModel {
+	opts := options.Index().SetName(spec.Name)
+	if spec.Unique {
+		opts.SetUnique(true)
+	}
+	if len(spec.Weights) > 0 {
+		opts.SetWeights(spec.Weights)
+	}
+	return mongo.IndexModel{Keys: spec.Keys, Options: opts}
+}
+
+// CollectionSchema is what a collection is expected to look like.
+type CollectionSchema struct {
+	Collection string
+	Indexes    []IndexSpec
+	// Validator is applied at the moderate level, so documents that were
+	// already invalid can still be updated.
+	Validator bson.D
+}
+
+// ProductSchema declares the products collection: an index for every field
+// FindAll sorts on or Search filters by, and a validator matching the rules
+// products are created under. Products have no SKU or tenant, so the name
+// alone is what must be unique. There is no text index: Search matches any
+// part of a name, as every backend does, which only the name index can help
+// with. Databases that still have the text index report it as drift.
+func ProductSchema(collectionName string) CollectionSchema {
+	if collectionName == "" {
+		collectionName = defaultProductsCollection
+	}
+
+	count := bson.A{"int", "long"}
+	return CollectionSchema{
+		Collection: collectionName,
+		Indexes: []IndexSpec{
+			{Name: "name_1", Keys: bson.D{{Key: "name", Value: 1}}, Unique: true},
+			// Keyset pages sort on the field and then _id
+			{Name: "name_1__id_1", Keys: bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}},
+			{Name: "price.amount_1__id_1", Keys: bson.D{{Key: "price.amount", Value: 1}, {Key: "_id", Value: 1}}},
+			{Name: "stock_1__id_1", Keys: bson.D{{Key: "stock", Value: 1}, {Key: "_id", Value: 1}}},
+			{Name: "created_at_1__id_1", Keys: bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+		},
+		Validator: bson.D{{Key: "$jsonSchema", Value: bson.D{
+			{Key: "bsonType", Value: "object"},
+			{Key: "required", Value: bson.A{"name", "price", "stock", "created_at", "updated_at"}},
+			{Key: "properties", Value: bson.D{
+				{Key: "name", Value: bson.D{{Key: "bsonType", Value: "string"}, {Key: "minLength", Value: 1}}},
+				{Key: "description", Value: bson.D{{Key: "bsonType", Value: "string"}}},
+				{Key: "price", Value: bson.D{
+					{Key: "bsonType", Value: "object"},
+					{Key: "required", Value: bson.A{"amount"}},
+					{Key: "properties", Value: bson.D{
+						{Key: "amount", Value: bson.D{
+							{Key: "bsonType", Value: bson.A{"double", "int", "long", "decimal"}},
+							{Key: "exclusiveMinimum", Value: true},
+							{Key: "minimum", Value: 0},
+						}},
+						{Key: "currency", Value: bson.D{{Key: "bsonType", Value: "string"}}},
+					}},
+				}},
+				{Key: "stock", Value: bson.D{{Key: "bsonType", Value: count}, {Key: "minimum", Value: 0}}},
+				{Key: "reserved", Value: bson.D{{Key: "bsonType", Value: count}, {Key: "minimum", Value: 0}}},
+				{Key: "stock_levels", Value: bson.D{
+					{Key: "bsonType", Value: "object"},
+					{Key: "additionalProperties", Value: bson.D{{Key: "bsonType", Value: count}, {Key: "minimum", Value: 0}}},
+				}},
+				{Key: "reserved_levels", Value: bson.D{
+					{Key: "bsonType", Value: "object"},
+					{Key: "additionalProperties", Value: bson.D{{Key: "bsonType", Value: count}, {Key: "minimum", Value: 0}}},
+				}},
+				{Key: "version", Value: bson.D{{Key: "bsonType", Value: count}, {Key: "minimum", Value: 0}}},
+				{Key: "created_at", Value: bson.D{{Key: "bsonType", Value: "date"}}},
+				{Key: "updated_at", Value: bson.D{{Key: "bsonType", Value: "date"}}},
+			}},
+		}}},
+	}
+}
+
+// ReservationSchema declares the index the reservation sweeper claims
+// expired reservations through.
+func ReservationSchema() CollectionSchema {
+	return CollectionSchema{
+		Collection: reservationsCollection,
+		Indexes: []IndexSpec{
+			{Name: "status_1_expires_at_1", Keys: bson.D{{Key: "status", Value: 1}, {Key: "expires_at", Value: 1}}},
+		},
+	}
+}
+
+// OutboxSchema declares the index the relay claims pending messages
+// through: equality on status, then the claim order, then the retry time.
+func OutboxSchema() CollectionSchema {
+	return CollectionSchema{
+		Collection: outboxCollection,
+		Indexes: []IndexSpec{
+			{
+				Name: "status_1_occurred_at_1_next_attempt_at_1",
+				Keys: bson.D{{Key: "status", Value: 1}, {Key: "occurred_at", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+			},
+		},
+	}
+}
+
+// WebhookDeliverySchema declares the webhook_deliveries indexes. The unique
+// one is what makes queuing the same event twice for a subscription a no-op.
+func WebhookDeliverySchema() CollectionSchema {
+	return CollectionSchema{
+		Collection: webhookDeliveriesCollection,
+		Indexes: []IndexSpec{
+			{Name: "subscription_id_1_event_id_1", Keys: bson.D{{Key: "subscription_id", Value: 1}, {Key: "event_id", Value: 1}}, Unique: true},
+			{Name: "status_1_next_attempt_at_1", Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+			{
+				Name: "subscription_id_1_created_at_-1__id_-1",
+				Keys: bson.D{{Key: "subscription_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+			},
+		},
+	}
+}
+
+// SchemaReport describes what Ensure found and did.
+type SchemaReport struct {
+	Collection string
+	// Created lists the indexes built by this run.
+	Created []string
+	// ValidatorApplied is set when the validator was missing or differed.
+	ValidatorApplied bool
+	// Drift describes differences Ensure left alone because fixing them could
+	// lose data or block writes: indexes declared with other options, indexes
+	// that could not be built and indexes nobody declared.
+	Drift []string
+}
+
+// SchemaManager brings collections in line with their declared schema.
+type SchemaManager struct {
+	db *mongo.Database
+}
+
+func NewSchemaManager(db *mongo.Database) *SchemaManager {
+	return &SchemaManager{db: db}
+}
+
+// Ensure creates the collection with its validator if it is missing, builds
+// missing indexes and replaces a validator that differs. It never drops or
+// rebuilds an index, so running it again on every start is safe; whatever it
+// will not fix is reported as drift.
+func (m *SchemaManager) Ensure(ctx context.Context, schema CollectionSchema) (*SchemaReport, error) {
+	report := &SchemaReport{Collection: schema.Collection}
+
+	applied, err := m.ensureValidator(ctx, schema)
+	if err != nil {
+		return nil, err
+	}
+	report.ValidatorApplied = applied
+
+	indexes := m.db.Collection(schema.Collection).Indexes()
+	cursor, err := indexes.List(ctx)
+	if err != nil {
+		return nil, storageError("failed to list indexes", err)
+	}
+	var existing []bson.Raw
+	if err := cursor.All(ctx, &existing); err != nil {
+		return nil, storageError("failed to list indexes", err)
+	}
+
+	byName := make(map[string]bson.Raw, len(existing))
+	for _, index := range existing {
+		name, _ := index.Lookup("name").StringValueOK()
+		byName[name] = index
+	}
+
+	declared := make(map[string]bool, len(schema.Indexes))
+	for _, spec := range schema.Indexes {
+		declared[spec.Name] = true
+
+		if index, ok := byName[spec.Name]; ok {
+			report.Drift = append(report.Drift, indexDrift(spec, index)...)
+			continue
+		}
+
+		if _, err := indexes.CreateOne(ctx, spec.model()); err != nil {
+			logger.Error().
+				Str("collection", schema.Collection).
+				Str("index", spec.Name).
+				Err(err).
+				Msg("failed to build index")
+			report.Drift = append(report.Drift, fmt.Sprintf("index %s could not be built: %v", spec.Name, err))
+			continue
+		}
+		logger.Info().
+			Str("collection", schema.Collection).
+			Str("index", spec.Name).
+			Msg("index built")
+		report.Created = append(report.Created, spec.Name)
+	}
+
+	for _, index := range existing {
+		name, _ := index.Lookup("name").StringValueOK()
+		if name != "_id_" && !declared[name] {
+			report.Drift = append(report.Drift, fmt.Sprintf("index %s is not declared", name))
+		}
+	}
+
+	for _, drift := range report.Drift {
+		logger.Warn().
+			Str("collection", schema.Collection).
+			Msg(drift)
+	}
+	return report, nil
+}
+
+// ensureValidator reports whether the validator had to be applied.
+func (m *SchemaManager) ensureValidator(ctx context.Context, schema CollectionSchema) (bool, error) {
+	// Building the first index creates a collection without a validator
+	if len(schema.Validator) == 0 {
+		return false, nil
+	}
+
+	specs, err := m.db.ListCollectionSpecifications(ctx, bson.M{"name": schema.Collection})
+	if err != nil {
+		return false, storageError("failed to list collections", err)
+	}
+
+	if len(specs) == 0 {
+		opts := options.CreateCollection().
+			SetValidator(schema.Validator).
+			SetValidationLevel("moderate").
+			SetValidationAction("error")
+		err := m.db.CreateCollection(ctx, schema.Collection, opts)
+		if err == nil {
+			logger.Info().
+				Str("collection", schema.Collection).
+				Msg("collection created")
+			return true, nil
+		}
+		var cmdErr mongo.CommandError
+		if !stderrors.As(err, &cmdErr) || cmdErr.Code != namespaceExists {
+			return false, storageError("failed to create collection", err)
+		}
+	} else if !validatorDrifted(schema.Validator, specs[0].Options) {
+		return false, nil
+	}
+
+	err = m.db.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: schema.Collection},
+		{Key: "validator", Value: schema.Validator},
+		{Key: "validationLevel", Value: "moderate"},
+		{Key: "validationAction", Value: "error"},
+	}).Err()
+	if err != nil {
+		return false, storageError("failed to apply validator", err)
+	}
+
+	logger.Info().
+		Str("collection", schema.Collection).
+		Msg("validator applied")
+	return true, nil
+}
+
+// validatorDrifted compares the declared validator with a collection's
+// options. The server hands the validator back exactly as it was stored, so
+// an unchanged one matches byte for byte.
+func validatorDrifted(validator bson.D, collectionOptions bson.Raw) bool {
+	want, err := bson.Marshal(validator)
+	if err != nil {
+		return true
+	}
+	got, ok := collectionOptions.Lookup("validator").DocumentOK()
+	if !ok || !bytes.Equal(got, want) {
+		return true
+	}
+	level, _ := collectionOptions.Lookup("validationLevel").StringValueOK()
+	action, _ := collectionOptions.Lookup("validationAction").StringValueOK()
+	return level != "moderate" || action != "error"
+}
+
+// indexDrift describes how an index on the server differs from its spec.
+func indexDrift(spec IndexSpec, index bson.Raw) []string {
+	var drift []string
+
+	unique, _ := index.Lookup("unique").BooleanOK()
+	if unique != spec.Unique {
+		drift = append(drift, fmt.Sprintf("index %s has unique=%t, want %t", spec.Name, unique, spec.Unique))
+	}
+
+	if isTextIndex(spec.Keys) {
+		want := map[string]float64{}
+		for _, key := range spec.Keys {
+			if key.Value == "text" {
+				want[key.Key] = 1
+			}
+		}
+		for _, weight := range spec.Weights {
+			want[weight.Key] = number(weight.Value)
+		}
+
+		got := map[string]float64{}
+		weights, _ := index.Lookup("weights").DocumentOK()
+		elements, _ := weights.Elements()
+		for _, element := range elements {
+			got[element.Key()] = rawNumber(element.Value())
+		}
+
+		if !sameWeights(got, want) {
+			drift = append(drift, fmt.Sprintf("index %s has weights %v, want %v", spec.Name, got, want))
+		}
+		return drift
+	}
+
+	keys, _ := index.Lookup("key").DocumentOK()
+	elements, _ := keys.Elements()
+	same := len(elements) == len(spec.Keys)
+	for i := 0; same && i < len(elements); i++ {
+		same = elements[i].Key() == spec.Keys[i].Key && rawNumber(elements[i].Value()) == number(spec.Keys[i].Value)
+	}
+	if !same {
+		drift = append(drift, fmt.Sprintf("index %s has keys %s, want %v", spec.Name, keys, spec.Keys))
+	}
+	return drift
+}
+
+func isTextIndex(keys bson.D) bool {
+	for _, key := range keys {
+		if key.Value == "text" {
+			return true
+		}
+	}
+	return false
+}
+
+func sameWeights(a, b map[string]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for field, weight := range a {
+		if other, ok := b[field]; !ok || other != weight {
+			return false
+		}
+	}
+	return true
+}
+
+// number reads a declared key direction or weight. Key directions come back
+// from the server as int32, int64 or double depending on the client that
+// created the index, so they are compared as floats.
+func number(value interface{}) float64 {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
+
+func rawNumber(value bson.RawValue) float64 {
+	switch value.Type {
+	case bsontype.Int32:
+		return float64(value.Int32())
+	case bsontype.Int64:
+		return float64(value.Int64())
+	case bsontype.Double:
+		return value.Double()
+	}
+	return 0
+}
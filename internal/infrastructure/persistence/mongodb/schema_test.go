@@ -0,0 +1,109 @@
+package mongodb
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func marshal(t *testing.T, doc interface{}) bson.Raw {
+	t.Helper()
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return raw
+}
+
+func TestIndexDrift(t *testing.T) {
+	unique := IndexSpec{Name: "name_1", Keys: bson.D{{Key: "name", Value: 1}}, Unique: true}
+	text := IndexSpec{
+		Name:    "name_text_description_text",
+		Keys:    bson.D{{Key: "name", Value: "text"}, {Key: "description", Value: "text"}},
+		Weights: bson.D{{Key: "name", Value: 10}},
+	}
+
+	tests := []struct {
+		name  string
+		spec  IndexSpec
+		index bson.D
+		drift int
+	}{
+		{
+			name:  "matching",
+			spec:  unique,
+			index: bson.D{{Key: "name", Value: "name_1"}, {Key: "key", Value: bson.D{{Key: "name", Value: int32(1)}}}, {Key: "unique", Value: true}},
+		},
+		{
+			name:  "created from the shell",
+			spec:  IndexSpec{Name: "price_1", Keys: bson.D{{Key: "price", Value: 1}}},
+			index: bson.D{{Key: "name", Value: "price_1"}, {Key: "key", Value: bson.D{{Key: "price", Value: 1.0}}}},
+		},
+		{
+			name:  "not unique",
+			spec:  unique,
+			index: bson.D{{Key: "name", Value: "name_1"}, {Key: "key", Value: bson.D{{Key: "name", Value: int32(1)}}}},
+			drift: 1,
+		},
+		{
+			name:  "other direction",
+			spec:  unique,
+			index: bson.D{{Key: "name", Value: "name_1"}, {Key: "key", Value: bson.D{{Key: "name", Value: int32(-1)}}}, {Key: "unique", Value: true}},
+			drift: 1,
+		},
+		{
+			name: "text weights",
+			spec: text,
+			index: bson.D{
+				{Key: "name", Value: "name_text_description_text"},
+				{Key: "key", Value: bson.D{{Key: "_fts", Value: "text"}, {Key: "_ftsx", Value: int32(1)}}},
+				{Key: "weights", Value: bson.D{{Key: "description", Value: int32(1)}, {Key: "name", Value: int32(10)}}},
+			},
+		},
+		{
+			name: "text field missing",
+			spec: text,
+			index: bson.D{
+				{Key: "name", Value: "name_text_description_text"},
+				{Key: "key", Value: bson.D{{Key: "_fts", Value: "text"}, {Key: "_ftsx", Value: int32(1)}}},
+				{Key: "weights", Value: bson.D{{Key: "name", Value: int32(10)}}},
+			},
+			drift: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			drift := indexDrift(tt.spec, marshal(t, tt.index))
+			if len(drift) != tt.drift {
+				t.Errorf("indexDrift() = %v, want %d differences", drift, tt.drift)
+			}
+		})
+	}
+}
+
+func TestValidatorDrifted(t *testing.T) {
+	validator := ProductSchema("").Validator
+
+	current := marshal(t, bson.D{
+		{Key: "validator", Value: validator},
+		{Key: "validationLevel", Value: "moderate"},
+		{Key: "validationAction", Value: "error"},
+	})
+	if validatorDrifted(validator, current) {
+		t.Error("validatorDrifted() = true for the declared validator")
+	}
+
+	warnOnly := marshal(t, bson.D{
+		{Key: "validator", Value: validator},
+		{Key: "validationLevel", Value: "moderate"},
+		{Key: "validationAction", Value: "warn"},
+	})
+	if !validatorDrifted(validator, warnOnly) {
+		t.Error("validatorDrifted() = false for a warn-only validator")
+	}
+
+	if !validatorDrifted(validator, marshal(t, bson.D{})) {
+		t.Error("validatorDrifted() = false for a collection without a validator")
+	}
+}
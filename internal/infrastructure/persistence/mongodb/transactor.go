@@ -0,0 +1,68 @@
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"go-microservice-product-porto/pkg/logger"
+)
+
+// Transactor runs work inside a MongoDB multi-document transaction.
+// Transactions need a replica set or sharded cluster; against a standalone
+// server the work runs without one, which is only acceptable in development.
+type Transactor struct {
+	client    *mongo.Client
+	supported bool
+}
+
+func NewTransactor(ctx context.Context, client *mongo.Client) *Transactor {
+	supported := supportsTransactions(ctx, client)
+	if !supported {
+		logger.Warn().
+			Msg("MongoDB deployment does not support transactions; outbox writes will not be atomic")
+	}
+
+	return &Transactor{
+		client:    client,
+		supported: supported,
+	}
+}
+
+func (t *Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	// Join a transaction that is already running instead of nesting
+	if !t.supported || mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := t.client.StartSession()
+	if err != nil {
+		return storageError("failed to start session", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+	return err
+}
+
+// supportsTransactions reports whether the server is a replica set member or
+// a mongos router.
+func supportsTransactions(ctx context.Context, client *mongo.Client) bool {
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+
+	err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		logger.Warn().
+			Err(err).
+			Msg("failed to detect MongoDB topology")
+		return false
+	}
+
+	return hello.SetName != "" || hello.Msg == "isdbgrid"
+}
@@ -0,0 +1,252 @@
+package mongodb
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"go-microservice-product-porto/internal/domain/webhook"
+	"go-microservice-product-porto/pkg/errors"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+const webhookDeliveriesCollection = "webhook_deliveries"
+
+type WebhookDeliveryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWebhookDeliveryRepository(db *mongo.Database) *WebhookDeliveryRepository {
+	collection := db.Collection(webhookDeliveriesCollection)
+	return &WebhookDeliveryRepository{
+		collection: collection,
+	}
+}
+
+func (r *WebhookDeliveryRepository) Enqueue(ctx context.Context, deliveries ...*webhook.Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	// Upsert on (subscription, event) so a redelivered event is a no-op
+	models := make([]mongo.WriteModel, len(deliveries))
+	for i, delivery := range deliveries {
+		if delivery.ID.IsZero() {
+			delivery.ID = primitive.NewObjectID()
+		}
+		models[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"subscription_id": delivery.SubscriptionID, "event_id": delivery.EventID}).
+			SetUpdate(bson.M{"$setOnInsert": delivery}).
+			SetUpsert(true)
+	}
+
+	_, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil && !onlyDuplicateKeys(err) {
+		logger.Error().
+			Int("count", len(deliveries)).
+			Err(err).
+			Msg("failed to enqueue webhook deliveries")
+		return storageError("failed to enqueue webhook deliveries", err)
+	}
+
+	return nil
+}
+
+func (r *WebhookDeliveryRepository) FindByID(ctx context.Context, id string) (*webhook.Delivery, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.StandardError(errors.EINVALID, fmt.Errorf("invalid webhook delivery ID: %v", err))
+	}
+
+	var delivery webhook.Delivery
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&delivery)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.StandardError(errors.ENOTFOUND, webhook.ErrDeliveryNotFound)
+	}
+	if err != nil {
+		logger.Error().
+			Str("delivery_id", id).
+			Err(err).
+			Msg("failed to find webhook delivery")
+		return nil, storageError("failed to find webhook delivery", err)
+	}
+
+	return &delivery, nil
+}
+
+func (r *WebhookDeliveryRepository) FindBySubscription(ctx context.Context, filter webhook.DeliveryFilter) ([]*webhook.Delivery, int64, error) {
+	query := bson.M{"subscription_id": filter.SubscriptionID}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((filter.Page - 1) * filter.PageSize)).
+		SetLimit(int64(filter.PageSize))
+
+	cursor, err := r.collection.Find(ctx, query, findOptions)
+	if err != nil {
+		logger.Error().
+			Str("subscription_id", filter.SubscriptionID).
+			Err(err).
+			Msg("failed to find webhook deliveries")
+		return nil, 0, storageError("failed to find webhook deliveries", err)
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []*webhook.Delivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, 0, storageError("failed to decode webhook deliveries", err)
+	}
+
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, storageError("failed to count webhook deliveries", err)
+	}
+
+	return deliveries, total, nil
+}
+
+func (r *WebhookDeliveryRepository) Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*webhook.Delivery, error) {
+	filter := bson.M{
+		"status":          webhook.DeliveryPending,
+		"next_attempt_at": bson.M{"$lte": now},
+		"$or": bson.A{
+			bson.M{"locked_until": bson.M{"$exists": false}},
+			bson.M{"locked_until": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"locked_until": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	// Claim one at a time so each lease is taken atomically
+	var deliveries []*webhook.Delivery
+	for len(deliveries) < limit {
+		var delivery webhook.Delivery
+		err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&delivery)
+		if err == mongo.ErrNoDocuments {
+			break
+		}
+		if err != nil {
+			logger.Error().
+				Err(err).
+				Msg("failed to claim webhook delivery")
+			return deliveries, storageError("failed to claim webhook delivery", err)
+		}
+		deliveries = append(deliveries, &delivery)
+	}
+
+	return deliveries, nil
+}
+
+func (r *WebhookDeliveryRepository) MarkDelivered(ctx context.Context, id string, responseStatus int) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.StandardError(errors.EINVALID, fmt.Errorf("invalid webhook delivery ID: %v", err))
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":          webhook.DeliveryDelivered,
+			"response_status": responseStatus,
+			"delivered_at":    time.Now(),
+		},
+		"$inc":   bson.M{"attempts": 1},
+		"$unset": bson.M{"locked_until": "", "last_error": ""},
+	}
+	if _, err := r.collection.UpdateByID(ctx, objectID, update); err != nil {
+		return storageError("failed to mark webhook delivery delivered", err)
+	}
+	return nil
+}
+
+func (r *WebhookDeliveryRepository) MarkFailed(ctx context.Context, id string, responseStatus int, lastError string, nextAttemptAt time.Time, dead bool) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.StandardError(errors.EINVALID, fmt.Errorf("invalid webhook delivery ID: %v", err))
+	}
+
+	status := webhook.DeliveryPending
+	if dead {
+		status = webhook.DeliveryDead
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":          status,
+			"response_status": responseStatus,
+			"last_error":      lastError,
+			"next_attempt_at": nextAttemptAt,
+		},
+		"$inc":   bson.M{"attempts": 1},
+		"$unset": bson.M{"locked_until": ""},
+	}
+	if _, err := r.collection.UpdateByID(ctx, objectID, update); err != nil {
+		return storageError("failed to mark webhook delivery failed", err)
+	}
+	return nil
+}
+
+func (r *WebhookDeliveryRepository) Requeue(ctx context.Context, id string) (*webhook.Delivery, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.StandardError(errors.EINVALID, fmt.Errorf("invalid webhook delivery ID: %v", err))
+	}
+
+	filter := bson.M{"_id": objectID, "status": webhook.DeliveryDead}
+	update := bson.M{
+		"$set":   bson.M{"status": webhook.DeliveryPending, "attempts": 0, "next_attempt_at": time.Now()},
+		"$unset": bson.M{"locked_until": ""},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var delivery webhook.Delivery
+	err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&delivery)
+	if err == mongo.ErrNoDocuments {
+		count, err := r.collection.CountDocuments(ctx, bson.M{"_id": objectID})
+		if err != nil {
+			return nil, storageError("failed to check webhook delivery existence", err)
+		}
+		if count == 0 {
+			return nil, errors.StandardError(errors.ENOTFOUND, webhook.ErrDeliveryNotFound)
+		}
+		return nil, errors.StandardError(errors.ECONFLICT, webhook.ErrDeliveryNotDead)
+	}
+	if err != nil {
+		logger.Error().
+			Str("delivery_id", id).
+			Err(err).
+			Msg("failed to requeue webhook delivery")
+		return nil, storageError("failed to requeue webhook delivery", err)
+	}
+
+	logger.Info().
+		Str("delivery_id", id).
+		Msg("webhook delivery requeued")
+	return &delivery, nil
+}
+
+// onlyDuplicateKeys reports whether every write of a bulk write failed on a
+// unique index. Two instances upserting the same delivery at once race on
+// (subscription_id, event_id); the loser's delivery already exists.
+func onlyDuplicateKeys(err error) bool {
+	var bulkErr mongo.BulkWriteException
+	if !stderrors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil || len(bulkErr.WriteErrors) == 0 {
+		return false
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if !mongo.IsDuplicateKeyError(writeErr) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,146 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"go-microservice-product-porto/internal/domain/webhook"
+	"go-microservice-product-porto/pkg/errors"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+type WebhookRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWebhookRepository(db *mongo.Database) *WebhookRepository {
+	collection := db.Collection("webhook_subscriptions")
+	return &WebhookRepository{
+		collection: collection,
+	}
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, sub *webhook.Subscription) error {
+	logger.Debug().
+		Str("url", sub.URL).
+		Msg("attempting to create webhook subscription")
+
+	if sub.ID.IsZero() {
+		sub.ID = primitive.NewObjectID()
+	}
+
+	if _, err := r.collection.InsertOne(ctx, sub); err != nil {
+		logger.Error().
+			Str("url", sub.URL).
+			Err(err).
+			Msg("failed to create webhook subscription")
+		return storageError("failed to create webhook subscription", err)
+	}
+
+	logger.Info().
+		Str("subscription_id", sub.ID.Hex()).
+		Msg("webhook subscription created successfully")
+	return nil
+}
+
+func (r *WebhookRepository) FindByID(ctx context.Context, id string) (*webhook.Subscription, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.StandardError(errors.EINVALID, fmt.Errorf("invalid webhook subscription ID: %v", err))
+	}
+
+	var sub webhook.Subscription
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&sub)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.StandardError(errors.ENOTFOUND, webhook.ErrSubscriptionNotFound)
+	}
+	if err != nil {
+		logger.Error().
+			Str("subscription_id", id).
+			Err(err).
+			Msg("failed to find webhook subscription")
+		return nil, storageError("failed to find webhook subscription", err)
+	}
+
+	return &sub, nil
+}
+
+func (r *WebhookRepository) FindAll(ctx context.Context) ([]*webhook.Subscription, error) {
+	return r.find(ctx, bson.M{})
+}
+
+func (r *WebhookRepository) FindActiveByEventType(ctx context.Context, eventType string) ([]*webhook.Subscription, error) {
+	return r.find(ctx, bson.M{
+		"active": true,
+		"$or": bson.A{
+			bson.M{"event_types": eventType},
+			bson.M{"event_types": bson.M{"$size": 0}},
+		},
+	})
+}
+
+func (r *WebhookRepository) find(ctx context.Context, filter bson.M) ([]*webhook.Subscription, error) {
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("failed to find webhook subscriptions")
+		return nil, storageError("failed to find webhook subscriptions", err)
+	}
+	defer cursor.Close(ctx)
+
+	subscriptions := []*webhook.Subscription{}
+	if err := cursor.All(ctx, &subscriptions); err != nil {
+		return nil, storageError("failed to decode webhook subscriptions", err)
+	}
+
+	return subscriptions, nil
+}
+
+func (r *WebhookRepository) Update(ctx context.Context, sub *webhook.Subscription) error {
+	result, err := r.collection.ReplaceOne(ctx, bson.M{"_id": sub.ID}, sub)
+	if err != nil {
+		logger.Error().
+			Str("subscription_id", sub.ID.Hex()).
+			Err(err).
+			Msg("failed to update webhook subscription")
+		return storageError("failed to update webhook subscription", err)
+	}
+	if result.MatchedCount == 0 {
+		return errors.StandardError(errors.ENOTFOUND, webhook.ErrSubscriptionNotFound)
+	}
+
+	logger.Info().
+		Str("subscription_id", sub.ID.Hex()).
+		Msg("webhook subscription updated successfully")
+	return nil
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.StandardError(errors.EINVALID, fmt.Errorf("invalid webhook subscription ID: %v", err))
+	}
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		logger.Error().
+			Str("subscription_id", id).
+			Err(err).
+			Msg("failed to delete webhook subscription")
+		return storageError("failed to delete webhook subscription", err)
+	}
+	if result.DeletedCount == 0 {
+		return errors.StandardError(errors.ENOTFOUND, webhook.ErrSubscriptionNotFound)
+	}
+
+	logger.Info().
+		Str("subscription_id", id).
+		Msg("webhook subscription deleted successfully")
+	return nil
+}
@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"go-microservice-product-porto/pkg/config"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+type PostgresConfig struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	DBName   string
+	SSLMode  string
+	TimeZone string
+}
+
+// ConfigFromApp takes the PostgreSQL settings from the application config.
+func ConfigFromApp(cfg *config.Config) PostgresConfig {
+	return PostgresConfig{
+		Host:     cfg.DBHost,
+		Port:     cfg.DBPort,
+		User:     cfg.DBUser,
+		Password: cfg.DBPassword,
+		DBName:   cfg.DBName,
+		SSLMode:  cfg.DBSSLMode,
+		TimeZone: cfg.DBTimeZone,
+	}
+}
+
+// URL builds the connection string for cfg.
+func (cfg PostgresConfig) URL() string {
+	query := url.Values{}
+	if cfg.SSLMode != "" {
+		query.Set("sslmode", cfg.SSLMode)
+	}
+	if cfg.TimeZone != "" {
+		query.Set("timezone", cfg.TimeZone)
+	}
+
+	u := url.URL{
+		Scheme:   "postgres",
+		Host:     cfg.Host + ":" + cfg.Port,
+		Path:     "/" + cfg.DBName,
+		RawQuery: query.Encode(),
+	}
+	if cfg.User != "" {
+		u.User = url.UserPassword(cfg.User, cfg.Password)
+	}
+	return u.String()
+}
+
+func InitPostgres(cfg PostgresConfig) (*pgxpool.Pool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, cfg.URL())
+	if err != nil {
+		return nil, errors.StandardError(errors.EREPOSITORY, fmt.Errorf("failed to connect to PostgreSQL: %v", err))
+	}
+
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, errors.StandardError(errors.ETIMEOUT, fmt.Errorf("failed to ping PostgreSQL: %v", err))
+	}
+
+	return pool, nil
+}
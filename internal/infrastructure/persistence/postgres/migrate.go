@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"go-microservice-product-porto/internal/infrastructure/persistence/migrate"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID keys the advisory lock that keeps instances starting
+// together from applying the same migration twice.
+const migrationLockID = 7_301_944_028
+
+// migrationStore records migrations in schema_migrations and runs each one in
+// its own transaction, so a failed migration leaves nothing behind and the
+// next run picks up where this one stopped.
+type migrationStore struct {
+	pool *pgxpool.Pool
+}
+
+func (s *migrationStore) Lock(ctx context.Context) (func(), error) {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return nil, storageError("failed to acquire connection", err)
+	}
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		conn.Release()
+		return nil, storageError("failed to lock migrations", err)
+	}
+
+	return func() {
+		conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID)
+		conn.Release()
+	}, nil
+}
+
+func (s *migrationStore) Applied(ctx context.Context) ([]migrate.Record, error) {
+	_, err := s.pool.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return nil, storageError("failed to create schema_migrations", err)
+	}
+
+	rows, err := s.pool.Query(ctx, "SELECT version, name, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, storageError("failed to read schema_migrations", err)
+	}
+	records, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (migrate.Record, error) {
+		var record migrate.Record
+		err := row.Scan(&record.Version, &record.Name, &record.AppliedAt)
+		return record, err
+	})
+	if err != nil {
+		return nil, storageError("failed to read schema_migrations", err)
+	}
+	return records, nil
+}
+
+func (s *migrationStore) Run(ctx context.Context, m migrate.Record, up bool, fn func(ctx context.Context, tx pgx.Tx) error) error {
+	err := pgx.BeginFunc(ctx, s.pool, func(tx pgx.Tx) error {
+		if err := fn(ctx, tx); err != nil {
+			return err
+		}
+		if !up {
+			_, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", m.Version)
+			return err
+		}
+		_, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", m.Version, m.Name)
+		return err
+	})
+	if err != nil {
+		return storageError(fmt.Sprintf("failed to run migration %s", m.Name), err)
+	}
+	return nil
+}
+
+// NewMigrator returns the migrator for the embedded schema migrations.
+func NewMigrator(pool *pgxpool.Pool) (*migrate.Migrator[pgx.Tx], error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	return migrate.New[pgx.Tx](&migrationStore{pool: pool}, migrations)
+}
+
+// Migrate brings the schema up to date.
+func Migrate(ctx context.Context, pool *pgxpool.Pool) error {
+	migrator, err := NewMigrator(pool)
+	if err != nil {
+		return err
+	}
+	_, err = migrator.Up(ctx, migrator.Latest())
+	return err
+}
+
+// loadMigrations reads the embedded migrations. Files are named
+// <version>_<name>.up.sql, with an optional <version>_<name>.down.sql that
+// reverts them. Migrations are named after their files, version included,
+// as schema_migrations has always recorded them.
+func loadMigrations() ([]migrate.Migration[pgx.Tx], error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := map[int]*migrate.Migration[pgx.Tx]{}
+	var migrations []*migrate.Migration[pgx.Tx]
+	for _, entry := range entries {
+		base, up := strings.CutSuffix(entry.Name(), ".up.sql")
+		if !up {
+			var down bool
+			if base, down = strings.CutSuffix(entry.Name(), ".down.sql"); !down {
+				return nil, fmt.Errorf("migration %s is neither .up.sql nor .down.sql", entry.Name())
+			}
+		}
+
+		prefix, _, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has no version: %w", entry.Name(), err)
+		}
+
+		sql, err := migrationFiles.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migrate.Migration[pgx.Tx]{Version: version, Name: base}
+			byVersion[version] = m
+			migrations = append(migrations, m)
+		}
+		if up {
+			m.Up = execSQL(string(sql))
+		} else {
+			m.Down = execSQL(string(sql))
+		}
+	}
+
+	result := make([]migrate.Migration[pgx.Tx], len(migrations))
+	for i, m := range migrations {
+		result[i] = *m
+	}
+	return result, nil
+}
+
+func execSQL(sql string) func(ctx context.Context, tx pgx.Tx) error {
+	return func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, sql)
+		return err
+	}
+}
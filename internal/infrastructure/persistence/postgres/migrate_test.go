@@ -0,0 +1,22 @@
+package postgres
+
+import "testing"
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+
+	for _, m := range migrations {
+		if m.Up == nil || m.Down == nil {
+			t.Errorf("migration %d %s is missing its up or down step", m.Version, m.Name)
+		}
+	}
+	if first := migrations[0]; first.Version != 1 || first.Name != "0001_create_products" {
+		t.Errorf("first migration = %d %s, want 1 0001_create_products", first.Version, first.Name)
+	}
+}
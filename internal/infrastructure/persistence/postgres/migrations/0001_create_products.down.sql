@@ -0,0 +1,2 @@
+-- The pg_trgm extension stays: other schemas may rely on it.
+DROP TABLE products;
@@ -0,0 +1,26 @@
+-- Products keep the hex form of their ObjectID, so IDs look the same
+-- whichever backend stored them.
+CREATE TABLE products (
+    id           CHAR(24) COLLATE "C" PRIMARY KEY,
+    name         TEXT NOT NULL,
+    description  TEXT NOT NULL DEFAULT '',
+    price        DOUBLE PRECISION NOT NULL,
+    stock        INTEGER NOT NULL DEFAULT 0,
+    reserved     INTEGER NOT NULL DEFAULT 0,
+    -- Quantity held at each location, keyed by location ID
+    stock_levels JSONB NOT NULL DEFAULT '{}',
+    version      BIGINT NOT NULL DEFAULT 1,
+    created_at   TIMESTAMPTZ NOT NULL,
+    updated_at   TIMESTAMPTZ NOT NULL
+);
+
+-- List sorts. Names sort by code point, as they do in MongoDB.
+CREATE INDEX products_name_idx ON products (name COLLATE "C");
+CREATE INDEX products_price_idx ON products (price);
+CREATE INDEX products_stock_idx ON products (stock);
+CREATE INDEX products_created_at_idx ON products (created_at);
+
+-- Search matches names against case-insensitive regular expressions, which
+-- only a trigram index can serve.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX products_name_trgm_idx ON products USING GIN (name gin_trgm_ops);
@@ -0,0 +1,9 @@
+CREATE INDEX products_name_idx ON products (name COLLATE "C");
+CREATE INDEX products_price_idx ON products (price);
+CREATE INDEX products_stock_idx ON products (stock);
+CREATE INDEX products_created_at_idx ON products (created_at);
+
+DROP INDEX products_name_id_idx;
+DROP INDEX products_price_id_idx;
+DROP INDEX products_stock_id_idx;
+DROP INDEX products_created_at_id_idx;
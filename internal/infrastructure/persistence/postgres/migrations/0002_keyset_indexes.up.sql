@@ -0,0 +1,12 @@
+-- Keyset pages order by the sort field and then the ID, and compare rows
+-- the same way, so each sort needs an index ending in id. They also serve
+-- everything the single-column indexes did.
+CREATE INDEX products_name_id_idx ON products (name COLLATE "C", id);
+CREATE INDEX products_price_id_idx ON products (price, id);
+CREATE INDEX products_stock_id_idx ON products (stock, id);
+CREATE INDEX products_created_at_id_idx ON products (created_at, id);
+
+DROP INDEX products_name_idx;
+DROP INDEX products_price_idx;
+DROP INDEX products_stock_idx;
+DROP INDEX products_created_at_idx;
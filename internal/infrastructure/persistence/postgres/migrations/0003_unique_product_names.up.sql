@@ -0,0 +1,3 @@
+-- Names are what tells products apart, as in MongoDB. The keyset index on
+-- name stays: it orders by code point, which this one doesn't.
+CREATE UNIQUE INDEX products_name_key ON products (name);
@@ -0,0 +1,3 @@
+-- Quantity reserved at each location, keyed by location ID. Reservations
+-- not counted here are held in the unallocated stock.
+ALTER TABLE products ADD COLUMN reserved_levels JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,579 @@
+package postgres
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/infrastructure/persistence/stock"
+	"go-microservice-product-porto/pkg/errors"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+const productColumns = "id, name, description, price, stock, reserved, stock_levels, reserved_levels, version, created_at, updated_at"
+
+// sortColumns maps the fields products can be listed by to the expressions
+// their indexes are built on.
+var sortColumns = map[string]string{
+	"name":       `name COLLATE "C"`,
+	"price":      "price",
+	"stock":      "stock",
+	"created_at": "created_at",
+}
+
+// uniqueViolation is the SQLSTATE of a duplicate key.
+const uniqueViolation = "23505"
+
+// ProductRepository stores products in PostgreSQL. It answers like the
+// MongoDB repository, including its error codes, so the two can be swapped.
+type ProductRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewProductRepository(pool *pgxpool.Pool) *ProductRepository {
+	return &ProductRepository{
+		pool: pool,
+	}
+}
+
+func (r *ProductRepository) Create(ctx context.Context, prod *product.Product) error {
+	logger.Debug().
+		Str("product_name", prod.Name).
+		Float64("price", prod.Price).
+		Msg("attempting to create product")
+
+	if prod.ID.IsZero() {
+		prod.ID = primitive.NewObjectID()
+	}
+	if prod.Version == 0 {
+		prod.Version = 1
+	}
+
+	_, err := r.pool.Exec(ctx,
+		"INSERT INTO products ("+productColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)",
+		prod.ID.Hex(), prod.Name, prod.Description, prod.Price, prod.Stock, prod.Reserved,
+		levels(prod.StockLevels), levels(prod.ReservedLevels), prod.Version, prod.CreatedAt, prod.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if stderrors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			logger.Error().
+				Str("product_name", prod.Name).
+				Err(err).
+				Msg("product already exists")
+			return errors.StandardError(errors.ECONFLICT, product.ErrProductAlreadyExists)
+		}
+		logger.Error().
+			Str("product_name", prod.Name).
+			Err(err).
+			Msg("failed to create product")
+		return storageError("failed to create product", err)
+	}
+	logger.Info().
+		Str("product_name", prod.Name).
+		Msg("product created successfully")
+
+	return nil
+}
+
+func (r *ProductRepository) FindByID(ctx context.Context, id string) (*product.Product, error) {
+	logger.Debug().
+		Str("product_id", id).
+		Msg("attempting to find product by ID")
+
+	if _, err := parseID(id); err != nil {
+		return nil, err
+	}
+
+	prod, err := scanProduct(r.pool.QueryRow(ctx, "SELECT "+productColumns+" FROM products WHERE id = $1", id))
+	if stderrors.Is(err, pgx.ErrNoRows) {
+		logger.Error().
+			Str("product_id", id).
+			Msg("product not found")
+		return nil, errors.StandardError(errors.ENOTFOUND, product.ErrProductNotFound)
+	}
+	if err != nil {
+		logger.Error().
+			Str("product_id", id).
+			Err(err).
+			Msg("failed to find product")
+		return nil, storageError("failed to find product", err)
+	}
+	return prod, nil
+}
+
+func (r *ProductRepository) FindAll(ctx context.Context, page, pageSize int, sortBy, sortDir string) ([]*product.Product, int64, error) {
+	logger.Debug().
+		Int("page", page).
+		Int("page_size", pageSize).
+		Str("sort_by", sortBy).
+		Str("sort_dir", sortDir).
+		Msg("attempting to find all products")
+
+	// Same rules as MongoDB: no field means ID order, an unknown field means
+	// no particular order. The ID breaks ties so pages never overlap.
+	orderBy := " ORDER BY id"
+	if sortBy != "" {
+		orderBy = ""
+		if column, exists := sortColumns[sortBy]; exists {
+			dir := "ASC"
+			if strings.ToLower(sortDir) == "desc" {
+				dir = "DESC"
+			}
+			orderBy = fmt.Sprintf(" ORDER BY %s %s, id %s", column, dir, dir)
+		}
+	}
+
+	offset := (page - 1) * pageSize
+	if offset < 0 {
+		offset = 0
+	}
+	// A NULL limit returns every row, like a zero limit in MongoDB
+	var limit *int
+	if pageSize > 0 {
+		limit = &pageSize
+	}
+
+	rows, err := r.pool.Query(ctx, "SELECT "+productColumns+" FROM products"+orderBy+" LIMIT $1 OFFSET $2", limit, offset)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("failed to find products")
+		return nil, 0, storageError("failed to find products", err)
+	}
+	products, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*product.Product, error) {
+		return scanProduct(row)
+	})
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("failed to decode products")
+		return nil, 0, storageError("failed to decode products", err)
+	}
+
+	var total int64
+	if err := r.pool.QueryRow(ctx, "SELECT count(*) FROM products").Scan(&total); err != nil {
+		logger.Error().
+			Err(err).
+			Msg("failed to count products")
+		return nil, 0, storageError("failed to count products", err)
+	}
+
+	logger.Info().
+		Int64("total", total).
+		Msg("products found successfully")
+	return products, total, nil
+}
+
+func (r *ProductRepository) FindPage(ctx context.Context, sortBy, sortDir string, after *product.Keyset, limit int) ([]*product.Product, error) {
+	logger.Debug().
+		Str("sort_by", sortBy).
+		Str("sort_dir", sortDir).
+		Bool("after", after != nil).
+		Int("limit", limit).
+		Msg("attempting to find a page of products")
+
+	dir, past := "ASC", ">"
+	if strings.ToLower(sortDir) == "desc" {
+		dir, past = "DESC", "<"
+	}
+
+	// Rows compare field first and ID second, exactly the order the
+	// (field, id) indexes keep them in
+	var (
+		where   string
+		orderBy = " ORDER BY id " + dir
+		args    []interface{}
+	)
+	column, sorted := sortColumns[sortBy]
+	if sorted {
+		orderBy = fmt.Sprintf(" ORDER BY %s %s, id %s", column, dir, dir)
+	}
+	if after != nil {
+		if sorted {
+			where = fmt.Sprintf(" WHERE (%s, id) %s ($1, $2)", column, past)
+			args = append(args, after.Value, after.ID.Hex())
+		} else {
+			where = " WHERE id " + past + " $1"
+			args = append(args, after.ID.Hex())
+		}
+	}
+	var limitArg *int
+	if limit > 0 {
+		limitArg = &limit
+	}
+	args = append(args, limitArg)
+
+	query := fmt.Sprintf("SELECT %s FROM products%s%s LIMIT $%d", productColumns, where, orderBy, len(args))
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("failed to find products")
+		return nil, storageError("failed to find products", err)
+	}
+	products, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*product.Product, error) {
+		return scanProduct(row)
+	})
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("failed to decode products")
+		return nil, storageError("failed to decode products", err)
+	}
+
+	logger.Info().
+		Int("count", len(products)).
+		Msg("page of products found successfully")
+	return products, nil
+}
+
+func (r *ProductRepository) Count(ctx context.Context) (int64, error) {
+	var total int64
+	if err := r.pool.QueryRow(ctx, "SELECT count(*) FROM products").Scan(&total); err != nil {
+		logger.Error().
+			Err(err).
+			Msg("failed to count products")
+		return 0, storageError("failed to count products", err)
+	}
+	return total, nil
+}
+
+func (r *ProductRepository) Update(ctx context.Context, prod *product.Product) error {
+	logger.Debug().
+		Str("product_id", prod.ID.Hex()).
+		Int64("version", prod.Version).
+		Msg("attempting to update product")
+
+	result, err := r.pool.Exec(ctx,
+		`UPDATE products
+		SET name = $3, description = $4, price = $5, stock = $6, reserved = $7,
+			stock_levels = $8, reserved_levels = $12, version = $11, created_at = $9, updated_at = $10
+		WHERE id = $1 AND version = $2`,
+		prod.ID.Hex(), prod.Version, prod.Name, prod.Description, prod.Price, prod.Stock,
+		prod.Reserved, levels(prod.StockLevels), prod.CreatedAt, prod.UpdatedAt, prod.Version+1,
+		levels(prod.ReservedLevels))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if stderrors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			logger.Error().
+				Str("product_name", prod.Name).
+				Err(err).
+				Msg("product already exists")
+			return errors.StandardError(errors.ECONFLICT, product.ErrProductAlreadyExists)
+		}
+		logger.Error().
+			Str("product_id", prod.ID.Hex()).
+			Err(err).
+			Msg("failed to update product")
+		return storageError("failed to update product", err)
+	}
+	if result.RowsAffected() == 0 {
+		return r.missOrConflict(ctx, prod.ID.Hex())
+	}
+
+	prod.Version++
+	logger.Info().
+		Str("product_id", prod.ID.Hex()).
+		Int64("version", prod.Version).
+		Msg("product updated successfully")
+	return nil
+}
+
+func (r *ProductRepository) Delete(ctx context.Context, prod *product.Product) error {
+	logger.Debug().
+		Str("product_id", prod.ID.Hex()).
+		Int64("version", prod.Version).
+		Msg("attempting to delete product")
+
+	result, err := r.pool.Exec(ctx, "DELETE FROM products WHERE id = $1 AND version = $2", prod.ID.Hex(), prod.Version)
+	if err != nil {
+		logger.Error().
+			Str("product_id", prod.ID.Hex()).
+			Err(err).
+			Msg("failed to delete product")
+		return storageError("failed to delete product", err)
+	}
+	if result.RowsAffected() == 0 {
+		return r.missOrConflict(ctx, prod.ID.Hex())
+	}
+	logger.Info().
+		Str("product_id", prod.ID.Hex()).
+		Msg("product deleted successfully")
+
+	return nil
+}
+
+func (r *ProductRepository) AdjustStock(ctx context.Context, id, locationID string, delta int) (*product.Product, error) {
+	logger.Debug().
+		Str("product_id", id).
+		Str("location_id", locationID).
+		Int("delta", delta).
+		Msg("attempting to adjust product stock")
+
+	change, err := stock.Adjust(locationID, delta)
+	if err != nil {
+		return nil, err
+	}
+	return r.guardedUpdate(ctx, id, change, "adjust product stock")
+}
+
+func (r *ProductRepository) TransferStock(ctx context.Context, id, fromLocationID, toLocationID string, quantity int) (*product.Product, error) {
+	logger.Debug().
+		Str("product_id", id).
+		Str("from_location_id", fromLocationID).
+		Str("to_location_id", toLocationID).
+		Int("quantity", quantity).
+		Msg("attempting to transfer product stock")
+
+	change, err := stock.Transfer(fromLocationID, toLocationID, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return r.guardedUpdate(ctx, id, change, "transfer product stock")
+}
+
+func (r *ProductRepository) HasStockAt(ctx context.Context, locationID string) (bool, error) {
+	if err := stock.ValidateLocationID(locationID); err != nil {
+		return false, err
+	}
+
+	var exists bool
+	err := r.pool.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM products WHERE (stock_levels ->> $1)::int > 0)",
+		locationID).Scan(&exists)
+	if err != nil {
+		logger.Error().
+			Str("location_id", locationID).
+			Err(err).
+			Msg("failed to check stock at location")
+		return false, storageError("failed to check stock at location", err)
+	}
+	return exists, nil
+}
+
+func (r *ProductRepository) Reserve(ctx context.Context, id, locationID string, quantity int) (*product.Product, error) {
+	logger.Debug().
+		Str("product_id", id).
+		Str("location_id", locationID).
+		Int("quantity", quantity).
+		Msg("attempting to reserve product stock")
+
+	change, err := stock.Reserve(locationID, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return r.guardedUpdate(ctx, id, change, "reserve product stock")
+}
+
+func (r *ProductRepository) ReleaseReserved(ctx context.Context, id, locationID string, quantity int, consume bool) (*product.Product, error) {
+	logger.Debug().
+		Str("product_id", id).
+		Str("location_id", locationID).
+		Int("quantity", quantity).
+		Bool("consume", consume).
+		Msg("attempting to release reserved product stock")
+
+	change, err := stock.Release(locationID, quantity, consume)
+	if err != nil {
+		return nil, err
+	}
+	return r.guardedUpdate(ctx, id, change, "release reserved product stock")
+}
+
+// guardedUpdate applies change to the product only while it is allowed. The
+// row stays locked from the check to the write, so concurrent writers can
+// never push stock or reservations past their limits.
+func (r *ProductRepository) guardedUpdate(ctx context.Context, id string, change *stock.Change, op string) (*product.Product, error) {
+	if _, err := parseID(id); err != nil {
+		return nil, err
+	}
+
+	var prod *product.Product
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		prod, err = scanProduct(tx.QueryRow(ctx, "SELECT "+productColumns+" FROM products WHERE id = $1 FOR UPDATE", id))
+		if stderrors.Is(err, pgx.ErrNoRows) {
+			logger.Error().
+				Str("product_id", id).
+				Msg("product not found")
+			return errors.StandardError(errors.ENOTFOUND, product.ErrProductNotFound)
+		}
+		if err != nil {
+			return err
+		}
+		if !change.Allowed(prod) {
+			logger.Warn().
+				Str("product_id", id).
+				Msg("insufficient stock")
+			return errors.StandardError(errors.EVALIDATION, product.ErrInsufficientStock)
+		}
+
+		change.Apply(prod)
+		prod.Version++
+		prod.UpdatedAt = time.Now()
+
+		_, err = tx.Exec(ctx,
+			`UPDATE products
+			SET stock = $2, reserved = $3, stock_levels = $4, reserved_levels = $5, version = $6, updated_at = $7
+			WHERE id = $1`,
+			id, prod.Stock, prod.Reserved, levels(prod.StockLevels), levels(prod.ReservedLevels), prod.Version, prod.UpdatedAt)
+		return err
+	})
+	if err != nil {
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) {
+			return nil, appErr
+		}
+		logger.Error().
+			Str("product_id", id).
+			Err(err).
+			Msgf("failed to %s", op)
+		return nil, storageError("failed to "+op, err)
+	}
+
+	logger.Info().
+		Str("product_id", id).
+		Int("stock", prod.Stock).
+		Int("reserved", prod.Reserved).
+		Msgf("%s succeeded", op)
+	return prod, nil
+}
+
+// missOrConflict explains why a versioned write matched nothing: either the
+// product is gone or another writer advanced its version first.
+func (r *ProductRepository) missOrConflict(ctx context.Context, id string) error {
+	var exists bool
+	if err := r.pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)", id).Scan(&exists); err != nil {
+		return storageError("failed to check product existence", err)
+	}
+	if !exists {
+		logger.Error().
+			Str("product_id", id).
+			Msg("product not found")
+		return errors.StandardError(errors.ENOTFOUND, product.ErrProductNotFound)
+	}
+
+	logger.Warn().
+		Str("product_id", id).
+		Msg("product version conflict")
+	return errors.StandardError(errors.ECONFLICT, product.ErrVersionConflict)
+}
+
+// Search matches names as a case-insensitive regular expression, the way the
+// MongoDB repository does.
+func (r *ProductRepository) Search(ctx context.Context, name string, minPrice, maxPrice float64) ([]*product.Product, error) {
+	logger.Debug().
+		Str("name", name).
+		Float64("min_price", minPrice).
+		Float64("max_price", maxPrice).
+		Msg("attempting to search products with parameters")
+
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	if name != "" {
+		args = append(args, name)
+		conditions = append(conditions, fmt.Sprintf("name ~* $%d", len(args)))
+	}
+	if minPrice > 0 {
+		args = append(args, minPrice)
+		conditions = append(conditions, fmt.Sprintf("price >= $%d", len(args)))
+	}
+	if maxPrice > 0 {
+		args = append(args, maxPrice)
+		conditions = append(conditions, fmt.Sprintf("price <= $%d", len(args)))
+	}
+
+	query := "SELECT " + productColumns + " FROM products"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id"
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("failed to search products")
+		return nil, storageError("failed to search products", err)
+	}
+	products, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*product.Product, error) {
+		return scanProduct(row)
+	})
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("failed to decode search results")
+		return nil, storageError("failed to decode search results", err)
+	}
+
+	logger.Info().
+		Int("total", len(products)).
+		Msg("products found successfully")
+	return products, nil
+}
+
+// scanProduct reads a row selected with productColumns.
+func scanProduct(row pgx.Row) (*product.Product, error) {
+	var (
+		prod product.Product
+		id   string
+	)
+	err := row.Scan(&id, &prod.Name, &prod.Description, &prod.Price, &prod.Stock, &prod.Reserved,
+		&prod.StockLevels, &prod.ReservedLevels, &prod.Version, &prod.CreatedAt, &prod.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if prod.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, fmt.Errorf("stored product ID %q: %w", id, err)
+	}
+	// MongoDB drops empty levels, so an empty map reads back as none
+	if len(prod.StockLevels) == 0 {
+		prod.StockLevels = nil
+	}
+	if len(prod.ReservedLevels) == 0 {
+		prod.ReservedLevels = nil
+	}
+	return &prod, nil
+}
+
+// levels is the value of a stock_levels or reserved_levels column, which is
+// never NULL.
+func levels(quantities map[string]int) map[string]int {
+	if quantities == nil {
+		return map[string]int{}
+	}
+	return quantities
+}
+
+func parseID(id string) (primitive.ObjectID, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		logger.Error().
+			Str("product_id", id).
+			Err(err).
+			Msg("invalid product ID")
+		return primitive.NilObjectID, errors.StandardError(errors.EINVALID, fmt.Errorf("invalid product ID: %v", err))
+	}
+	return objectID, nil
+}
+
+// storageError wraps a driver failure, telling timeouts apart from an
+// unavailable database so callers can decide whether to retry.
+func storageError(msg string, err error) *errors.AppError {
+	code := errors.EREPOSITORY
+	if pgconn.Timeout(err) || stderrors.Is(err, context.DeadlineExceeded) {
+		code = errors.ETIMEOUT
+	}
+	return errors.StandardError(code, fmt.Errorf("%s: %v", msg, err))
+}
@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/domain/product/producttest"
+)
+
+// TestProductRepositoryContract runs against the database named by
+// POSTGRES_TEST_URL, whose products table it empties, and is skipped when
+// that isn't set.
+func TestProductRepositoryContract(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_URL")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_URL not set")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer pool.Close()
+
+	if err := Migrate(ctx, pool); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	// Running again must find nothing left to do
+	if err := Migrate(ctx, pool); err != nil {
+		t.Fatalf("Migrate again: %v", err)
+	}
+
+	producttest.RunRepositoryContract(t, func(t *testing.T) product.Repository {
+		if _, err := pool.Exec(ctx, "TRUNCATE products"); err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+		return NewProductRepository(pool)
+	})
+}
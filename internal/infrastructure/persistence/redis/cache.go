@@ -2,24 +2,82 @@ package redis
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"go-microservice-product-porto/internal/infrastructure/cache"
 	"go-microservice-product-porto/pkg/errors"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 )
 
+const (
+	// scanBatchSize is the COUNT hint used when scanning for pattern deletes.
+	scanBatchSize = 500
+	// tagPrefix namespaces the sets that track which keys carry a tag.
+	tagPrefix = "tag:"
+)
+
+// setTaggedScript stores KEYS[1] and adds it to the tag sets in KEYS[2:]. Tag
+// sets live at least as long as their longest-lived member, so a tag never
+// forgets an entry that still exists.
+var setTaggedScript = redis.NewScript(`
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+for i = 2, #KEYS do
+	redis.call('SADD', KEYS[i], KEYS[1])
+	if redis.call('PTTL', KEYS[i]) < tonumber(ARGV[2]) then
+		redis.call('PEXPIRE', KEYS[i], ARGV[2])
+	end
+end
+return 1
+`)
+
+// invalidateTagsScript deletes every member of the tag sets in KEYS, then the
+// sets themselves, in one atomic step. It returns how many entries it removed
+// followed by every member it saw, so other instances can drop their local
+// copies even of entries that already expired here.
+var invalidateTagsScript = redis.NewScript(`
+local result = {0}
+for i = 1, #KEYS do
+	local members = redis.call('SMEMBERS', KEYS[i])
+	for j = 1, #members, 1000 do
+		result[1] = result[1] + redis.call('DEL', unpack(members, j, math.min(j + 999, #members)))
+	end
+	for j = 1, #members do
+		result[#result + 1] = members[j]
+	end
+	redis.call('DEL', KEYS[i])
+end
+return result
+`)
+
 type RedisConfig struct {
 	Host     string
 	Port     string
 	Password string
+	// DefaultTTL applies to writes that don't set a TTL of their own.
+	DefaultTTL time.Duration
+	// Codec encodes stored values. It defaults to JSON.
+	Codec cache.Codec
+	// InvalidationChannel, when set, is the pub/sub channel every write but
+	// a fill is announced on, so instances keeping local copies can drop
+	// them.
+	InvalidationChannel string
 }
 
 type RedisCache struct {
-	client *redis.Client
+	client     *redis.Client
+	defaultTTL time.Duration
+	codec      cache.Codec
+	channel    string
+	// origin tells this instance's invalidations apart from its peers'.
+	origin string
 }
 
+// NewRedisCache doesn't wait for Redis to be reachable: connections are made
+// on first use and remade after failures. Use Ping to check on it.
 func NewRedisCache(cfg RedisConfig) (*RedisCache, error) {
 	client := redis.NewClient(&redis.Options{
 		Addr:     cfg.Host + ":" + cfg.Port,
@@ -27,49 +85,298 @@ func NewRedisCache(cfg RedisConfig) (*RedisCache, error) {
 		DB:       0,
 	})
 
-	if err := client.Ping(context.Background()).Err(); err != nil {
-		return nil, errors.StandardError(errors.ECACHE, fmt.Errorf("failed to connect to Redis: %v", err))
+	codec := cfg.Codec
+	if codec == nil {
+		codec = cache.JSON
+	}
+
+	origin := make([]byte, 8)
+	if _, err := rand.Read(origin); err != nil {
+		return nil, errors.StandardError(errors.EINTERNAL, fmt.Errorf("failed to generate cache instance ID: %v", err))
 	}
 
-	return &RedisCache{client: client}, nil
+	return &RedisCache{
+		client:     client,
+		defaultTTL: cfg.DefaultTTL,
+		codec:      codec,
+		channel:    cfg.InvalidationChannel,
+		origin:     hex.EncodeToString(origin),
+	}, nil
 }
 
-func (c *RedisCache) Set(key string, value interface{}) error {
-	json, err := json.Marshal(value)
+func (c *RedisCache) Ping(ctx context.Context) error {
+	if err := c.client.Ping(ctx).Err(); err != nil {
+		return errors.StandardError(errors.ECACHE, fmt.Errorf("failed to connect to Redis: %v", err))
+	}
+	return nil
+}
+
+func (c *RedisCache) Set(ctx context.Context, key string, value interface{}, opts ...cache.Option) error {
+	options := cache.ApplyOptions(c.defaultTTL, opts)
+
+	data, err := c.codec.Marshal(value)
 	if err != nil {
 		return errors.StandardError(errors.ECACHE, fmt.Errorf("failed to marshal value: %v", err))
 	}
 
-	err = c.client.Set(context.Background(), key, json, 24*time.Hour).Err()
-	if err != nil {
+	if err := c.set(ctx, c.client, key, data, options); err != nil {
 		return errors.StandardError(errors.ECACHE, fmt.Errorf("failed to set value in Redis: %v", err))
 	}
 
+	if options.Fill {
+		return nil
+	}
+	return c.publish(ctx, cache.Invalidation{Keys: []string{key}})
+}
+
+// set writes one encoded entry, registering it under its tags when it has any.
+func (c *RedisCache) set(ctx context.Context, client redis.Cmdable, key string, data []byte, options cache.Options) error {
+	if len(options.Tags) == 0 {
+		return client.Set(ctx, key, data, options.TTL).Err()
+	}
+
+	keys := make([]string, 0, len(options.Tags)+1)
+	keys = append(keys, key)
+	for _, tag := range options.Tags {
+		keys = append(keys, tagPrefix+tag)
+	}
+	return setTaggedScript.Run(ctx, client, keys, data, options.TTL.Milliseconds()).Err()
+}
+
+func (c *RedisCache) Get(ctx context.Context, key string, target interface{}) error {
+	val, err := c.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return errors.StandardError(errors.ENOTFOUND, fmt.Errorf("key %s not found in Redis: %w", key, cache.ErrMiss))
+	}
+	if err != nil {
+		return errors.StandardError(errors.ECACHE, fmt.Errorf("failed to get value from Redis: %v", err))
+	}
+
+	if err = c.codec.Unmarshal(val, target); err != nil {
+		return errors.StandardError(errors.ECACHE, fmt.Errorf("failed to unmarshal value from Redis: %v", err))
+	}
+
 	return nil
 }
 
-func (c *RedisCache) Get(key string) (interface{}, error) {
-	val, err := c.client.Get(context.Background(), key).Result()
+func (c *RedisCache) Inspect(ctx context.Context, key string) (*cache.Entry, error) {
+	var (
+		get  *redis.StringCmd
+		pttl *redis.DurationCmd
+	)
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		pttl = pipe.PTTL(ctx, key)
+		return nil
+	})
 	if err == redis.Nil {
-		return nil, errors.StandardError(errors.ENOTFOUND, fmt.Errorf("key not found in Redis: %v", err))
+		return nil, errors.StandardError(errors.ENOTFOUND, fmt.Errorf("key %s not found in Redis: %w", key, cache.ErrMiss))
 	}
 	if err != nil {
-		return nil, errors.StandardError(errors.ECACHE, fmt.Errorf("failed to get value from Redis: %v", err))
+		return nil, errors.StandardError(errors.ECACHE, fmt.Errorf("failed to inspect key in Redis: %v", err))
 	}
 
-	var result interface{}
-	if err = json.Unmarshal([]byte(val), &result); err != nil {
-		return nil, errors.StandardError(errors.ECACHE, fmt.Errorf("failed to unmarshal value from Redis: %v", err))
+	// PTTL reports a negative duration for keys without an expiry
+	ttl := pttl.Val()
+	if ttl < 0 {
+		ttl = 0
 	}
 
-	return result, nil
+	data, _ := get.Bytes()
+	return cache.NewEntry(c.codec, key, data, ttl), nil
 }
 
-func (c *RedisCache) Delete(key string) error {
-	err := c.client.Del(context.Background(), key).Err()
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	err := c.client.Del(ctx, keys...).Err()
 	if err != nil {
 		return errors.StandardError(errors.ECACHE, fmt.Errorf("failed to delete key from Redis: %v", err))
 	}
 
+	return c.publish(ctx, cache.Invalidation{Keys: keys})
+}
+
+// MSet writes every item in one transaction. Redis' own MSET can't set
+// expiries, so the writes are pipelined SETs instead.
+func (c *RedisCache) MSet(ctx context.Context, items map[string]interface{}, opts ...cache.Option) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	options := cache.ApplyOptions(c.defaultTTL, opts)
+
+	encoded := make(map[string][]byte, len(items))
+	for key, value := range items {
+		data, err := c.codec.Marshal(value)
+		if err != nil {
+			return errors.StandardError(errors.ECACHE, fmt.Errorf("failed to marshal value for %s: %v", key, err))
+		}
+		encoded[key] = data
+	}
+
+	// Inside a pipeline EVALSHA can't fall back to EVAL, so make sure the
+	// script is loaded first
+	if len(options.Tags) > 0 {
+		if err := setTaggedScript.Load(ctx, c.client).Err(); err != nil {
+			return errors.StandardError(errors.ECACHE, fmt.Errorf("failed to load tagging script into Redis: %v", err))
+		}
+	}
+
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, data := range encoded {
+			if err := c.set(ctx, pipe, key, data, options); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.StandardError(errors.ECACHE, fmt.Errorf("failed to set values in Redis: %v", err))
+	}
+
+	if options.Fill {
+		return nil
+	}
+	keys := make([]string, 0, len(encoded))
+	for key := range encoded {
+		keys = append(keys, key)
+	}
+	return c.publish(ctx, cache.Invalidation{Keys: keys})
+}
+
+func (c *RedisCache) MGet(ctx context.Context, keys []string, target interface{}) error {
+	entries := make(map[string][]byte, len(keys))
+	if len(keys) > 0 {
+		values, err := c.client.MGet(ctx, keys...).Result()
+		if err != nil {
+			return errors.StandardError(errors.ECACHE, fmt.Errorf("failed to get values from Redis: %v", err))
+		}
+
+		for i, value := range values {
+			// Missing keys come back as nil
+			if raw, ok := value.(string); ok {
+				entries[keys[i]] = []byte(raw)
+			}
+		}
+	}
+
+	if err := cache.DecodeEntries(c.codec, entries, target); err != nil {
+		return errors.StandardError(errors.ECACHE, fmt.Errorf("failed to unmarshal values from Redis: %v", err))
+	}
+
+	return nil
+}
+
+// DeletePattern walks the keyspace with SCAN rather than KEYS so large
+// keyspaces don't block the server.
+func (c *RedisCache) DeletePattern(ctx context.Context, pattern string) (int64, error) {
+	var (
+		cursor  uint64
+		deleted int64
+	)
+	for {
+		keys, next, err := c.client.Scan(ctx, cursor, pattern, scanBatchSize).Result()
+		if err != nil {
+			return deleted, errors.StandardError(errors.ECACHE, fmt.Errorf("failed to scan keys in Redis: %v", err))
+		}
+
+		if len(keys) > 0 {
+			n, err := c.client.Unlink(ctx, keys...).Result()
+			if err != nil {
+				return deleted, errors.StandardError(errors.ECACHE, fmt.Errorf("failed to delete keys from Redis: %v", err))
+			}
+			deleted += n
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return deleted, c.publish(ctx, cache.Invalidation{Patterns: []string{pattern}})
+		}
+	}
+}
+
+func (c *RedisCache) InvalidateTags(ctx context.Context, tags ...string) (int64, error) {
+	if len(tags) == 0 {
+		return 0, nil
+	}
+
+	keys := make([]string, len(tags))
+	for i, tag := range tags {
+		keys[i] = tagPrefix + tag
+	}
+
+	result, err := invalidateTagsScript.Run(ctx, c.client, keys).Slice()
+	if err != nil {
+		return 0, errors.StandardError(errors.ECACHE, fmt.Errorf("failed to invalidate tags in Redis: %v", err))
+	}
+
+	removed, _ := result[0].(int64)
+	members := make([]string, 0, len(result)-1)
+	for _, member := range result[1:] {
+		if key, ok := member.(string); ok {
+			members = append(members, key)
+		}
+	}
+
+	return removed, c.publish(ctx, cache.Invalidation{Keys: members, Tags: tags})
+}
+
+// publish announces a write on the invalidation channel, if there is one.
+// Fills aren't announced: every miss would otherwise evict the copy on every
+// other instance, and the local tier would rarely hold anything.
+func (c *RedisCache) publish(ctx context.Context, inv cache.Invalidation) error {
+	if c.channel == "" {
+		return nil
+	}
+
+	inv.Origin = c.origin
+	payload, err := json.Marshal(inv)
+	if err != nil {
+		return errors.StandardError(errors.ECACHE, fmt.Errorf("failed to marshal cache invalidation: %v", err))
+	}
+
+	if err := c.client.Publish(ctx, c.channel, payload).Err(); err != nil {
+		return errors.StandardError(errors.ECACHE, fmt.Errorf("failed to publish cache invalidation: %v", err))
+	}
+
+	return nil
+}
+
+// Subscribe calls handle with every invalidation other instances publish,
+// until ctx is done. The subscription is made, and remade after Redis drops
+// it, in the background; writes made while it was down are only caught by
+// local expiry.
+func (c *RedisCache) Subscribe(ctx context.Context, handle func(context.Context, cache.Invalidation)) error {
+	if c.channel == "" {
+		return errors.StandardError(errors.EINVALID, fmt.Errorf("no invalidation channel configured"))
+	}
+
+	pubsub := c.client.Subscribe(ctx, c.channel)
+
+	go func() {
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				var inv cache.Invalidation
+				if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil || inv.Origin == c.origin {
+					continue
+				}
+				handle(ctx, inv)
+			}
+		}
+	}()
+
 	return nil
 }
@@ -0,0 +1,136 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	// Registers the pure-Go "sqlite" driver, so no cgo is needed
+	_ "modernc.org/sqlite"
+
+	"go-microservice-product-porto/pkg/errors"
+)
+
+type SQLiteConfig struct {
+	// Path is the database file, created if it doesn't exist.
+	Path string
+	// BusyTimeout is how long a write waits for another to finish before
+	// giving up.
+	BusyTimeout time.Duration
+}
+
+// DSN builds the connection string for cfg. Every connection runs in WAL
+// mode, so reads carry on while a write is in progress, and starts its
+// transactions as writes, so two of them never deadlock upgrading a read.
+func (cfg SQLiteConfig) DSN() string {
+	busyTimeout := cfg.BusyTimeout
+	if busyTimeout <= 0 {
+		busyTimeout = 5 * time.Second
+	}
+	return fmt.Sprintf("%s?_pragma=journal_mode(WAL)&_pragma=busy_timeout(%d)&_pragma=synchronous(NORMAL)&_txlock=immediate",
+		cfg.Path, busyTimeout.Milliseconds())
+}
+
+// InitSQLite opens the database and creates its schema if needed.
+func InitSQLite(cfg SQLiteConfig) (*sql.DB, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	db, err := sql.Open("sqlite", cfg.DSN())
+	if err != nil {
+		return nil, errors.StandardError(errors.EREPOSITORY, fmt.Errorf("failed to open SQLite database: %v", err))
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, errors.StandardError(errors.EREPOSITORY, fmt.Errorf("failed to open SQLite database: %v", err))
+	}
+
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		db.Close()
+		return nil, errors.StandardError(errors.EREPOSITORY, fmt.Errorf("failed to create SQLite schema: %v", err))
+	}
+	for _, column := range addedColumns {
+		if err := addColumn(ctx, db, column.name, column.definition); err != nil {
+			db.Close()
+			return nil, errors.StandardError(errors.EREPOSITORY, fmt.Errorf("failed to add SQLite column %s: %v", column.name, err))
+		}
+	}
+
+	return db, nil
+}
+
+// schema is created on every start, so each statement must be safe to
+// repeat.
+const schema = `
+CREATE TABLE IF NOT EXISTS products (
+	-- seq keys the full-text index, which needs a rowid that never changes
+	seq          INTEGER PRIMARY KEY,
+	id           TEXT NOT NULL UNIQUE,
+	name         TEXT NOT NULL,
+	description  TEXT NOT NULL DEFAULT '',
+	price        REAL NOT NULL,
+	stock        INTEGER NOT NULL DEFAULT 0,
+	reserved     INTEGER NOT NULL DEFAULT 0,
+	stock_levels TEXT NOT NULL DEFAULT '{}',
+	reserved_levels TEXT NOT NULL DEFAULT '{}',
+	version      INTEGER NOT NULL DEFAULT 1,
+	-- Times are Unix nanoseconds, which sort and round-trip exactly
+	created_at   INTEGER NOT NULL,
+	updated_at   INTEGER NOT NULL
+);
+
+-- Sorts end in the ID, so keyset pages can seek straight to where the last
+-- one stopped. These replace the single-column indexes of older databases.
+CREATE INDEX IF NOT EXISTS products_name_id_idx ON products (name, id);
+CREATE INDEX IF NOT EXISTS products_price_id_idx ON products (price, id);
+CREATE INDEX IF NOT EXISTS products_stock_id_idx ON products (stock, id);
+CREATE INDEX IF NOT EXISTS products_created_at_id_idx ON products (created_at, id);
+DROP INDEX IF EXISTS products_name_idx;
+
+-- Names are what tells products apart, as in the other backends
+CREATE UNIQUE INDEX IF NOT EXISTS products_name_key ON products (name);
+DROP INDEX IF EXISTS products_price_idx;
+DROP INDEX IF EXISTS products_stock_idx;
+DROP INDEX IF EXISTS products_created_at_idx;
+
+-- Trigrams let a search term match anywhere in a word, as it does in the
+-- other backends, and ignore case
+CREATE VIRTUAL TABLE IF NOT EXISTS products_fts USING fts5(
+	name, description,
+	content = 'products', content_rowid = 'seq',
+	tokenize = 'trigram'
+);
+
+CREATE TRIGGER IF NOT EXISTS products_fts_insert AFTER INSERT ON products BEGIN
+	INSERT INTO products_fts (rowid, name, description) VALUES (new.seq, new.name, new.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS products_fts_delete AFTER DELETE ON products BEGIN
+	INSERT INTO products_fts (products_fts, rowid, name, description) VALUES ('delete', old.seq, old.name, old.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS products_fts_update AFTER UPDATE OF name, description ON products BEGIN
+	INSERT INTO products_fts (products_fts, rowid, name, description) VALUES ('delete', old.seq, old.name, old.description);
+	INSERT INTO products_fts (rowid, name, description) VALUES (new.seq, new.name, new.description);
+END;
+`
+
+// addedColumns are products columns that came after the table was first
+// created, so older databases get them on start.
+var addedColumns = []struct{ name, definition string }{
+	{"reserved_levels", "TEXT NOT NULL DEFAULT '{}'"},
+}
+
+// addColumn adds a column to products unless it is already there. SQLite has
+// no ADD COLUMN IF NOT EXISTS.
+func addColumn(ctx context.Context, db *sql.DB, name, definition string) error {
+	var exists bool
+	err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM pragma_table_info('products') WHERE name = ?)", name).Scan(&exists)
+	if err != nil || exists {
+		return err
+	}
+	_, err = db.ExecContext(ctx, "ALTER TABLE products ADD COLUMN "+name+" "+definition)
+	return err
+}
@@ -0,0 +1,657 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/infrastructure/persistence/stock"
+	"go-microservice-product-porto/pkg/errors"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+const productColumns = "id, name, description, price, stock, reserved, stock_levels, reserved_levels, version, created_at, updated_at"
+
+// sortColumns maps the fields products can be listed by to their columns.
+var sortColumns = map[string]string{
+	"name":       "name",
+	"price":      "price",
+	"stock":      "stock",
+	"created_at": "created_at",
+}
+
+// minTrigramTerm is the shortest term the trigram index can look up.
+const minTrigramTerm = 3
+
+// ProductRepository stores products in an embedded SQLite database. It
+// answers like the MongoDB repository, including its error codes, except that
+// searches look at descriptions as well as names.
+type ProductRepository struct {
+	db *sql.DB
+}
+
+func NewProductRepository(db *sql.DB) *ProductRepository {
+	return &ProductRepository{
+		db: db,
+	}
+}
+
+func (r *ProductRepository) Create(ctx context.Context, prod *product.Product) error {
+	logger.Debug().
+		Str("product_name", prod.Name).
+		Float64("price", prod.Price).
+		Msg("attempting to create product")
+
+	if prod.ID.IsZero() {
+		prod.ID = primitive.NewObjectID()
+	}
+	if prod.Version == 0 {
+		prod.Version = 1
+	}
+
+	levels, reservedLevels, err := encodeLevels(prod)
+	if err != nil {
+		return errors.StandardError(errors.EINTERNAL, err)
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		"INSERT INTO products ("+productColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		prod.ID.Hex(), prod.Name, prod.Description, prod.Price, prod.Stock, prod.Reserved,
+		levels, reservedLevels, prod.Version, prod.CreatedAt.UnixNano(), prod.UpdatedAt.UnixNano())
+	if err != nil {
+		if isUniqueViolation(err) {
+			logger.Error().
+				Str("product_name", prod.Name).
+				Err(err).
+				Msg("product already exists")
+			return errors.StandardError(errors.ECONFLICT, product.ErrProductAlreadyExists)
+		}
+		logger.Error().
+			Str("product_name", prod.Name).
+			Err(err).
+			Msg("failed to create product")
+		return storageError("failed to create product", err)
+	}
+	logger.Info().
+		Str("product_name", prod.Name).
+		Msg("product created successfully")
+
+	return nil
+}
+
+func (r *ProductRepository) FindByID(ctx context.Context, id string) (*product.Product, error) {
+	logger.Debug().
+		Str("product_id", id).
+		Msg("attempting to find product by ID")
+
+	if _, err := parseID(id); err != nil {
+		return nil, err
+	}
+
+	prod, err := scanProduct(r.db.QueryRowContext(ctx, "SELECT "+productColumns+" FROM products WHERE id = ?", id))
+	if stderrors.Is(err, sql.ErrNoRows) {
+		logger.Error().
+			Str("product_id", id).
+			Msg("product not found")
+		return nil, errors.StandardError(errors.ENOTFOUND, product.ErrProductNotFound)
+	}
+	if err != nil {
+		logger.Error().
+			Str("product_id", id).
+			Err(err).
+			Msg("failed to find product")
+		return nil, storageError("failed to find product", err)
+	}
+	return prod, nil
+}
+
+func (r *ProductRepository) FindAll(ctx context.Context, page, pageSize int, sortBy, sortDir string) ([]*product.Product, int64, error) {
+	logger.Debug().
+		Int("page", page).
+		Int("page_size", pageSize).
+		Str("sort_by", sortBy).
+		Str("sort_dir", sortDir).
+		Msg("attempting to find all products")
+
+	// Same rules as MongoDB: no field means ID order, an unknown field means
+	// no particular order. The ID breaks ties so pages never overlap.
+	orderBy := " ORDER BY id"
+	if sortBy != "" {
+		orderBy = ""
+		if column, exists := sortColumns[sortBy]; exists {
+			dir := "ASC"
+			if strings.ToLower(sortDir) == "desc" {
+				dir = "DESC"
+			}
+			orderBy = fmt.Sprintf(" ORDER BY %s %s, id %s", column, dir, dir)
+		}
+	}
+
+	offset := (page - 1) * pageSize
+	if offset < 0 {
+		offset = 0
+	}
+	// A negative limit returns every row, like a zero limit in MongoDB
+	limit := pageSize
+	if limit <= 0 {
+		limit = -1
+	}
+
+	products, err := r.query(ctx, "SELECT "+productColumns+" FROM products"+orderBy+" LIMIT ? OFFSET ?", limit, offset)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("failed to find products")
+		return nil, 0, storageError("failed to find products", err)
+	}
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx, "SELECT count(*) FROM products").Scan(&total); err != nil {
+		logger.Error().
+			Err(err).
+			Msg("failed to count products")
+		return nil, 0, storageError("failed to count products", err)
+	}
+
+	logger.Info().
+		Int64("total", total).
+		Msg("products found successfully")
+	return products, total, nil
+}
+
+func (r *ProductRepository) FindPage(ctx context.Context, sortBy, sortDir string, after *product.Keyset, limit int) ([]*product.Product, error) {
+	logger.Debug().
+		Str("sort_by", sortBy).
+		Str("sort_dir", sortDir).
+		Bool("after", after != nil).
+		Int("limit", limit).
+		Msg("attempting to find a page of products")
+
+	dir, past := "ASC", ">"
+	if strings.ToLower(sortDir) == "desc" {
+		dir, past = "DESC", "<"
+	}
+
+	// Rows compare field first and ID second, the order the (field, id)
+	// indexes keep them in
+	var (
+		where   string
+		orderBy = " ORDER BY id " + dir
+		args    []interface{}
+	)
+	column, sorted := sortColumns[sortBy]
+	if sorted {
+		orderBy = fmt.Sprintf(" ORDER BY %s %s, id %s", column, dir, dir)
+	}
+	if after != nil {
+		if sorted {
+			value := after.Value
+			if t, ok := value.(time.Time); ok {
+				value = t.UnixNano()
+			}
+			where = fmt.Sprintf(" WHERE (%s, id) %s (?, ?)", column, past)
+			args = append(args, value, after.ID.Hex())
+		} else {
+			where = " WHERE id " + past + " ?"
+			args = append(args, after.ID.Hex())
+		}
+	}
+	// A negative limit returns every row
+	if limit <= 0 {
+		limit = -1
+	}
+	args = append(args, limit)
+
+	products, err := r.query(ctx, "SELECT "+productColumns+" FROM products"+where+orderBy+" LIMIT ?", args...)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("failed to find products")
+		return nil, storageError("failed to find products", err)
+	}
+
+	logger.Info().
+		Int("count", len(products)).
+		Msg("page of products found successfully")
+	return products, nil
+}
+
+func (r *ProductRepository) Count(ctx context.Context) (int64, error) {
+	var total int64
+	if err := r.db.QueryRowContext(ctx, "SELECT count(*) FROM products").Scan(&total); err != nil {
+		logger.Error().
+			Err(err).
+			Msg("failed to count products")
+		return 0, storageError("failed to count products", err)
+	}
+	return total, nil
+}
+
+func (r *ProductRepository) Update(ctx context.Context, prod *product.Product) error {
+	logger.Debug().
+		Str("product_id", prod.ID.Hex()).
+		Int64("version", prod.Version).
+		Msg("attempting to update product")
+
+	levels, reservedLevels, err := encodeLevels(prod)
+	if err != nil {
+		return errors.StandardError(errors.EINTERNAL, err)
+	}
+
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE products
+		SET name = ?, description = ?, price = ?, stock = ?, reserved = ?,
+			stock_levels = ?, reserved_levels = ?, version = ?, created_at = ?, updated_at = ?
+		WHERE id = ? AND version = ?`,
+		prod.Name, prod.Description, prod.Price, prod.Stock, prod.Reserved, levels, reservedLevels,
+		prod.Version+1, prod.CreatedAt.UnixNano(), prod.UpdatedAt.UnixNano(), prod.ID.Hex(), prod.Version)
+	if err != nil {
+		if isUniqueViolation(err) {
+			logger.Error().
+				Str("product_name", prod.Name).
+				Err(err).
+				Msg("product already exists")
+			return errors.StandardError(errors.ECONFLICT, product.ErrProductAlreadyExists)
+		}
+		logger.Error().
+			Str("product_id", prod.ID.Hex()).
+			Err(err).
+			Msg("failed to update product")
+		return storageError("failed to update product", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return r.missOrConflict(ctx, prod.ID.Hex())
+	}
+
+	prod.Version++
+	logger.Info().
+		Str("product_id", prod.ID.Hex()).
+		Int64("version", prod.Version).
+		Msg("product updated successfully")
+	return nil
+}
+
+func (r *ProductRepository) Delete(ctx context.Context, prod *product.Product) error {
+	logger.Debug().
+		Str("product_id", prod.ID.Hex()).
+		Int64("version", prod.Version).
+		Msg("attempting to delete product")
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM products WHERE id = ? AND version = ?", prod.ID.Hex(), prod.Version)
+	if err != nil {
+		logger.Error().
+			Str("product_id", prod.ID.Hex()).
+			Err(err).
+			Msg("failed to delete product")
+		return storageError("failed to delete product", err)
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return r.missOrConflict(ctx, prod.ID.Hex())
+	}
+	logger.Info().
+		Str("product_id", prod.ID.Hex()).
+		Msg("product deleted successfully")
+
+	return nil
+}
+
+func (r *ProductRepository) AdjustStock(ctx context.Context, id, locationID string, delta int) (*product.Product, error) {
+	logger.Debug().
+		Str("product_id", id).
+		Str("location_id", locationID).
+		Int("delta", delta).
+		Msg("attempting to adjust product stock")
+
+	change, err := stock.Adjust(locationID, delta)
+	if err != nil {
+		return nil, err
+	}
+	return r.guardedUpdate(ctx, id, change, "adjust product stock")
+}
+
+func (r *ProductRepository) TransferStock(ctx context.Context, id, fromLocationID, toLocationID string, quantity int) (*product.Product, error) {
+	logger.Debug().
+		Str("product_id", id).
+		Str("from_location_id", fromLocationID).
+		Str("to_location_id", toLocationID).
+		Int("quantity", quantity).
+		Msg("attempting to transfer product stock")
+
+	change, err := stock.Transfer(fromLocationID, toLocationID, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return r.guardedUpdate(ctx, id, change, "transfer product stock")
+}
+
+func (r *ProductRepository) HasStockAt(ctx context.Context, locationID string) (bool, error) {
+	// Well-formed IDs are also safe to use as JSON paths
+	if err := stock.ValidateLocationID(locationID); err != nil {
+		return false, err
+	}
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM products WHERE json_extract(stock_levels, ?) > 0)",
+		`$."`+locationID+`"`).Scan(&exists)
+	if err != nil {
+		logger.Error().
+			Str("location_id", locationID).
+			Err(err).
+			Msg("failed to check stock at location")
+		return false, storageError("failed to check stock at location", err)
+	}
+	return exists, nil
+}
+
+func (r *ProductRepository) Reserve(ctx context.Context, id, locationID string, quantity int) (*product.Product, error) {
+	logger.Debug().
+		Str("product_id", id).
+		Str("location_id", locationID).
+		Int("quantity", quantity).
+		Msg("attempting to reserve product stock")
+
+	change, err := stock.Reserve(locationID, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return r.guardedUpdate(ctx, id, change, "reserve product stock")
+}
+
+func (r *ProductRepository) ReleaseReserved(ctx context.Context, id, locationID string, quantity int, consume bool) (*product.Product, error) {
+	logger.Debug().
+		Str("product_id", id).
+		Str("location_id", locationID).
+		Int("quantity", quantity).
+		Bool("consume", consume).
+		Msg("attempting to release reserved product stock")
+
+	change, err := stock.Release(locationID, quantity, consume)
+	if err != nil {
+		return nil, err
+	}
+	return r.guardedUpdate(ctx, id, change, "release reserved product stock")
+}
+
+// guardedUpdate applies change to the product only while it is allowed. The
+// transaction takes the write lock before reading, so concurrent writers can
+// never push stock or reservations past their limits.
+func (r *ProductRepository) guardedUpdate(ctx context.Context, id string, change *stock.Change, op string) (*product.Product, error) {
+	if _, err := parseID(id); err != nil {
+		return nil, err
+	}
+
+	prod, err := r.inTx(ctx, func(tx *sql.Tx) (*product.Product, error) {
+		prod, err := scanProduct(tx.QueryRowContext(ctx, "SELECT "+productColumns+" FROM products WHERE id = ?", id))
+		if stderrors.Is(err, sql.ErrNoRows) {
+			logger.Error().
+				Str("product_id", id).
+				Msg("product not found")
+			return nil, errors.StandardError(errors.ENOTFOUND, product.ErrProductNotFound)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !change.Allowed(prod) {
+			logger.Warn().
+				Str("product_id", id).
+				Msg("insufficient stock")
+			return nil, errors.StandardError(errors.EVALIDATION, product.ErrInsufficientStock)
+		}
+
+		change.Apply(prod)
+		prod.Version++
+		prod.UpdatedAt = time.Now()
+
+		levels, reservedLevels, err := encodeLevels(prod)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.ExecContext(ctx,
+			"UPDATE products SET stock = ?, reserved = ?, stock_levels = ?, reserved_levels = ?, version = ?, updated_at = ? WHERE id = ?",
+			prod.Stock, prod.Reserved, levels, reservedLevels, prod.Version, prod.UpdatedAt.UnixNano(), id)
+		return prod, err
+	})
+	if err != nil {
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) {
+			return nil, appErr
+		}
+		logger.Error().
+			Str("product_id", id).
+			Err(err).
+			Msgf("failed to %s", op)
+		return nil, storageError("failed to "+op, err)
+	}
+
+	logger.Info().
+		Str("product_id", id).
+		Int("stock", prod.Stock).
+		Int("reserved", prod.Reserved).
+		Msgf("%s succeeded", op)
+	return prod, nil
+}
+
+// inTx runs fn in a transaction, committing only if it succeeds.
+func (r *ProductRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) (*product.Product, error)) (*product.Product, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	prod, err := fn(tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return prod, nil
+}
+
+// missOrConflict explains why a versioned write matched nothing: either the
+// product is gone or another writer advanced its version first.
+func (r *ProductRepository) missOrConflict(ctx context.Context, id string) error {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM products WHERE id = ?)", id).Scan(&exists); err != nil {
+		return storageError("failed to check product existence", err)
+	}
+	if !exists {
+		logger.Error().
+			Str("product_id", id).
+			Msg("product not found")
+		return errors.StandardError(errors.ENOTFOUND, product.ErrProductNotFound)
+	}
+
+	logger.Warn().
+		Str("product_id", id).
+		Msg("product version conflict")
+	return errors.StandardError(errors.ECONFLICT, product.ErrVersionConflict)
+}
+
+// Search finds products whose name or description contains name, ignoring
+// case. Plain terms are looked up in the full-text index; terms too short for
+// it, or written as regular expressions like the MongoDB repository accepts,
+// are matched against each product in the price range instead.
+func (r *ProductRepository) Search(ctx context.Context, name string, minPrice, maxPrice float64) ([]*product.Product, error) {
+	logger.Debug().
+		Str("name", name).
+		Float64("min_price", minPrice).
+		Float64("max_price", maxPrice).
+		Msg("attempting to search products with parameters")
+
+	var (
+		conditions []string
+		args       []interface{}
+		pattern    *regexp.Regexp
+	)
+	if name != "" {
+		if regexp.QuoteMeta(name) == name && utf8.RuneCountInString(name) >= minTrigramTerm {
+			conditions = append(conditions, "seq IN (SELECT rowid FROM products_fts WHERE products_fts MATCH ?)")
+			args = append(args, `"`+strings.ReplaceAll(name, `"`, `""`)+`"`)
+		} else {
+			var err error
+			if pattern, err = regexp.Compile("(?i)" + name); err != nil {
+				return nil, storageError("failed to search products", err)
+			}
+		}
+	}
+	if minPrice > 0 {
+		conditions = append(conditions, "price >= ?")
+		args = append(args, minPrice)
+	}
+	if maxPrice > 0 {
+		conditions = append(conditions, "price <= ?")
+		args = append(args, maxPrice)
+	}
+
+	query := "SELECT " + productColumns + " FROM products"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY seq"
+
+	found, err := r.query(ctx, query, args...)
+	if err != nil {
+		logger.Error().
+			Err(err).
+			Msg("failed to search products")
+		return nil, storageError("failed to search products", err)
+	}
+
+	products := found
+	if pattern != nil {
+		products = make([]*product.Product, 0, len(found))
+		for _, p := range found {
+			if pattern.MatchString(p.Name) || pattern.MatchString(p.Description) {
+				products = append(products, p)
+			}
+		}
+	}
+
+	logger.Info().
+		Int("total", len(products)).
+		Msg("products found successfully")
+	return products, nil
+}
+
+// query runs a select of productColumns and reads every row.
+func (r *ProductRepository) query(ctx context.Context, query string, args ...interface{}) ([]*product.Product, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []*product.Product{}
+	for rows.Next() {
+		prod, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, prod)
+	}
+	return products, rows.Err()
+}
+
+// scanProduct reads a row selected with productColumns.
+func scanProduct(row interface{ Scan(...interface{}) error }) (*product.Product, error) {
+	var (
+		prod                       product.Product
+		id, levels, reservedLevels string
+		createdAt, updatedAt       int64
+	)
+	err := row.Scan(&id, &prod.Name, &prod.Description, &prod.Price, &prod.Stock, &prod.Reserved,
+		&levels, &reservedLevels, &prod.Version, &createdAt, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if prod.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return nil, fmt.Errorf("stored product ID %q: %w", id, err)
+	}
+	if err := json.Unmarshal([]byte(levels), &prod.StockLevels); err != nil {
+		return nil, fmt.Errorf("stored stock levels of %s: %w", id, err)
+	}
+	if err := json.Unmarshal([]byte(reservedLevels), &prod.ReservedLevels); err != nil {
+		return nil, fmt.Errorf("stored reserved levels of %s: %w", id, err)
+	}
+	// MongoDB drops empty levels, so an empty map reads back as none
+	if len(prod.StockLevels) == 0 {
+		prod.StockLevels = nil
+	}
+	if len(prod.ReservedLevels) == 0 {
+		prod.ReservedLevels = nil
+	}
+	prod.CreatedAt = time.Unix(0, createdAt)
+	prod.UpdatedAt = time.Unix(0, updatedAt)
+	return &prod, nil
+}
+
+// encodeLevels is the product's stock_levels and reserved_levels columns,
+// which are never NULL.
+func encodeLevels(prod *product.Product) (string, string, error) {
+	levels, err := encodeQuantities(prod.StockLevels)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode stock levels: %w", err)
+	}
+	reservedLevels, err := encodeQuantities(prod.ReservedLevels)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode reserved levels: %w", err)
+	}
+	return levels, reservedLevels, nil
+}
+
+func encodeQuantities(quantities map[string]int) (string, error) {
+	if len(quantities) == 0 {
+		return "{}", nil
+	}
+	encoded, err := json.Marshal(quantities)
+	return string(encoded), err
+}
+
+func parseID(id string) (primitive.ObjectID, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		logger.Error().
+			Str("product_id", id).
+			Err(err).
+			Msg("invalid product ID")
+		return primitive.NilObjectID, errors.StandardError(errors.EINVALID, fmt.Errorf("invalid product ID: %v", err))
+	}
+	return objectID, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !stderrors.As(err, &sqliteErr) {
+		return false
+	}
+	code := sqliteErr.Code()
+	return code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+// storageError wraps a driver failure, telling timeouts apart from an
+// unavailable database so callers can decide whether to retry. A write that
+// waited out the busy timeout counts as a timeout.
+func storageError(msg string, err error) *errors.AppError {
+	code := errors.EREPOSITORY
+	var sqliteErr *sqlite.Error
+	if stderrors.Is(err, context.DeadlineExceeded) ||
+		(stderrors.As(err, &sqliteErr) && sqliteErr.Code()&0xff == sqlite3.SQLITE_BUSY) {
+		code = errors.ETIMEOUT
+	}
+	return errors.StandardError(code, fmt.Errorf("%s: %v", msg, err))
+}
@@ -0,0 +1,76 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/domain/product/producttest"
+)
+
+func newTestRepository(t *testing.T) *ProductRepository {
+	t.Helper()
+	db, err := InitSQLite(SQLiteConfig{Path: filepath.Join(t.TempDir(), "products.db")})
+	if err != nil {
+		t.Fatalf("InitSQLite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewProductRepository(db)
+}
+
+func TestProductRepositoryContract(t *testing.T) {
+	producttest.RunRepositoryContract(t, func(t *testing.T) product.Repository {
+		return newTestRepository(t)
+	})
+}
+
+func TestSearchDescriptions(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+
+	for _, p := range []*product.Product{
+		product.NewProduct("Lamp", "Brass reading lamp", 40, 1),
+		product.NewProduct("Desk", "Oak writing desk", 300, 1),
+	} {
+		if err := repo.Create(ctx, p); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	tests := []struct {
+		term string
+		want string
+	}{
+		{"READING", "Lamp"},
+		{"oak w", "Desk"},
+		{"^oak", "Desk"},
+	}
+	for _, tt := range tests {
+		found, err := repo.Search(ctx, tt.term, 0, 0)
+		if err != nil {
+			t.Fatalf("Search %q: %v", tt.term, err)
+		}
+		if len(found) != 1 || found[0].Name != tt.want {
+			t.Errorf("Search %q = %v, want %s", tt.term, found, tt.want)
+		}
+	}
+
+	// Renames reach the index
+	found, _ := repo.Search(ctx, "Lamp", 0, 0)
+	lamp := found[0]
+	lamp.Name = "Sconce"
+	lamp.Description = "Wall light"
+	if err := repo.Update(ctx, lamp); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	for term, want := range map[string]int{"reading": 0, "wall light": 1} {
+		found, err := repo.Search(ctx, term, 0, 0)
+		if err != nil {
+			t.Fatalf("Search %q: %v", term, err)
+		}
+		if len(found) != want {
+			t.Errorf("Search %q after rename found %d, want %d", term, len(found), want)
+		}
+	}
+}
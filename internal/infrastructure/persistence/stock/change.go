@@ -0,0 +1,155 @@
+// Package stock holds the stock rules shared by the repositories that read a
+// product, check it and write it back: memory, PostgreSQL and SQLite. The
+// MongoDB repository expresses the same rules as conditional updates, and
+// the repository contract tests keep them in step.
+package stock
+
+import (
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+// Change moves a product's stock counters, provided its guard holds for the
+// product as currently stored.
+type Change struct {
+	guard          func(p *product.Product) bool
+	stock          int
+	reserved       int
+	levels         map[string]int
+	reservedLevels map[string]int
+}
+
+// Allowed reports whether the change may be applied to p.
+func (c *Change) Allowed(p *product.Product) bool {
+	return c.guard(p)
+}
+
+// Apply moves p's counters. It leaves the version and timestamps to the
+// caller, which writes them along with the counters.
+func (c *Change) Apply(p *product.Product) {
+	p.Stock += c.stock
+	p.Reserved += c.reserved
+	for locationID, delta := range c.levels {
+		if p.StockLevels == nil {
+			p.StockLevels = map[string]int{}
+		}
+		p.StockLevels[locationID] += delta
+	}
+	// Holds placed before reservations were counted per location were
+	// never added, so taking them away stops at zero
+	for locationID, delta := range c.reservedLevels {
+		if p.ReservedLevels == nil {
+			p.ReservedLevels = map[string]int{}
+		}
+		p.ReservedLevels[locationID] = max(p.ReservedLevels[locationID]+delta, 0)
+	}
+}
+
+// Adjust changes the stock at a location, or the unallocated stock when
+// locationID is empty, by delta. Reserved units are spoken for, so only
+// available stock may be taken, and never more than the location (or
+// unallocated pool) holds beyond what is reserved there.
+func Adjust(locationID string, delta int) (*Change, error) {
+	change := &Change{stock: delta, levels: map[string]int{}}
+	if locationID != "" {
+		if err := ValidateLocationID(locationID); err != nil {
+			return nil, err
+		}
+		change.levels[locationID] = delta
+	}
+	change.guard = func(p *product.Product) bool {
+		return p.Stock-p.Reserved >= -delta && Free(p, locationID) >= -delta
+	}
+	return change, nil
+}
+
+// Transfer moves quantity between locations, where an empty ID stands for
+// the unallocated stock. The total doesn't change, and reserved units stay
+// where they are held.
+func Transfer(fromLocationID, toLocationID string, quantity int) (*Change, error) {
+	change := &Change{levels: map[string]int{}}
+	if fromLocationID != "" {
+		if err := ValidateLocationID(fromLocationID); err != nil {
+			return nil, err
+		}
+		change.levels[fromLocationID] -= quantity
+	}
+	if toLocationID != "" {
+		if err := ValidateLocationID(toLocationID); err != nil {
+			return nil, err
+		}
+		change.levels[toLocationID] += quantity
+	}
+	change.guard = func(p *product.Product) bool {
+		return Free(p, fromLocationID) >= quantity
+	}
+	return change, nil
+}
+
+// Reserve holds quantity units that are both available and held at the
+// location, or unallocated when locationID is empty, and not already
+// reserved there.
+func Reserve(locationID string, quantity int) (*Change, error) {
+	change := &Change{reserved: quantity, reservedLevels: map[string]int{}}
+	if locationID != "" {
+		if err := ValidateLocationID(locationID); err != nil {
+			return nil, err
+		}
+		change.reservedLevels[locationID] = quantity
+	}
+	change.guard = func(p *product.Product) bool {
+		return p.Stock-p.Reserved >= quantity && Free(p, locationID) >= quantity
+	}
+	return change, nil
+}
+
+// Release gives up quantity reserved units. When consume is set the units
+// are taken out of stock too, from the location they were held at or the
+// unallocated stock, so stock never drops below what locations hold.
+func Release(locationID string, quantity int, consume bool) (*Change, error) {
+	change := &Change{reserved: -quantity, levels: map[string]int{}, reservedLevels: map[string]int{}}
+	if locationID != "" {
+		if err := ValidateLocationID(locationID); err != nil {
+			return nil, err
+		}
+		change.reservedLevels[locationID] = -quantity
+	}
+	if consume {
+		change.stock = -quantity
+		if locationID != "" {
+			change.levels[locationID] = -quantity
+		}
+	}
+	change.guard = func(p *product.Product) bool {
+		return p.Reserved >= quantity && (!consume || At(p, locationID) >= quantity)
+	}
+	return change, nil
+}
+
+// At is the stock held at a location, or the unallocated stock when
+// locationID is empty.
+func At(p *product.Product, locationID string) int {
+	if locationID == "" {
+		return p.UnallocatedStock()
+	}
+	return p.StockLevels[locationID]
+}
+
+// Free is the stock at a location, or unallocated when locationID is empty,
+// that isn't reserved there.
+func Free(p *product.Product, locationID string) int {
+	return At(p, locationID) - p.ReservedAt(locationID)
+}
+
+// ValidateLocationID accepts only well-formed location IDs, like the MongoDB
+// repository does.
+func ValidateLocationID(locationID string) error {
+	if _, err := primitive.ObjectIDFromHex(locationID); err != nil {
+		return errors.StandardError(errors.EINVALID, fmt.Errorf("invalid location ID: %v", err))
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package http
+
+import (
+	stderrors "errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go-microservice-product-porto/internal/application/commands"
+	"go-microservice-product-porto/internal/application/queries"
+	"go-microservice-product-porto/pkg/errors"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+var errCacheKeyRequired = stderrors.New("cache key is required")
+
+type CacheAdminHandler struct {
+	commandHandler *commands.CacheCommandHandler
+	queryHandler   *queries.CacheQueryHandler
+	products       *queries.ProductQueryHandler
+	// warm is what a warm-up loads unless the request says otherwise
+	warm queries.WarmCacheQuery
+}
+
+func NewCacheAdminHandler(commandHandler *commands.CacheCommandHandler, queryHandler *queries.CacheQueryHandler, products *queries.ProductQueryHandler, warm queries.WarmCacheQuery) *CacheAdminHandler {
+	return &CacheAdminHandler{
+		commandHandler: commandHandler,
+		queryHandler:   queryHandler,
+		products:       products,
+		warm:           warm,
+	}
+}
+
+func (h *CacheAdminHandler) GetCacheStats(c *gin.Context) {
+	logger.Info().
+		Str("handler", "GetCacheStats").
+		Msg("Fetching cache statistics")
+
+	stats, err := h.queryHandler.HandleGetCacheStats(c.Request.Context(), queries.GetCacheStatsQuery{})
+	if err != nil {
+		logger.Error().
+			Str("handler", "GetCacheStats").
+			Err(err).
+			Msg("Error fetching cache statistics")
+
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func (h *CacheAdminHandler) InspectCacheKey(c *gin.Context) {
+	logger.Info().
+		Str("handler", "InspectCacheKey").
+		Msg("Inspecting cache key")
+
+	key := c.Param("key")
+	if key == "" {
+		respondError(c, errors.StandardError(errors.EBADREQUEST, errCacheKeyRequired))
+		return
+	}
+
+	entry, err := h.queryHandler.HandleInspectCacheKey(c.Request.Context(), queries.InspectCacheKeyQuery{Key: key})
+	if err != nil {
+		logger.Error().
+			Str("handler", "InspectCacheKey").
+			Err(err).
+			Msg("Error inspecting cache key")
+
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+func (h *CacheAdminHandler) FlushCachePrefix(c *gin.Context) {
+	logger.Info().
+		Str("handler", "FlushCachePrefix").
+		Msg("Flushing cache keys by prefix")
+
+	cmd := commands.FlushCachePrefixCommand{Prefix: c.Query("prefix")}
+	deleted, err := h.commandHandler.HandleFlushCachePrefix(c.Request.Context(), cmd)
+	if err != nil {
+		logger.Error().
+			Str("handler", "FlushCachePrefix").
+			Err(err).
+			Msg("Error flushing cache keys by prefix")
+
+		respondError(c, err)
+		return
+	}
+
+	logger.Info().
+		Str("handler", "FlushCachePrefix").
+		Str("prefix", cmd.Prefix).
+		Int64("deleted", deleted).
+		Msg("Cache keys flushed successfully")
+
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
+}
+
+func (h *CacheAdminHandler) FlushCacheTags(c *gin.Context) {
+	logger.Info().
+		Str("handler", "FlushCacheTags").
+		Msg("Flushing cache keys by tag")
+
+	cmd := commands.FlushCacheTagsCommand{Tags: c.QueryArray("tag")}
+	removed, err := h.commandHandler.HandleFlushCacheTags(c.Request.Context(), cmd)
+	if err != nil {
+		logger.Error().
+			Str("handler", "FlushCacheTags").
+			Err(err).
+			Msg("Error flushing cache keys by tag")
+
+		respondError(c, err)
+		return
+	}
+
+	logger.Info().
+		Str("handler", "FlushCacheTags").
+		Strs("tags", cmd.Tags).
+		Int64("deleted", removed).
+		Msg("Cache keys flushed successfully")
+
+	c.JSON(http.StatusOK, gin.H{"deleted": removed})
+}
+
+func (h *CacheAdminHandler) WarmCache(c *gin.Context) {
+	logger.Info().
+		Str("handler", "WarmCache").
+		Msg("Warming cache")
+
+	// Without a body the configured warm-up runs
+	query := h.warm
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&query); err != nil {
+			logger.Error().
+				Str("handler", "WarmCache").
+				Err(err).
+				Msg("Error binding JSON")
+
+			respondError(c, errors.StandardError(errors.EBADREQUEST, err))
+			return
+		}
+	}
+
+	result, err := h.products.HandleWarmCache(c.Request.Context(), query)
+	if err != nil {
+		logger.Error().
+			Str("handler", "WarmCache").
+			Err(err).
+			Msg("Error warming cache")
+
+		respondError(c, err)
+		return
+	}
+
+	logger.Info().
+		Str("handler", "WarmCache").
+		Int("recent_products", result.RecentProducts).
+		Int("list_pages", result.ListPages).
+		Msg("Cache warmed successfully")
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go-microservice-product-porto/pkg/errors"
+)
+
+// statusByCode maps application error codes to HTTP statuses.
+var statusByCode = map[string]int{
+	errors.EBADREQUEST:   http.StatusBadRequest,
+	errors.EINVALID:      http.StatusBadRequest,
+	errors.EUNAUTHORIZED: http.StatusUnauthorized,
+	errors.EFORBIDDEN:    http.StatusForbidden,
+	errors.ENOTFOUND:     http.StatusNotFound,
+	errors.ECONFLICT:     http.StatusConflict,
+	errors.EPRECONDITION: http.StatusPreconditionFailed,
+	errors.EUNSUPPORTED:  http.StatusUnsupportedMediaType,
+	errors.EVALIDATION:   http.StatusUnprocessableEntity,
+	errors.EINTERNAL:     http.StatusInternalServerError,
+	errors.EREPOSITORY:   http.StatusServiceUnavailable,
+	errors.ECACHE:        http.StatusServiceUnavailable,
+	errors.ETIMEOUT:      http.StatusGatewayTimeout,
+}
+
+// StatusFromError translates err into an HTTP status and the AppError that
+// describes it. Errors that carry no AppError are reported as internal errors,
+// except for context deadlines which surface as timeouts.
+func StatusFromError(err error) (int, *errors.AppError) {
+	appErr, ok := errors.RootAppError(err)
+	if !ok {
+		if stderrors.Is(err, context.DeadlineExceeded) {
+			appErr = errors.StandardError(errors.ETIMEOUT, err)
+		} else {
+			appErr = errors.StandardError(errors.EINTERNAL, err)
+		}
+	}
+
+	status, ok := statusByCode[appErr.Code]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+
+	return status, appErr
+}
+
+// respondError writes err as a structured error body and aborts the request.
+func respondError(c *gin.Context, err error) {
+	status, appErr := StatusFromError(err)
+	c.AbortWithStatusJSON(status, NewErrorResponse(appErr, status, RequestIDFromContext(c)))
+}
@@ -0,0 +1,90 @@
+package http
+
+import (
+	stderrors "errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/pkg/errors"
+)
+
+var errInvalidIfMatch = stderrors.New("If-Match is not a list of entity tags")
+
+// setETag exposes a product version as a strong entity tag.
+func setETag(c *gin.Context, version int64) {
+	c.Header("ETag", fmt.Sprintf("%q", strconv.FormatInt(version, 10)))
+}
+
+// expectedVersions reads the versions a client pinned with If-Match. It
+// returns nil when the header is absent or "*", meaning any current version
+// will do. If-Match compares strongly, so weak tags never match; a list with
+// nothing that could match fails the precondition straight away.
+func expectedVersions(c *gin.Context) ([]int64, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	tags, ok := parseETags(header)
+	if !ok {
+		return nil, errors.StandardError(errors.EPRECONDITION, errInvalidIfMatch)
+	}
+
+	var versions []int64
+	for _, tag := range tags {
+		if tag.weak {
+			continue
+		}
+		// Tags other than a version were never issued, so they can't match
+		if version, err := strconv.ParseInt(tag.opaque, 10, 64); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, errors.StandardError(errors.EPRECONDITION, product.ErrVersionConflict)
+	}
+
+	return versions, nil
+}
+
+type entityTag struct {
+	opaque string
+	weak   bool
+}
+
+// parseETags splits a comma-separated list of entity tags. Commas may appear
+// inside a tag's quotes, so the list is scanned rather than split.
+func parseETags(header string) ([]entityTag, bool) {
+	var tags []entityTag
+	rest := header
+	for {
+		rest = strings.TrimLeft(rest, " \t,")
+		if rest == "" {
+			return tags, len(tags) > 0
+		}
+
+		var tag entityTag
+		if strings.HasPrefix(rest, "W/") {
+			tag.weak = true
+			rest = rest[len("W/"):]
+		}
+		if !strings.HasPrefix(rest, `"`) {
+			return nil, false
+		}
+		end := strings.IndexByte(rest[1:], '"')
+		if end < 0 {
+			return nil, false
+		}
+		tag.opaque = rest[1 : end+1]
+		tags = append(tags, tag)
+
+		rest = strings.TrimLeft(rest[end+2:], " \t")
+		if rest != "" && rest[0] != ',' {
+			return nil, false
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"go-microservice-product-porto/pkg/errors"
+)
+
+func TestExpectedVersions(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    []int64
+		wantErr bool
+	}{
+		{header: ""},
+		{header: "*"},
+		{header: ` * `},
+		{header: `"3"`, want: []int64{3}},
+		{header: `"3", "5"`, want: []int64{3, 5}},
+		{header: `"3","5" ,"7"`, want: []int64{3, 5, 7}},
+		{header: `W/"3", "5"`, want: []int64{5}},
+		{header: `"a,b", "5"`, want: []int64{5}},
+		{header: `W/"3"`, wantErr: true},
+		{header: `W/"3", W/"5"`, wantErr: true},
+		{header: `"abc"`, wantErr: true},
+		{header: `3`, wantErr: true},
+		{header: `"3`, wantErr: true},
+		{header: `"3" "5"`, wantErr: true},
+		{header: `"3", *`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPut, "/", nil)
+		c.Request.Header.Set("If-Match", tt.header)
+
+		got, err := expectedVersions(c)
+		if tt.wantErr {
+			if appErr, ok := errors.RootAppError(err); !ok || appErr.Code != errors.EPRECONDITION {
+				t.Errorf("If-Match %s: error = %v, want %s", tt.header, err, errors.EPRECONDITION)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("If-Match %s: %v", tt.header, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("If-Match %s = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
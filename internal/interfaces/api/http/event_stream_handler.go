@@ -0,0 +1,106 @@
+package http
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+
+	"go-microservice-product-porto/internal/application/events"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+// LastEventIDHeader is sent by EventSource clients when they reconnect.
+const LastEventIDHeader = "Last-Event-ID"
+
+// EventStreamHandler streams product events to clients as server-sent events
+// so dashboards can react to changes instead of polling the list endpoint.
+type EventStreamHandler struct {
+	stream    *events.Stream
+	heartbeat time.Duration
+}
+
+func NewEventStreamHandler(stream *events.Stream, heartbeat time.Duration) *EventStreamHandler {
+	return &EventStreamHandler{
+		stream:    stream,
+		heartbeat: heartbeat,
+	}
+}
+
+// StreamProductEvents streams events as they are published. The types query
+// parameter takes a comma-separated list of event types to filter on, and a
+// Last-Event-ID header (or last_event_id parameter, for clients that cannot
+// set headers) resumes after that event.
+func (h *EventStreamHandler) StreamProductEvents(c *gin.Context) {
+	logger.Info().
+		Str("handler", "StreamProductEvents").
+		Msg("Opening product event stream")
+
+	var types []string
+	for _, t := range strings.Split(c.Query("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+
+	lastEventID := c.GetHeader(LastEventIDHeader)
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	replay, listener := h.stream.Listen(lastEventID, types)
+	defer listener.Close()
+
+	c.Header("Content-Type", sse.ContentType)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Keep reverse proxies from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+
+	for _, envelope := range replay {
+		renderEvent(c, envelope)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for h.next(c, listener, heartbeat.C) {
+		c.Writer.Flush()
+	}
+
+	logger.Info().
+		Str("handler", "StreamProductEvents").
+		Msg("Product event stream closed")
+}
+
+// next writes the next event or heartbeat and reports whether the stream
+// should stay open.
+func (h *EventStreamHandler) next(c *gin.Context, listener *events.Listener, heartbeat <-chan time.Time) bool {
+	select {
+	case <-c.Request.Context().Done():
+		return false
+	case envelope, ok := <-listener.C:
+		if !ok {
+			// Dropped for falling behind; the client reconnects and resumes
+			// from its last event ID
+			return false
+		}
+		renderEvent(c, envelope)
+		return true
+	case <-heartbeat:
+		// A comment line keeps idle connections from timing out
+		_, err := io.WriteString(c.Writer, ": heartbeat\n\n")
+		return err == nil
+	}
+}
+
+func renderEvent(c *gin.Context, envelope *events.Envelope) {
+	c.Render(-1, sse.Event{
+		Id:    envelope.ID,
+		Event: envelope.Type,
+		Data:  []byte(envelope.Data),
+	})
+}
@@ -0,0 +1,194 @@
+package http
+
+import (
+	stderrors "errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go-microservice-product-porto/internal/application/commands"
+	"go-microservice-product-porto/internal/application/queries"
+	"go-microservice-product-porto/pkg/errors"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+var errLocationIDRequired = stderrors.New("location id is required")
+
+type LocationHandler struct {
+	commandHandler *commands.LocationCommandHandler
+	queryHandler   *queries.LocationQueryHandler
+}
+
+func NewLocationHandler(commandHandler *commands.LocationCommandHandler, queryHandler *queries.LocationQueryHandler) *LocationHandler {
+	return &LocationHandler{
+		commandHandler: commandHandler,
+		queryHandler:   queryHandler,
+	}
+}
+
+func (h *LocationHandler) CreateLocation(c *gin.Context) {
+	logger.Info().
+		Str("handler", "CreateLocation").
+		Msg("Creating a new location")
+
+	var cmd commands.CreateLocationCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		logger.Error().
+			Str("handler", "CreateLocation").
+			Err(err).
+			Msg("Error binding JSON")
+
+		respondError(c, errors.StandardError(errors.EBADREQUEST, err))
+		return
+	}
+
+	location, err := h.commandHandler.HandleCreateLocation(c.Request.Context(), cmd)
+	if err != nil {
+		logger.Error().
+			Str("handler", "CreateLocation").
+			Err(err).
+			Msg("Error handling create location command")
+
+		respondError(c, err)
+		return
+	}
+
+	logger.Info().
+		Str("handler", "CreateLocation").
+		Msg("Location created successfully")
+
+	c.JSON(http.StatusCreated, location)
+}
+
+func (h *LocationHandler) ListLocations(c *gin.Context) {
+	logger.Info().
+		Str("handler", "ListLocations").
+		Msg("Fetching list of locations")
+
+	locations, err := h.queryHandler.HandleListLocations(c.Request.Context(), queries.ListLocationsQuery{})
+	if err != nil {
+		logger.Error().
+			Str("handler", "ListLocations").
+			Err(err).
+			Msg("Error fetching list of locations")
+
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": locations})
+}
+
+func (h *LocationHandler) GetLocation(c *gin.Context) {
+	logger.Info().
+		Str("handler", "GetLocation").
+		Msg("Fetching location details")
+
+	locationID := c.Param("id")
+	if locationID == "" {
+		respondError(c, errors.StandardError(errors.EBADREQUEST, errLocationIDRequired))
+		return
+	}
+
+	location, err := h.queryHandler.HandleGetLocation(c.Request.Context(), queries.GetLocationQuery{ID: locationID})
+	if err != nil {
+		logger.Error().
+			Str("handler", "GetLocation").
+			Err(err).
+			Msg("Error fetching location details")
+
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, location)
+}
+
+func (h *LocationHandler) UpdateLocation(c *gin.Context) {
+	logger.Info().
+		Str("handler", "UpdateLocation").
+		Msg("Updating location")
+
+	locationID := c.Param("id")
+	if locationID == "" {
+		respondError(c, errors.StandardError(errors.EBADREQUEST, errLocationIDRequired))
+		return
+	}
+
+	var request struct {
+		Code    string `json:"code"`
+		Name    string `json:"name"`
+		Address string `json:"address"`
+		Active  *bool  `json:"active"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error().
+			Str("handler", "UpdateLocation").
+			Err(err).
+			Msg("Error binding JSON")
+
+		respondError(c, errors.StandardError(errors.EBADREQUEST, err))
+		return
+	}
+
+	// Locations stay active unless explicitly switched off
+	active := true
+	if request.Active != nil {
+		active = *request.Active
+	}
+
+	cmd := commands.UpdateLocationCommand{
+		LocationID: locationID,
+		Code:       request.Code,
+		Name:       request.Name,
+		Address:    request.Address,
+		Active:     active,
+	}
+
+	location, err := h.commandHandler.HandleUpdateLocation(c.Request.Context(), cmd)
+	if err != nil {
+		logger.Error().
+			Str("handler", "UpdateLocation").
+			Err(err).
+			Msg("Error handling update location command")
+
+		respondError(c, err)
+		return
+	}
+
+	logger.Info().
+		Str("handler", "UpdateLocation").
+		Msg("Location updated successfully")
+
+	c.JSON(http.StatusOK, location)
+}
+
+func (h *LocationHandler) DeleteLocation(c *gin.Context) {
+	logger.Info().
+		Str("handler", "DeleteLocation").
+		Msg("Deleting location")
+
+	locationID := c.Param("id")
+	if locationID == "" {
+		respondError(c, errors.StandardError(errors.EBADREQUEST, errLocationIDRequired))
+		return
+	}
+
+	cmd := commands.DeleteLocationCommand{LocationID: locationID}
+	if err := h.commandHandler.HandleDeleteLocation(c.Request.Context(), cmd); err != nil {
+		logger.Error().
+			Str("handler", "DeleteLocation").
+			Err(err).
+			Msg("Error deleting location")
+
+		respondError(c, err)
+		return
+	}
+
+	logger.Info().
+		Str("handler", "DeleteLocation").
+		Msg("Location deleted successfully")
+
+	c.JSON(http.StatusOK, gin.H{"message": "Location deleted successfully"})
+}
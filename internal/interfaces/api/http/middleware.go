@@ -1,8 +1,15 @@
 package http
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	stderrors "errors"
+	"strings"
 	"time"
 
+	"go-microservice-product-porto/pkg/common"
+	"go-microservice-product-porto/pkg/errors"
 	"go-microservice-product-porto/pkg/logger"
 
 	"github.com/gin-gonic/gin"
@@ -11,8 +18,9 @@ import (
 func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match, "+LastEventIDHeader+", "+ActorHeader+", "+RequestIDHeader)
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag, "+RequestIDHeader)
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -23,6 +31,55 @@ func CORSMiddleware() gin.HandlerFunc {
 	}
 }
 
+const (
+	RequestIDHeader = "X-Request-ID"
+	requestIDKey    = "request_id"
+)
+
+// RequestIDMiddleware propagates the caller's X-Request-ID or assigns a new one
+// so that error bodies and logs can be correlated.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+
+		c.Set(requestIDKey, requestID)
+		c.Writer.Header().Set(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+func RequestIDFromContext(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// ActorHeader identifies who is making a change. Until authentication is in
+// place, callers are trusted to set it themselves.
+const ActorHeader = "X-User-ID"
+
+// ActorMiddleware attaches the calling actor to the request context so that
+// audit records can attribute changes.
+func ActorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if actor := c.GetHeader(ActorHeader); actor != "" {
+			c.Request = c.Request.WithContext(common.WithActor(c.Request.Context(), actor))
+		}
+
+		c.Next()
+	}
+}
+
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Add authentication logic here
@@ -31,6 +88,30 @@ func AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+var (
+	errAdminTokenInvalid = stderrors.New("a valid admin bearer token is required")
+	errAdminDisabled     = stderrors.New("admin endpoints are disabled until an admin token is configured")
+)
+
+// AdminAuthMiddleware guards operational endpoints with a shared bearer
+// token. Without a token they are closed to everyone.
+func AdminAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			respondError(c, errors.StandardError(errors.EFORBIDDEN, errAdminDisabled))
+			return
+		}
+
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			respondError(c, errors.StandardError(errors.EUNAUTHORIZED, errAdminTokenInvalid))
+			return
+		}
+
+		c.Next()
+	}
+}
+
 func LoggerMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
@@ -38,6 +119,7 @@ func LoggerMiddleware() gin.HandlerFunc {
 		query := c.Request.URL.RawQuery
 
 		logger.Info().
+			Str("request_id", RequestIDFromContext(c)).
 			Str("method", c.Request.Method).
 			Str("path", path).
 			Str("query", query).
@@ -56,6 +138,7 @@ func LoggerMiddleware() gin.HandlerFunc {
 		}
 
 		logEvent.
+			Str("request_id", RequestIDFromContext(c)).
 			Int("status", statusCode).
 			Str("method", c.Request.Method).
 			Str("path", path).
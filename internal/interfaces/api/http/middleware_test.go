@@ -0,0 +1,39 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	for _, tc := range []struct {
+		name   string
+		token  string
+		header string
+		want   int
+	}{
+		{name: "no token configured", token: "", header: "", want: http.StatusForbidden},
+		{name: "no token configured, empty bearer", token: "", header: "Bearer ", want: http.StatusForbidden},
+		{name: "missing header", token: "s3cret", header: "", want: http.StatusUnauthorized},
+		{name: "wrong token", token: "s3cret", header: "Bearer nope", want: http.StatusUnauthorized},
+		{name: "right token", token: "s3cret", header: "Bearer s3cret", want: http.StatusNoContent},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/admin", AdminAuthMiddleware(tc.token), func(c *gin.Context) {
+				c.Status(http.StatusNoContent)
+			})
+
+			headers := map[string]string{}
+			if tc.header != "" {
+				headers["Authorization"] = tc.header
+			}
+			rec := serve(t, router, http.MethodGet, "/admin", nil, headers)
+			expectStatus(t, rec, tc.want)
+		})
+	}
+}
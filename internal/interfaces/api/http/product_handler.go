@@ -1,6 +1,7 @@
 package http
 
 import (
+	stderrors "errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -10,9 +11,20 @@ import (
 	"go-microservice-product-porto/internal/application/commands"
 	"go-microservice-product-porto/internal/application/queries"
 	"go-microservice-product-porto/pkg/common"
+	"go-microservice-product-porto/pkg/errors"
 	"go-microservice-product-porto/pkg/logger"
 )
 
+var (
+	errProductIDRequired = stderrors.New("product id is required")
+	errMissingFields     = stderrors.New("required fields are missing")
+	errPatchMediaType    = stderrors.New("patches must be sent as application/merge-patch+json")
+)
+
+// mergePatchMediaType is the media type of RFC 7396 patches. Plain JSON is
+// accepted too, since it is what most clients send by default.
+const mergePatchMediaType = "application/merge-patch+json"
+
 type ProductHandler struct {
 	commandHandler *commands.ProductCommandHandler
 	queryHandler   *queries.ProductQueryHandler
@@ -43,7 +55,7 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 			Err(err).
 			Msg("Error binding JSON")
 
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, errors.StandardError(errors.EBADREQUEST, err))
 		return
 	}
 
@@ -60,7 +72,7 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 			Err(err).
 			Msg("Error handling create product command")
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -82,7 +94,7 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 			Str("handler", "GetProduct").
 			Msg("Product ID is required")
 
-		c.JSON(http.StatusBadRequest, gin.H{"error": "product id is required"})
+		respondError(c, errors.StandardError(errors.EBADREQUEST, errProductIDRequired))
 		return
 	}
 
@@ -94,7 +106,7 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 			Err(err).
 			Msg("Error fetching product details")
 
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -102,6 +114,7 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		Str("handler", "GetProduct").
 		Msg("Product details fetched successfully")
 
+	setETag(c, product.Version)
 	c.JSON(http.StatusOK, product)
 }
 
@@ -110,11 +123,16 @@ func (h *ProductHandler) ListProducts(c *gin.Context) {
 		Str("handler", "ListProducts").
 		Msg("Fetching list of products")
 
+	// Passing cursor, even empty, switches to keyset pages
+	cursor, keyset := c.GetQuery("cursor")
 	query := queries.ListProductsQuery{
-		Page:     common.ParseInt(c.DefaultQuery("page", "1")),
-		PageSize: common.ParseInt(c.DefaultQuery("page_size", "10")),
-		SortBy:   c.DefaultQuery("sort_by", ""),
-		SortDir:  c.DefaultQuery("sort_dir", "asc"),
+		Page:         common.ParseInt(c.DefaultQuery("page", "1")),
+		PageSize:     common.ParseInt(c.DefaultQuery("page_size", "10")),
+		SortBy:       c.DefaultQuery("sort_by", ""),
+		SortDir:      c.DefaultQuery("sort_dir", "asc"),
+		Keyset:       keyset,
+		Cursor:       cursor,
+		IncludeTotal: c.Query("include_total") == "true",
 	}
 
 	result, err := h.queryHandler.HandleListProducts(c.Request.Context(), query)
@@ -124,7 +142,7 @@ func (h *ProductHandler) ListProducts(c *gin.Context) {
 			Err(err).
 			Msg("Error fetching list of products")
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -146,7 +164,7 @@ func (h *ProductHandler) UpdateStock(c *gin.Context) {
 			Str("handler", "UpdateStock").
 			Msg("Product ID is required")
 
-		c.JSON(http.StatusBadRequest, gin.H{"error": "product id is required"})
+		respondError(c, errors.StandardError(errors.EBADREQUEST, errProductIDRequired))
 		return
 	}
 
@@ -157,18 +175,26 @@ func (h *ProductHandler) UpdateStock(c *gin.Context) {
 			Err(err).
 			Msg("Error binding JSON")
 
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, errors.StandardError(errors.EBADREQUEST, err))
 		return
 	}
 	cmd.ProductID = productID
 
-	if err := h.commandHandler.HandleUpdateStock(c.Request.Context(), cmd); err != nil {
+	versions, err := expectedVersions(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	cmd.ExpectedVersions = versions
+
+	product, err := h.commandHandler.HandleUpdateStock(c.Request.Context(), cmd)
+	if err != nil {
 		logger.Error().
 			Str("handler", "UpdateStock").
 			Err(err).
 			Msg("Error updating stock")
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -176,9 +202,305 @@ func (h *ProductHandler) UpdateStock(c *gin.Context) {
 		Str("handler", "UpdateStock").
 		Msg("Stock updated successfully")
 
+	setETag(c, product.Version)
 	c.JSON(http.StatusOK, gin.H{"message": "Stock updated successfully"})
 }
 
+func (h *ProductHandler) UpdateProduct(c *gin.Context) {
+	logger.Info().
+		Str("handler", "UpdateProduct").
+		Msg("Replacing product")
+
+	productID := c.Param("id")
+	if productID == "" {
+		logger.Error().
+			Str("handler", "UpdateProduct").
+			Msg("Product ID is required")
+
+		respondError(c, errors.StandardError(errors.EBADREQUEST, errProductIDRequired))
+		return
+	}
+
+	var request struct {
+		Name        *string  `json:"name"`
+		Description string   `json:"description"`
+		Price       *float64 `json:"price"`
+		Stock       *int     `json:"stock"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error().
+			Str("handler", "UpdateProduct").
+			Err(err).
+			Msg("Error binding JSON")
+
+		respondError(c, errors.StandardError(errors.EBADREQUEST, err))
+		return
+	}
+
+	// A full replace must state every required field explicitly
+	missing := map[string]string{}
+	if request.Name == nil {
+		missing["name"] = "is required"
+	}
+	if request.Price == nil {
+		missing["price"] = "is required"
+	}
+	if request.Stock == nil {
+		missing["stock"] = "is required"
+	}
+	if len(missing) > 0 {
+		respondError(c, errors.FieldError(errMissingFields, missing))
+		return
+	}
+
+	versions, err := expectedVersions(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	cmd := commands.UpdateProductCommand{
+		ProductID:        productID,
+		Name:             *request.Name,
+		Description:      request.Description,
+		Price:            *request.Price,
+		Stock:            *request.Stock,
+		ExpectedVersions: versions,
+	}
+
+	product, err := h.commandHandler.HandleUpdateProduct(c.Request.Context(), cmd)
+	if err != nil {
+		logger.Error().
+			Str("handler", "UpdateProduct").
+			Err(err).
+			Msg("Error handling update product command")
+
+		respondError(c, err)
+		return
+	}
+
+	logger.Info().
+		Str("handler", "UpdateProduct").
+		Msg("Product updated successfully")
+
+	setETag(c, product.Version)
+	c.JSON(http.StatusOK, product)
+}
+
+func (h *ProductHandler) PatchProduct(c *gin.Context) {
+	logger.Info().
+		Str("handler", "PatchProduct").
+		Msg("Patching product")
+
+	productID := c.Param("id")
+	if productID == "" {
+		logger.Error().
+			Str("handler", "PatchProduct").
+			Msg("Product ID is required")
+
+		respondError(c, errors.StandardError(errors.EBADREQUEST, errProductIDRequired))
+		return
+	}
+
+	if contentType := c.ContentType(); contentType != mergePatchMediaType && contentType != "application/json" {
+		logger.Error().
+			Str("handler", "PatchProduct").
+			Str("content_type", contentType).
+			Msg("Unsupported patch media type")
+
+		c.Header("Accept-Patch", mergePatchMediaType)
+		respondError(c, errors.StandardError(errors.EUNSUPPORTED, errPatchMediaType))
+		return
+	}
+
+	patch, err := c.GetRawData()
+	if err != nil {
+		logger.Error().
+			Str("handler", "PatchProduct").
+			Err(err).
+			Msg("Error reading request body")
+
+		respondError(c, errors.StandardError(errors.EBADREQUEST, err))
+		return
+	}
+
+	versions, err := expectedVersions(c)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	cmd := commands.PatchProductCommand{
+		ProductID:        productID,
+		Patch:            patch,
+		ExpectedVersions: versions,
+	}
+
+	product, err := h.commandHandler.HandlePatchProduct(c.Request.Context(), cmd)
+	if err != nil {
+		logger.Error().
+			Str("handler", "PatchProduct").
+			Err(err).
+			Msg("Error handling patch product command")
+
+		respondError(c, err)
+		return
+	}
+
+	logger.Info().
+		Str("handler", "PatchProduct").
+		Msg("Product patched successfully")
+
+	setETag(c, product.Version)
+	c.JSON(http.StatusOK, product)
+}
+
+func (h *ProductHandler) AdjustStock(c *gin.Context) {
+	logger.Info().
+		Str("handler", "AdjustStock").
+		Msg("Adjusting product stock")
+
+	productID := c.Param("id")
+	if productID == "" {
+		logger.Error().
+			Str("handler", "AdjustStock").
+			Msg("Product ID is required")
+
+		respondError(c, errors.StandardError(errors.EBADREQUEST, errProductIDRequired))
+		return
+	}
+
+	var cmd commands.AdjustStockCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		logger.Error().
+			Str("handler", "AdjustStock").
+			Err(err).
+			Msg("Error binding JSON")
+
+		respondError(c, errors.StandardError(errors.EBADREQUEST, err))
+		return
+	}
+	cmd.ProductID = productID
+
+	product, err := h.commandHandler.HandleAdjustStock(c.Request.Context(), cmd)
+	if err != nil {
+		logger.Error().
+			Str("handler", "AdjustStock").
+			Err(err).
+			Msg("Error adjusting stock")
+
+		respondError(c, err)
+		return
+	}
+
+	logger.Info().
+		Str("handler", "AdjustStock").
+		Msg("Stock adjusted successfully")
+
+	setETag(c, product.Version)
+	c.JSON(http.StatusOK, product)
+}
+
+func (h *ProductHandler) TransferStock(c *gin.Context) {
+	logger.Info().
+		Str("handler", "TransferStock").
+		Msg("Transferring product stock")
+
+	productID := c.Param("id")
+	if productID == "" {
+		logger.Error().
+			Str("handler", "TransferStock").
+			Msg("Product ID is required")
+
+		respondError(c, errors.StandardError(errors.EBADREQUEST, errProductIDRequired))
+		return
+	}
+
+	var cmd commands.TransferStockCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		logger.Error().
+			Str("handler", "TransferStock").
+			Err(err).
+			Msg("Error binding JSON")
+
+		respondError(c, errors.StandardError(errors.EBADREQUEST, err))
+		return
+	}
+	cmd.ProductID = productID
+
+	product, err := h.commandHandler.HandleTransferStock(c.Request.Context(), cmd)
+	if err != nil {
+		logger.Error().
+			Str("handler", "TransferStock").
+			Err(err).
+			Msg("Error transferring stock")
+
+		respondError(c, err)
+		return
+	}
+
+	logger.Info().
+		Str("handler", "TransferStock").
+		Msg("Stock transferred successfully")
+
+	setETag(c, product.Version)
+	c.JSON(http.StatusOK, product)
+}
+
+func (h *ProductHandler) ListStockMovements(c *gin.Context) {
+	logger.Info().
+		Str("handler", "ListStockMovements").
+		Msg("Fetching stock movements")
+
+	productID := c.Param("id")
+	if productID == "" {
+		logger.Error().
+			Str("handler", "ListStockMovements").
+			Msg("Product ID is required")
+
+		respondError(c, errors.StandardError(errors.EBADREQUEST, errProductIDRequired))
+		return
+	}
+
+	query := queries.ListStockMovementsQuery{
+		ProductID: productID,
+		Page:      common.ParseInt(c.DefaultQuery("page", "1")),
+		PageSize:  common.ParseInt(c.DefaultQuery("page_size", "20")),
+	}
+
+	var err error
+	if from := c.Query("from"); from != "" {
+		if query.From, err = common.ParseDateTime(from, false); err != nil {
+			respondError(c, errors.StandardError(errors.EBADREQUEST, err))
+			return
+		}
+	}
+	if to := c.Query("to"); to != "" {
+		if query.To, err = common.ParseDateTime(to, true); err != nil {
+			respondError(c, errors.StandardError(errors.EBADREQUEST, err))
+			return
+		}
+	}
+
+	result, err := h.queryHandler.HandleListStockMovements(c.Request.Context(), query)
+	if err != nil {
+		logger.Error().
+			Str("handler", "ListStockMovements").
+			Err(err).
+			Msg("Error fetching stock movements")
+
+		respondError(c, err)
+		return
+	}
+
+	logger.Info().
+		Str("handler", "ListStockMovements").
+		Msg("Stock movements fetched successfully")
+
+	c.JSON(http.StatusOK, result)
+}
+
 func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 	logger.Info().
 		Str("handler", "DeleteProduct").
@@ -190,18 +512,24 @@ func (h *ProductHandler) DeleteProduct(c *gin.Context) {
 			Str("handler", "DeleteProduct").
 			Msg("Product ID is required")
 
-		c.JSON(http.StatusBadRequest, gin.H{"error": "product id is required"})
+		respondError(c, errors.StandardError(errors.EBADREQUEST, errProductIDRequired))
+		return
+	}
+
+	versions, err := expectedVersions(c)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
-	cmd := commands.DeleteProductCommand{ProductID: productID}
+	cmd := commands.DeleteProductCommand{ProductID: productID, ExpectedVersions: versions}
 	if err := h.commandHandler.HandleDeleteProduct(c.Request.Context(), cmd); err != nil {
 		logger.Error().
 			Str("handler", "DeleteProduct").
 			Err(err).
 			Msg("Error deleting product")
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -222,12 +550,20 @@ func (h *ProductHandler) SearchProducts(c *gin.Context) {
 	}
 
 	if minPriceStr := c.Query("min_price"); minPriceStr != "" {
-		minPrice, _ := strconv.ParseFloat(minPriceStr, 64)
+		minPrice, err := strconv.ParseFloat(minPriceStr, 64)
+		if err != nil {
+			respondError(c, errors.StandardError(errors.EBADREQUEST, err))
+			return
+		}
 		query.MinPrice = minPrice
 	}
 
 	if maxPriceStr := c.Query("max_price"); maxPriceStr != "" {
-		maxPrice, _ := strconv.ParseFloat(maxPriceStr, 64)
+		maxPrice, err := strconv.ParseFloat(maxPriceStr, 64)
+		if err != nil {
+			respondError(c, errors.StandardError(errors.EBADREQUEST, err))
+			return
+		}
 		query.MaxPrice = maxPrice
 	}
 
@@ -238,7 +574,7 @@ func (h *ProductHandler) SearchProducts(c *gin.Context) {
 			Err(err).
 			Msg("Error searching for products")
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondError(c, err)
 		return
 	}
 
@@ -0,0 +1,270 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go-microservice-product-porto/internal/application/commands"
+	eventhandlers "go-microservice-product-porto/internal/application/event_handlers"
+	"go-microservice-product-porto/internal/application/outbox"
+	"go-microservice-product-porto/internal/application/queries"
+	"go-microservice-product-porto/internal/domain/movement"
+	"go-microservice-product-porto/internal/domain/product"
+	"go-microservice-product-porto/internal/infrastructure/cache"
+	"go-microservice-product-porto/internal/infrastructure/persistence/memory"
+)
+
+// discardMovements accepts movements without keeping them.
+type discardMovements struct{}
+
+func (discardMovements) Append(context.Context, *movement.Movement) error { return nil }
+
+func (discardMovements) FindByProduct(context.Context, movement.Filter) ([]*movement.Movement, int64, error) {
+	return nil, 0, nil
+}
+
+// discardOutbox accepts events without ever relaying them.
+type discardOutbox struct{}
+
+func (discardOutbox) Add(context.Context, ...*outbox.Message) error { return nil }
+
+func (discardOutbox) Claim(context.Context, time.Time, int, time.Duration) ([]*outbox.Message, error) {
+	return nil, nil
+}
+
+func (discardOutbox) MarkPublished(context.Context, string) error { return nil }
+
+func (discardOutbox) MarkFailed(context.Context, string, string, time.Time, bool) error {
+	return nil
+}
+
+// newTestRouter serves the product API from memory, with the same cache and
+// handlers the service wires up in main.
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	repo := memory.NewProductRepository()
+	cacheService := cache.NewMemoryCache(cache.MemoryConfig{})
+	ttls := cache.TTLPolicy{Product: time.Minute, List: time.Minute, Search: time.Minute}
+
+	eventHandler := eventhandlers.NewProductEventHandler(cacheService, ttls, repo)
+	commandHandler := commands.NewProductCommandHandler(repo, discardMovements{}, nil, discardOutbox{}, outbox.NoTransaction{}, eventHandler, cacheService, ttls)
+	queryHandler := queries.NewProductQueryHandler(repo, discardMovements{}, cacheService, ttls, nil)
+
+	return SetupRouter(NewProductHandler(commandHandler, queryHandler), nil, nil, nil, nil, nil, "")
+}
+
+func serve(t *testing.T, router *gin.Engine, method, path string, body interface{}, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("encode body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder, target interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), target); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body.String(), err)
+	}
+}
+
+func expectStatus(t *testing.T, rec *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if rec.Code != want {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, want, rec.Body.String())
+	}
+}
+
+func TestProductLifecycle(t *testing.T) {
+	router := newTestRouter(t)
+
+	for _, cmd := range []commands.CreateProductCommand{
+		{Name: "Lamp", Description: "Desk lamp", Price: 25, Stock: 3},
+		{Name: "Chair", Description: "Office chair", Price: 120, Stock: 8},
+	} {
+		rec := serve(t, router, http.MethodPost, "/api/v1/products/", cmd, nil)
+		expectStatus(t, rec, http.StatusCreated)
+	}
+
+	rec := serve(t, router, http.MethodPost, "/api/v1/products/", commands.CreateProductCommand{Name: "Free", Price: 0}, nil)
+	expectStatus(t, rec, http.StatusUnprocessableEntity)
+
+	var list queries.ListProductsResponse
+	rec = serve(t, router, http.MethodGet, "/api/v1/products/?sort_by=price&sort_dir=desc", nil, nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &list)
+	if list.Total == nil || *list.Total != 2 || len(list.Products) != 2 || list.Products[0].Name != "Chair" {
+		t.Fatalf("list = %+v, want Chair then Lamp", list)
+	}
+	chair := list.Products[0]
+
+	var found product.Product
+	rec = serve(t, router, http.MethodGet, "/api/v1/products/"+chair.ID.Hex(), nil, nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &found)
+	if found.Name != "Chair" || found.Stock != 8 {
+		t.Errorf("found %+v, want the chair", found)
+	}
+	etag := rec.Header().Get("ETag")
+
+	// The ETag pins the version, so a second write with it loses the race
+	rec = serve(t, router, http.MethodPatch, "/api/v1/products/"+chair.ID.Hex()+"/stock", commands.UpdateStockCommand{Stock: 5}, map[string]string{"If-Match": etag})
+	expectStatus(t, rec, http.StatusOK)
+	current := rec.Header().Get("ETag")
+	rec = serve(t, router, http.MethodPatch, "/api/v1/products/"+chair.ID.Hex()+"/stock", commands.UpdateStockCommand{Stock: 4}, map[string]string{"If-Match": etag})
+	expectStatus(t, rec, http.StatusPreconditionFailed)
+
+	// If-Match compares strongly, entry by entry
+	rec = serve(t, router, http.MethodPatch, "/api/v1/products/"+chair.ID.Hex()+"/stock", commands.UpdateStockCommand{Stock: 4}, map[string]string{"If-Match": "W/" + current})
+	expectStatus(t, rec, http.StatusPreconditionFailed)
+	rec = serve(t, router, http.MethodPatch, "/api/v1/products/"+chair.ID.Hex()+"/stock", commands.UpdateStockCommand{Stock: 5}, map[string]string{"If-Match": etag + ", " + current})
+	expectStatus(t, rec, http.StatusOK)
+
+	// Writes invalidate the cached list, so the next read sees them
+	rec = serve(t, router, http.MethodGet, "/api/v1/products/?sort_by=price&sort_dir=desc", nil, nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &list)
+	if list.Products[0].Stock != 5 {
+		t.Errorf("listed stock = %d after update, want 5", list.Products[0].Stock)
+	}
+
+	var search struct {
+		Data []*product.Product `json:"data"`
+	}
+	rec = serve(t, router, http.MethodGet, "/api/v1/products/search?name=LAMP&max_price=50", nil, nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &search)
+	if len(search.Data) != 1 || search.Data[0].Name != "Lamp" {
+		t.Errorf("search = %+v, want the lamp", search.Data)
+	}
+
+	rec = serve(t, router, http.MethodDelete, "/api/v1/products/"+chair.ID.Hex(), nil, nil)
+	expectStatus(t, rec, http.StatusOK)
+	rec = serve(t, router, http.MethodGet, "/api/v1/products/"+chair.ID.Hex(), nil, nil)
+	expectStatus(t, rec, http.StatusNotFound)
+
+	rec = serve(t, router, http.MethodGet, "/api/v1/products/", nil, nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &list)
+	if list.Total == nil || *list.Total != 1 || list.Products[0].Name != "Lamp" {
+		t.Errorf("list after delete = %+v, want only the lamp", list)
+	}
+}
+
+func TestListProductsByCursor(t *testing.T) {
+	router := newTestRouter(t)
+
+	for _, cmd := range []commands.CreateProductCommand{
+		{Name: "Lamp", Price: 25, Stock: 1},
+		{Name: "Chair", Price: 120, Stock: 1},
+		{Name: "Desk", Price: 250, Stock: 1},
+		{Name: "Rug", Price: 25, Stock: 1},
+		{Name: "Shelf", Price: 80, Stock: 1},
+	} {
+		rec := serve(t, router, http.MethodPost, "/api/v1/products/", cmd, nil)
+		expectStatus(t, rec, http.StatusCreated)
+	}
+
+	// The first page asks for the order and the total; the rest only follow
+	// the cursor
+	var names []string
+	path := "/api/v1/products/?cursor=&page_size=2&sort_by=price&sort_dir=desc&include_total=true"
+	for pages := 1; ; pages++ {
+		var list queries.ListProductsResponse
+		rec := serve(t, router, http.MethodGet, path, nil, nil)
+		expectStatus(t, rec, http.StatusOK)
+		decode(t, rec, &list)
+
+		if pages == 1 && (list.Total == nil || *list.Total != 5) {
+			t.Errorf("first page total = %v, want 5", list.Total)
+		}
+		if pages > 1 && list.Total != nil {
+			t.Errorf("page %d counted products without being asked to", pages)
+		}
+		for _, p := range list.Products {
+			names = append(names, p.Name)
+		}
+
+		if list.NextCursor == "" {
+			if pages != 3 {
+				t.Errorf("listing ended after %d pages, want 3", pages)
+			}
+			break
+		}
+		if pages == 3 {
+			t.Fatal("last page still has a next cursor")
+		}
+		path = "/api/v1/products/?page_size=2&cursor=" + url.QueryEscape(list.NextCursor)
+	}
+
+	// Lamp and Rug tie on price and keep the order of their IDs, reversed
+	want := "[Desk Chair Shelf Rug Lamp]"
+	if got := fmt.Sprint(names); got != want {
+		t.Errorf("products = %s, want %s", got, want)
+	}
+
+	rec := serve(t, router, http.MethodGet, "/api/v1/products/?cursor=forged.token", nil, nil)
+	expectStatus(t, rec, http.StatusBadRequest)
+}
+
+func TestPatchProductMediaTypes(t *testing.T) {
+	router := newTestRouter(t)
+
+	rec := serve(t, router, http.MethodPost, "/api/v1/products/", commands.CreateProductCommand{Name: "Lamp", Price: 25, Stock: 3}, nil)
+	expectStatus(t, rec, http.StatusCreated)
+
+	var list queries.ListProductsResponse
+	rec = serve(t, router, http.MethodGet, "/api/v1/products/", nil, nil)
+	expectStatus(t, rec, http.StatusOK)
+	decode(t, rec, &list)
+	path := "/api/v1/products/" + list.Products[0].ID.Hex()
+
+	tests := []struct {
+		contentType string
+		want        int
+	}{
+		{"application/merge-patch+json", http.StatusOK},
+		{"application/merge-patch+json; charset=utf-8", http.StatusOK},
+		{"application/json", http.StatusOK},
+		{"application/json-patch+json", http.StatusUnsupportedMediaType},
+		{"text/plain", http.StatusUnsupportedMediaType},
+		{"", http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		rec := serve(t, router, http.MethodPatch, path, map[string]interface{}{"description": "Desk lamp"}, map[string]string{"Content-Type": tt.contentType})
+		if rec.Code != tt.want {
+			t.Errorf("Content-Type %q: status = %d, want %d: %s", tt.contentType, rec.Code, tt.want, rec.Body.String())
+		}
+		if tt.want == http.StatusUnsupportedMediaType && rec.Header().Get("Accept-Patch") != "application/merge-patch+json" {
+			t.Errorf("Content-Type %q: Accept-Patch = %q", tt.contentType, rec.Header().Get("Accept-Patch"))
+		}
+	}
+}
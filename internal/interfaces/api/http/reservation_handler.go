@@ -0,0 +1,146 @@
+package http
+
+import (
+	stderrors "errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go-microservice-product-porto/internal/application/commands"
+	"go-microservice-product-porto/internal/application/queries"
+	"go-microservice-product-porto/pkg/errors"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+var errReservationIDRequired = stderrors.New("reservation id is required")
+
+type ReservationHandler struct {
+	commandHandler *commands.ReservationCommandHandler
+	queryHandler   *queries.ReservationQueryHandler
+}
+
+func NewReservationHandler(commandHandler *commands.ReservationCommandHandler, queryHandler *queries.ReservationQueryHandler) *ReservationHandler {
+	return &ReservationHandler{
+		commandHandler: commandHandler,
+		queryHandler:   queryHandler,
+	}
+}
+
+func (h *ReservationHandler) CreateReservation(c *gin.Context) {
+	logger.Info().
+		Str("handler", "CreateReservation").
+		Msg("Creating a new reservation")
+
+	var cmd commands.CreateReservationCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		logger.Error().
+			Str("handler", "CreateReservation").
+			Err(err).
+			Msg("Error binding JSON")
+
+		respondError(c, errors.StandardError(errors.EBADREQUEST, err))
+		return
+	}
+
+	reservation, err := h.commandHandler.HandleCreateReservation(c.Request.Context(), cmd)
+	if err != nil {
+		logger.Error().
+			Str("handler", "CreateReservation").
+			Err(err).
+			Msg("Error handling create reservation command")
+
+		respondError(c, err)
+		return
+	}
+
+	logger.Info().
+		Str("handler", "CreateReservation").
+		Msg("Reservation created successfully")
+
+	c.JSON(http.StatusCreated, reservation)
+}
+
+func (h *ReservationHandler) GetReservation(c *gin.Context) {
+	logger.Info().
+		Str("handler", "GetReservation").
+		Msg("Fetching reservation details")
+
+	reservationID := c.Param("id")
+	if reservationID == "" {
+		respondError(c, errors.StandardError(errors.EBADREQUEST, errReservationIDRequired))
+		return
+	}
+
+	reservation, err := h.queryHandler.HandleGetReservation(c.Request.Context(), queries.GetReservationQuery{ID: reservationID})
+	if err != nil {
+		logger.Error().
+			Str("handler", "GetReservation").
+			Err(err).
+			Msg("Error fetching reservation details")
+
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+func (h *ReservationHandler) ConfirmReservation(c *gin.Context) {
+	logger.Info().
+		Str("handler", "ConfirmReservation").
+		Msg("Confirming reservation")
+
+	reservationID := c.Param("id")
+	if reservationID == "" {
+		respondError(c, errors.StandardError(errors.EBADREQUEST, errReservationIDRequired))
+		return
+	}
+
+	cmd := commands.ConfirmReservationCommand{ReservationID: reservationID}
+	reservation, err := h.commandHandler.HandleConfirmReservation(c.Request.Context(), cmd)
+	if err != nil {
+		logger.Error().
+			Str("handler", "ConfirmReservation").
+			Err(err).
+			Msg("Error confirming reservation")
+
+		respondError(c, err)
+		return
+	}
+
+	logger.Info().
+		Str("handler", "ConfirmReservation").
+		Msg("Reservation confirmed successfully")
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+func (h *ReservationHandler) ReleaseReservation(c *gin.Context) {
+	logger.Info().
+		Str("handler", "ReleaseReservation").
+		Msg("Releasing reservation")
+
+	reservationID := c.Param("id")
+	if reservationID == "" {
+		respondError(c, errors.StandardError(errors.EBADREQUEST, errReservationIDRequired))
+		return
+	}
+
+	cmd := commands.ReleaseReservationCommand{ReservationID: reservationID}
+	reservation, err := h.commandHandler.HandleReleaseReservation(c.Request.Context(), cmd)
+	if err != nil {
+		logger.Error().
+			Str("handler", "ReleaseReservation").
+			Err(err).
+			Msg("Error releasing reservation")
+
+		respondError(c, err)
+		return
+	}
+
+	logger.Info().
+		Str("handler", "ReleaseReservation").
+		Msg("Reservation released successfully")
+
+	c.JSON(http.StatusOK, reservation)
+}
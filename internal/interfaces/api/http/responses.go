@@ -1,9 +1,19 @@
 package http
 
-import "go-microservice-product-porto/pkg/logger"
+import (
+	"go-microservice-product-porto/pkg/errors"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+type ErrorBody struct {
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
 
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error ErrorBody `json:"error"`
 }
 
 type SuccessResponse struct {
@@ -11,14 +21,28 @@ type SuccessResponse struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
-func NewErrorResponse(err error) ErrorResponse {
+// NewErrorResponse builds the error body for appErr. The underlying cause is
+// only exposed for client errors so server failures don't leak internals.
+func NewErrorResponse(appErr *errors.AppError, status int, requestID string) ErrorResponse {
 	logger.Error().
-		Err(err).
+		Err(appErr).
+		Str("code", appErr.Code).
+		Int("status", status).
+		Str("request_id", requestID).
 		Msg("Error occurred")
 
-	return ErrorResponse{
-		Error: err.Error(),
+	body := ErrorBody{
+		Code:      appErr.Code,
+		Message:   appErr.Message,
+		RequestID: requestID,
 	}
+	if len(appErr.Fields) > 0 {
+		body.Details = appErr.Fields
+	} else if status < 500 && appErr.Err != nil {
+		body.Details = appErr.Err.Error()
+	}
+
+	return ErrorResponse{Error: body}
 }
 
 func NewSuccessResponse(message string, data interface{}) SuccessResponse {
@@ -4,11 +4,15 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter(handler *ProductHandler) *gin.Engine {
+// SetupRouter serves every API whose handler is given. A nil handler leaves
+// its routes out, for features the service was started without.
+func SetupRouter(handler *ProductHandler, reservationHandler *ReservationHandler, locationHandler *LocationHandler, webhookHandler *WebhookHandler, eventStreamHandler *EventStreamHandler, cacheAdminHandler *CacheAdminHandler, adminToken string) *gin.Engine {
 	router := gin.Default()
 
 	// Middleware
+	router.Use(RequestIDMiddleware())
 	router.Use(CORSMiddleware())
+	router.Use(ActorMiddleware())
 	router.Use(LoggerMiddleware())
 
 	// API routes
@@ -18,14 +22,61 @@ func SetupRouter(handler *ProductHandler) *gin.Engine {
 		{
 			// Add this new route
 			products.GET("/search", handler.SearchProducts)
+			if eventStreamHandler != nil {
+				products.GET("/events", eventStreamHandler.StreamProductEvents)
+			}
 
 			// Existing routes remain unchanged
 			products.POST("/", handler.CreateProduct)
 			products.GET("/", handler.ListProducts)
 			products.GET("/:id", handler.GetProduct)
+			products.PUT("/:id", handler.UpdateProduct)
+			products.PATCH("/:id", handler.PatchProduct)
 			products.PATCH("/:id/stock", handler.UpdateStock)
+			products.POST("/:id/stock/adjustments", handler.AdjustStock)
+			products.POST("/:id/stock/transfers", handler.TransferStock)
+			products.GET("/:id/stock/movements", handler.ListStockMovements)
 			products.DELETE("/:id", handler.DeleteProduct)
 		}
+
+		if reservationHandler != nil {
+			reservations := v1.Group("/reservations")
+			reservations.POST("/", reservationHandler.CreateReservation)
+			reservations.GET("/:id", reservationHandler.GetReservation)
+			reservations.POST("/:id/confirm", reservationHandler.ConfirmReservation)
+			reservations.POST("/:id/release", reservationHandler.ReleaseReservation)
+		}
+
+		if locationHandler != nil {
+			locations := v1.Group("/locations")
+			locations.POST("/", locationHandler.CreateLocation)
+			locations.GET("/", locationHandler.ListLocations)
+			locations.GET("/:id", locationHandler.GetLocation)
+			locations.PUT("/:id", locationHandler.UpdateLocation)
+			locations.DELETE("/:id", locationHandler.DeleteLocation)
+		}
+
+		if webhookHandler != nil {
+			// Subscriptions hold signing secrets and choose where the
+			// service sends requests, so only operators may manage them
+			webhooks := v1.Group("/webhooks", AdminAuthMiddleware(adminToken))
+			webhooks.POST("/", webhookHandler.CreateWebhook)
+			webhooks.GET("/", webhookHandler.ListWebhooks)
+			webhooks.GET("/:id", webhookHandler.GetWebhook)
+			webhooks.PUT("/:id", webhookHandler.UpdateWebhook)
+			webhooks.DELETE("/:id", webhookHandler.DeleteWebhook)
+			webhooks.GET("/:id/deliveries", webhookHandler.ListWebhookDeliveries)
+			webhooks.POST("/:id/deliveries/:delivery_id/redeliver", webhookHandler.RedeliverWebhook)
+		}
+
+		if cacheAdminHandler != nil {
+			admin := v1.Group("/admin", AdminAuthMiddleware(adminToken))
+			admin.GET("/cache/stats", cacheAdminHandler.GetCacheStats)
+			admin.GET("/cache/keys/:key", cacheAdminHandler.InspectCacheKey)
+			admin.DELETE("/cache/keys", cacheAdminHandler.FlushCachePrefix)
+			admin.DELETE("/cache/tags", cacheAdminHandler.FlushCacheTags)
+			admin.POST("/cache/warm", cacheAdminHandler.WarmCache)
+		}
 	}
 
 	return router
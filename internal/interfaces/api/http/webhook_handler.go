@@ -0,0 +1,280 @@
+package http
+
+import (
+	stderrors "errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"go-microservice-product-porto/internal/application/commands"
+	"go-microservice-product-porto/internal/application/queries"
+	"go-microservice-product-porto/internal/domain/webhook"
+	"go-microservice-product-porto/pkg/common"
+	"go-microservice-product-porto/pkg/errors"
+	"go-microservice-product-porto/pkg/logger"
+)
+
+var (
+	errWebhookIDRequired     = stderrors.New("webhook id is required")
+	errDeliveryIDRequired    = stderrors.New("delivery id is required")
+	errInvalidDeliveryStatus = stderrors.New("status must be one of pending, delivered, dead")
+)
+
+type WebhookHandler struct {
+	commandHandler *commands.WebhookCommandHandler
+	queryHandler   *queries.WebhookQueryHandler
+}
+
+func NewWebhookHandler(commandHandler *commands.WebhookCommandHandler, queryHandler *queries.WebhookQueryHandler) *WebhookHandler {
+	return &WebhookHandler{
+		commandHandler: commandHandler,
+		queryHandler:   queryHandler,
+	}
+}
+
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	logger.Info().
+		Str("handler", "CreateWebhook").
+		Msg("Creating a new webhook subscription")
+
+	var cmd commands.CreateWebhookCommand
+	if err := c.ShouldBindJSON(&cmd); err != nil {
+		logger.Error().
+			Str("handler", "CreateWebhook").
+			Err(err).
+			Msg("Error binding JSON")
+
+		respondError(c, errors.StandardError(errors.EBADREQUEST, err))
+		return
+	}
+
+	sub, err := h.commandHandler.HandleCreateWebhook(c.Request.Context(), cmd)
+	if err != nil {
+		logger.Error().
+			Str("handler", "CreateWebhook").
+			Err(err).
+			Msg("Error handling create webhook command")
+
+		respondError(c, err)
+		return
+	}
+
+	logger.Info().
+		Str("handler", "CreateWebhook").
+		Msg("Webhook subscription created successfully")
+
+	// The secret is only ever revealed here
+	c.JSON(http.StatusCreated, struct {
+		*webhook.Subscription
+		Secret string `json:"secret"`
+	}{sub, sub.Secret})
+}
+
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	logger.Info().
+		Str("handler", "ListWebhooks").
+		Msg("Fetching list of webhook subscriptions")
+
+	subscriptions, err := h.queryHandler.HandleListWebhooks(c.Request.Context(), queries.ListWebhooksQuery{})
+	if err != nil {
+		logger.Error().
+			Str("handler", "ListWebhooks").
+			Err(err).
+			Msg("Error fetching list of webhook subscriptions")
+
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": subscriptions})
+}
+
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	logger.Info().
+		Str("handler", "GetWebhook").
+		Msg("Fetching webhook subscription details")
+
+	subscriptionID := c.Param("id")
+	if subscriptionID == "" {
+		respondError(c, errors.StandardError(errors.EBADREQUEST, errWebhookIDRequired))
+		return
+	}
+
+	sub, err := h.queryHandler.HandleGetWebhook(c.Request.Context(), queries.GetWebhookQuery{ID: subscriptionID})
+	if err != nil {
+		logger.Error().
+			Str("handler", "GetWebhook").
+			Err(err).
+			Msg("Error fetching webhook subscription details")
+
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	logger.Info().
+		Str("handler", "UpdateWebhook").
+		Msg("Updating webhook subscription")
+
+	subscriptionID := c.Param("id")
+	if subscriptionID == "" {
+		respondError(c, errors.StandardError(errors.EBADREQUEST, errWebhookIDRequired))
+		return
+	}
+
+	var request struct {
+		URL        string   `json:"url"`
+		EventTypes []string `json:"event_types"`
+		Secret     string   `json:"secret"`
+		Active     *bool    `json:"active"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		logger.Error().
+			Str("handler", "UpdateWebhook").
+			Err(err).
+			Msg("Error binding JSON")
+
+		respondError(c, errors.StandardError(errors.EBADREQUEST, err))
+		return
+	}
+
+	// Subscriptions stay active unless explicitly switched off
+	active := true
+	if request.Active != nil {
+		active = *request.Active
+	}
+
+	cmd := commands.UpdateWebhookCommand{
+		SubscriptionID: subscriptionID,
+		URL:            request.URL,
+		EventTypes:     request.EventTypes,
+		Secret:         request.Secret,
+		Active:         active,
+	}
+
+	sub, err := h.commandHandler.HandleUpdateWebhook(c.Request.Context(), cmd)
+	if err != nil {
+		logger.Error().
+			Str("handler", "UpdateWebhook").
+			Err(err).
+			Msg("Error handling update webhook command")
+
+		respondError(c, err)
+		return
+	}
+
+	logger.Info().
+		Str("handler", "UpdateWebhook").
+		Msg("Webhook subscription updated successfully")
+
+	c.JSON(http.StatusOK, sub)
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	logger.Info().
+		Str("handler", "DeleteWebhook").
+		Msg("Deleting webhook subscription")
+
+	subscriptionID := c.Param("id")
+	if subscriptionID == "" {
+		respondError(c, errors.StandardError(errors.EBADREQUEST, errWebhookIDRequired))
+		return
+	}
+
+	cmd := commands.DeleteWebhookCommand{SubscriptionID: subscriptionID}
+	if err := h.commandHandler.HandleDeleteWebhook(c.Request.Context(), cmd); err != nil {
+		logger.Error().
+			Str("handler", "DeleteWebhook").
+			Err(err).
+			Msg("Error deleting webhook subscription")
+
+		respondError(c, err)
+		return
+	}
+
+	logger.Info().
+		Str("handler", "DeleteWebhook").
+		Msg("Webhook subscription deleted successfully")
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook subscription deleted successfully"})
+}
+
+func (h *WebhookHandler) ListWebhookDeliveries(c *gin.Context) {
+	logger.Info().
+		Str("handler", "ListWebhookDeliveries").
+		Msg("Fetching webhook delivery log")
+
+	subscriptionID := c.Param("id")
+	if subscriptionID == "" {
+		respondError(c, errors.StandardError(errors.EBADREQUEST, errWebhookIDRequired))
+		return
+	}
+
+	status := webhook.DeliveryStatus(c.Query("status"))
+	switch status {
+	case "", webhook.DeliveryPending, webhook.DeliveryDelivered, webhook.DeliveryDead:
+	default:
+		respondError(c, errors.StandardError(errors.EBADREQUEST, errInvalidDeliveryStatus))
+		return
+	}
+
+	query := queries.ListWebhookDeliveriesQuery{
+		SubscriptionID: subscriptionID,
+		Status:         status,
+		Page:           common.ParseInt(c.DefaultQuery("page", "1")),
+		PageSize:       common.ParseInt(c.DefaultQuery("page_size", "20")),
+	}
+
+	result, err := h.queryHandler.HandleListWebhookDeliveries(c.Request.Context(), query)
+	if err != nil {
+		logger.Error().
+			Str("handler", "ListWebhookDeliveries").
+			Err(err).
+			Msg("Error fetching webhook delivery log")
+
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *WebhookHandler) RedeliverWebhook(c *gin.Context) {
+	logger.Info().
+		Str("handler", "RedeliverWebhook").
+		Msg("Redelivering webhook")
+
+	subscriptionID := c.Param("id")
+	if subscriptionID == "" {
+		respondError(c, errors.StandardError(errors.EBADREQUEST, errWebhookIDRequired))
+		return
+	}
+
+	deliveryID := c.Param("delivery_id")
+	if deliveryID == "" {
+		respondError(c, errors.StandardError(errors.EBADREQUEST, errDeliveryIDRequired))
+		return
+	}
+
+	cmd := commands.RedeliverWebhookCommand{SubscriptionID: subscriptionID, DeliveryID: deliveryID}
+	delivery, err := h.commandHandler.HandleRedeliverWebhook(c.Request.Context(), cmd)
+	if err != nil {
+		logger.Error().
+			Str("handler", "RedeliverWebhook").
+			Err(err).
+			Msg("Error redelivering webhook")
+
+		respondError(c, err)
+		return
+	}
+
+	logger.Info().
+		Str("handler", "RedeliverWebhook").
+		Msg("Webhook delivery requeued successfully")
+
+	c.JSON(http.StatusAccepted, delivery)
+}
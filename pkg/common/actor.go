@@ -0,0 +1,21 @@
+package common
+
+import "context"
+
+// AnonymousActor identifies changes made by callers that didn't identify themselves.
+const AnonymousActor = "anonymous"
+
+type actorKey struct{}
+
+// WithActor records who is performing the current operation.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor stored by WithActor, or AnonymousActor.
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return AnonymousActor
+}
@@ -1,6 +1,9 @@
 package common
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // FormatDateTime formats a time.Time object into a string in the format "DD-Mon-YYYY HH:mm:ss"
 func FormatDateTime(t time.Time) string {
@@ -16,3 +19,21 @@ func FormatDate(t time.Time) string {
 func FormatTime(t time.Time) string {
 	return t.Format("15:04:05")
 }
+
+// ParseDateTime accepts either an RFC 3339 timestamp or a YYYY-MM-DD date.
+// A bare date means the start of that day, or its last instant when endOfDay
+// is set, so that date ranges are inclusive.
+func ParseDateTime(value string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: expected RFC 3339 or YYYY-MM-DD", value)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
@@ -0,0 +1,44 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MergePatch applies an RFC 7396 JSON Merge Patch to target and returns the
+// resulting document.
+func MergePatch(target, patch []byte) ([]byte, error) {
+	var targetDoc, patchDoc interface{}
+	if len(target) > 0 {
+		if err := json.Unmarshal(target, &targetDoc); err != nil {
+			return nil, fmt.Errorf("invalid target document: %v", err)
+		}
+	}
+	if err := json.Unmarshal(patch, &patchDoc); err != nil {
+		return nil, fmt.Errorf("invalid merge patch: %v", err)
+	}
+
+	return json.Marshal(mergeValue(targetDoc, patchDoc))
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = mergeValue(targetObj[key], value)
+	}
+
+	return targetObj
+}
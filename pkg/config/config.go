@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
@@ -8,17 +10,118 @@ type Config struct {
 	// Server
 	ServerAddress string `mapstructure:"SERVER_ADDRESS"`
 
-	// MongoDB
-	MongoHost     string `mapstructure:"MONGO_HOST"`
-	MongoPort     string `mapstructure:"MONGO_PORT"`
-	MongoUser     string `mapstructure:"MONGO_USER"`
-	MongoPassword string `mapstructure:"MONGO_PASSWORD"`
-	MongoDBName   string `mapstructure:"MONGO_DB_NAME"`
+	// Product storage
+	ProductBackend string `mapstructure:"PRODUCT_BACKEND"`
+
+	// MongoDB. MONGODB_URI, when set, replaces host, port and credentials.
+	// It holds reservations, locations, webhooks and the outbox, so without
+	// it the service only serves products; see UsesMongo.
+	MongoURI                    string        `mapstructure:"MONGODB_URI"`
+	MongoHost                   string        `mapstructure:"MONGO_HOST"`
+	MongoPort                   string        `mapstructure:"MONGO_PORT"`
+	MongoUser                   string        `mapstructure:"MONGO_USER"`
+	MongoPassword               string        `mapstructure:"MONGO_PASSWORD"`
+	MongoDBName                 string        `mapstructure:"MONGO_DB_NAME"`
+	MongoAuthSource             string        `mapstructure:"MONGO_AUTH_SOURCE"`
+	MongoProductsCollection     string        `mapstructure:"MONGO_PRODUCTS_COLLECTION"`
+	MongoReplicaSet             string        `mapstructure:"MONGO_REPLICA_SET"`
+	MongoReadPreference         string        `mapstructure:"MONGO_READ_PREFERENCE"`
+	MongoDirect                 bool          `mapstructure:"MONGO_DIRECT"`
+	MongoTLS                    bool          `mapstructure:"MONGO_TLS"`
+	MongoTLSCAFile              string        `mapstructure:"MONGO_TLS_CA_FILE"`
+	MongoTLSCertFile            string        `mapstructure:"MONGO_TLS_CERT_FILE"`
+	MongoTLSKeyFile             string        `mapstructure:"MONGO_TLS_KEY_FILE"`
+	MongoTLSInsecure            bool          `mapstructure:"MONGO_TLS_INSECURE"`
+	MongoMinPoolSize            uint64        `mapstructure:"MONGO_MIN_POOL_SIZE"`
+	MongoMaxPoolSize            uint64        `mapstructure:"MONGO_MAX_POOL_SIZE"`
+	MongoMaxConnIdleTime        time.Duration `mapstructure:"MONGO_MAX_CONN_IDLE_TIME"`
+	MongoConnectTimeout         time.Duration `mapstructure:"MONGO_CONNECT_TIMEOUT"`
+	MongoServerSelectionTimeout time.Duration `mapstructure:"MONGO_SERVER_SELECTION_TIMEOUT"`
+	MongoTimeout                time.Duration `mapstructure:"MONGO_TIMEOUT"`
+	// MongoEnsureSchema builds missing indexes and applies the products
+	// validator on startup. It also builds the reservation, outbox and
+	// webhook delivery indexes.
+	MongoEnsureSchema bool `mapstructure:"MONGO_ENSURE_SCHEMA"`
+
+	// PostgreSQL
+	DBHost     string `mapstructure:"DB_HOST"`
+	DBPort     string `mapstructure:"DB_PORT"`
+	DBUser     string `mapstructure:"DB_USER"`
+	DBPassword string `mapstructure:"DB_PASSWORD"`
+	DBName     string `mapstructure:"DB_NAME"`
+	DBSSLMode  string `mapstructure:"DB_SSLMODE"`
+	DBTimeZone string `mapstructure:"DB_TIMEZONE"`
+
+	// SQLite
+	SQLitePath        string        `mapstructure:"SQLITE_PATH"`
+	SQLiteBusyTimeout time.Duration `mapstructure:"SQLITE_BUSY_TIMEOUT"`
 
 	// Redis
 	RedisHost     string `mapstructure:"REDIS_HOST"`
 	RedisPort     string `mapstructure:"REDIS_PORT"`
 	RedisPassword string `mapstructure:"REDIS_PASSWORD"`
+
+	// Cache
+	CacheBackend              string        `mapstructure:"CACHE_BACKEND"`
+	CacheLocalMaxEntries      int           `mapstructure:"CACHE_LOCAL_MAX_ENTRIES"`
+	CacheLocalTTL             time.Duration `mapstructure:"CACHE_LOCAL_TTL"`
+	CacheInvalidationChannel  string        `mapstructure:"CACHE_INVALIDATION_CHANNEL"`
+	CacheBreakerThreshold     int           `mapstructure:"CACHE_BREAKER_THRESHOLD"`
+	CacheBreakerRetryInterval time.Duration `mapstructure:"CACHE_BREAKER_RETRY_INTERVAL"`
+	CacheCodec                string        `mapstructure:"CACHE_CODEC"`
+	CacheDefaultTTL           time.Duration `mapstructure:"CACHE_DEFAULT_TTL"`
+	CacheProductTTL           time.Duration `mapstructure:"CACHE_PRODUCT_TTL"`
+	CacheListTTL              time.Duration `mapstructure:"CACHE_LIST_TTL"`
+	CacheSearchTTL            time.Duration `mapstructure:"CACHE_SEARCH_TTL"`
+	CacheStaleTTL             time.Duration `mapstructure:"CACHE_STALE_TTL"`
+
+	// Cache warm-up
+	CacheWarmOnStart        bool     `mapstructure:"CACHE_WARM_ON_START"`
+	CacheWarmRecentProducts int      `mapstructure:"CACHE_WARM_RECENT_PRODUCTS"`
+	CacheWarmSorts          []string `mapstructure:"CACHE_WARM_SORTS"`
+	CacheWarmPages          int      `mapstructure:"CACHE_WARM_PAGES"`
+	CacheWarmPageSize       int      `mapstructure:"CACHE_WARM_PAGE_SIZE"`
+
+	// Admin. The admin and webhook endpoints refuse every request while the
+	// token is empty.
+	AdminToken string `mapstructure:"ADMIN_TOKEN"`
+
+	// Listing. CursorSecret signs list cursors and must be shared by every
+	// instance behind the same address, so it is required with the "nats"
+	// event bus. Left empty, each instance signs with a random key.
+	CursorSecret string `mapstructure:"CURSOR_SECRET"`
+
+	// Reservations
+	ReservationTTL           time.Duration `mapstructure:"RESERVATION_TTL"`
+	ReservationSweepInterval time.Duration `mapstructure:"RESERVATION_SWEEP_INTERVAL"`
+
+	// Outbox
+	OutboxPollInterval time.Duration `mapstructure:"OUTBOX_POLL_INTERVAL"`
+	OutboxMaxAttempts  int           `mapstructure:"OUTBOX_MAX_ATTEMPTS"`
+
+	// Event bus. The event stream relies on it to see other instances'
+	// events, so anything but a single instance needs "nats".
+	EventBus          string `mapstructure:"EVENT_BUS"`
+	EventSource       string `mapstructure:"EVENT_SOURCE"`
+	NatsURL           string `mapstructure:"NATS_URL"`
+	NatsSubjectPrefix string `mapstructure:"NATS_SUBJECT_PREFIX"`
+
+	// Event stream
+	EventStreamReplaySize int           `mapstructure:"EVENT_STREAM_REPLAY_SIZE"`
+	EventStreamHeartbeat  time.Duration `mapstructure:"EVENT_STREAM_HEARTBEAT"`
+
+	// Webhooks
+	WebhookWorkers      int           `mapstructure:"WEBHOOK_WORKERS"`
+	WebhookPollInterval time.Duration `mapstructure:"WEBHOOK_POLL_INTERVAL"`
+	WebhookMaxAttempts  int           `mapstructure:"WEBHOOK_MAX_ATTEMPTS"`
+	WebhookTimeout      time.Duration `mapstructure:"WEBHOOK_TIMEOUT"`
+}
+
+// UsesMongo reports whether MongoDB is part of the deployment: it stores the
+// products, or it was given an address. A memory, SQLite or PostgreSQL
+// product store without one needs no database server at all.
+func (c *Config) UsesMongo() bool {
+	return c.ProductBackend == "mongodb" || c.MongoURI != "" || c.MongoHost != ""
 }
 
 func LoadConfig() (*Config, error) {
@@ -40,18 +143,84 @@ func LoadConfig() (*Config, error) {
 		}
 	}
 
-	err := viper.Unmarshal(config)
-	return config, err
+	if err := viper.Unmarshal(config); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
 }
 
 func setDefaults() {
 	viper.SetDefault("SERVER_ADDRESS", ":8001")
-	viper.SetDefault("MONGO_HOST", "localhost")
+	viper.SetDefault("PRODUCT_BACKEND", "mongodb")
+	viper.SetDefault("MONGODB_URI", "")
+	viper.SetDefault("MONGO_HOST", "")
 	viper.SetDefault("MONGO_PORT", "27017")
 	viper.SetDefault("MONGO_USER", "")
 	viper.SetDefault("MONGO_PASSWORD", "")
-	viper.SetDefault("MONGO_DB_NAME", "products")
+	viper.SetDefault("MONGO_DB_NAME", "")
+	viper.SetDefault("MONGO_AUTH_SOURCE", "")
+	viper.SetDefault("MONGO_PRODUCTS_COLLECTION", "products")
+	viper.SetDefault("MONGO_REPLICA_SET", "")
+	viper.SetDefault("MONGO_READ_PREFERENCE", "")
+	viper.SetDefault("MONGO_DIRECT", false)
+	viper.SetDefault("MONGO_TLS", false)
+	viper.SetDefault("MONGO_TLS_CA_FILE", "")
+	viper.SetDefault("MONGO_TLS_CERT_FILE", "")
+	viper.SetDefault("MONGO_TLS_KEY_FILE", "")
+	viper.SetDefault("MONGO_TLS_INSECURE", false)
+	viper.SetDefault("MONGO_MIN_POOL_SIZE", 0)
+	viper.SetDefault("MONGO_MAX_POOL_SIZE", 0)
+	viper.SetDefault("MONGO_MAX_CONN_IDLE_TIME", "0s")
+	viper.SetDefault("MONGO_CONNECT_TIMEOUT", "10s")
+	viper.SetDefault("MONGO_SERVER_SELECTION_TIMEOUT", "10s")
+	viper.SetDefault("MONGO_TIMEOUT", "0s")
+	viper.SetDefault("MONGO_ENSURE_SCHEMA", true)
+	viper.SetDefault("DB_HOST", "localhost")
+	viper.SetDefault("DB_PORT", "5432")
+	viper.SetDefault("DB_USER", "postgres")
+	viper.SetDefault("DB_PASSWORD", "")
+	viper.SetDefault("DB_NAME", "product_db")
+	viper.SetDefault("DB_SSLMODE", "disable")
+	viper.SetDefault("DB_TIMEZONE", "UTC")
+	viper.SetDefault("SQLITE_PATH", "products.db")
+	viper.SetDefault("SQLITE_BUSY_TIMEOUT", "5s")
 	viper.SetDefault("REDIS_HOST", "localhost")
 	viper.SetDefault("REDIS_PORT", "6379")
 	viper.SetDefault("REDIS_PASSWORD", "")
+	viper.SetDefault("CACHE_BACKEND", "tiered")
+	viper.SetDefault("CACHE_LOCAL_MAX_ENTRIES", 10000)
+	viper.SetDefault("CACHE_LOCAL_TTL", "30s")
+	viper.SetDefault("CACHE_INVALIDATION_CHANNEL", "cache:invalidations")
+	viper.SetDefault("CACHE_BREAKER_THRESHOLD", 5)
+	viper.SetDefault("CACHE_BREAKER_RETRY_INTERVAL", "5s")
+	viper.SetDefault("CACHE_CODEC", "json")
+	viper.SetDefault("CACHE_DEFAULT_TTL", "1h")
+	viper.SetDefault("CACHE_PRODUCT_TTL", "1h")
+	viper.SetDefault("CACHE_LIST_TTL", "1m")
+	viper.SetDefault("CACHE_SEARCH_TTL", "1m")
+	viper.SetDefault("CACHE_STALE_TTL", "30s")
+	viper.SetDefault("CACHE_WARM_ON_START", true)
+	viper.SetDefault("CACHE_WARM_RECENT_PRODUCTS", 100)
+	viper.SetDefault("CACHE_WARM_SORTS", "created_at:desc,name:asc,price:asc")
+	viper.SetDefault("CACHE_WARM_PAGES", 3)
+	viper.SetDefault("CACHE_WARM_PAGE_SIZE", 10)
+	viper.SetDefault("ADMIN_TOKEN", "")
+	viper.SetDefault("CURSOR_SECRET", "")
+	viper.SetDefault("RESERVATION_TTL", "15m")
+	viper.SetDefault("RESERVATION_SWEEP_INTERVAL", "30s")
+	viper.SetDefault("OUTBOX_POLL_INTERVAL", "1s")
+	viper.SetDefault("OUTBOX_MAX_ATTEMPTS", 10)
+	viper.SetDefault("EVENT_BUS", "memory")
+	viper.SetDefault("EVENT_SOURCE", "/product-service")
+	viper.SetDefault("NATS_URL", "nats://localhost:4222")
+	viper.SetDefault("NATS_SUBJECT_PREFIX", "products")
+	viper.SetDefault("EVENT_STREAM_REPLAY_SIZE", 1000)
+	viper.SetDefault("EVENT_STREAM_HEARTBEAT", "15s")
+	viper.SetDefault("WEBHOOK_WORKERS", 4)
+	viper.SetDefault("WEBHOOK_POLL_INTERVAL", "1s")
+	viper.SetDefault("WEBHOOK_MAX_ATTEMPTS", 8)
+	viper.SetDefault("WEBHOOK_TIMEOUT", "10s")
 }
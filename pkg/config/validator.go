@@ -3,13 +3,129 @@ package config
 import "fmt"
 
 func (c *Config) Validate() error {
-	if c.MongoDBName == "" {
-		return fmt.Errorf("MONGO_DB_NAME is required")
-	}
-
 	if c.ServerAddress == "" {
 		return fmt.Errorf("SERVER_ADDRESS is required")
 	}
 
+	// MongoDB needs no settings of its own: the database defaults to the
+	// one in MONGODB_URI, then to products_db
+	switch c.ProductBackend {
+	case "mongodb", "memory":
+	case "postgres":
+		if c.DBHost == "" || c.DBName == "" {
+			return fmt.Errorf("DB_HOST and DB_NAME are required when PRODUCT_BACKEND is postgres")
+		}
+	case "sqlite":
+		if c.SQLitePath == "" {
+			return fmt.Errorf("SQLITE_PATH is required when PRODUCT_BACKEND is sqlite")
+		}
+	default:
+		return fmt.Errorf("PRODUCT_BACKEND must be one of mongodb, postgres, sqlite, memory")
+	}
+
+	switch c.CacheBackend {
+	case "memory":
+	case "redis", "tiered":
+		if c.RedisHost == "" {
+			return fmt.Errorf("REDIS_HOST is required when CACHE_BACKEND is %s", c.CacheBackend)
+		}
+	default:
+		return fmt.Errorf("CACHE_BACKEND must be one of memory, redis, tiered")
+	}
+
+	if c.CacheBackend == "tiered" {
+		if c.CacheLocalTTL <= 0 {
+			return fmt.Errorf("CACHE_LOCAL_TTL must be positive when CACHE_BACKEND is tiered")
+		}
+		if c.CacheInvalidationChannel == "" {
+			return fmt.Errorf("CACHE_INVALIDATION_CHANNEL is required when CACHE_BACKEND is tiered")
+		}
+	}
+
+	if c.CacheLocalMaxEntries < 0 {
+		return fmt.Errorf("CACHE_LOCAL_MAX_ENTRIES must not be negative")
+	}
+
+	if c.CacheBreakerThreshold <= 0 {
+		return fmt.Errorf("CACHE_BREAKER_THRESHOLD must be positive")
+	}
+
+	if c.CacheBreakerRetryInterval <= 0 {
+		return fmt.Errorf("CACHE_BREAKER_RETRY_INTERVAL must be positive")
+	}
+
+	switch c.CacheCodec {
+	case "json", "msgpack", "gob":
+	default:
+		return fmt.Errorf("CACHE_CODEC must be one of json, msgpack, gob")
+	}
+
+	if c.CacheDefaultTTL <= 0 || c.CacheProductTTL <= 0 || c.CacheListTTL <= 0 || c.CacheSearchTTL <= 0 {
+		return fmt.Errorf("CACHE_DEFAULT_TTL, CACHE_PRODUCT_TTL, CACHE_LIST_TTL and CACHE_SEARCH_TTL must be positive")
+	}
+
+	if c.CacheStaleTTL < 0 {
+		return fmt.Errorf("CACHE_STALE_TTL must not be negative")
+	}
+
+	if c.ReservationTTL <= 0 {
+		return fmt.Errorf("RESERVATION_TTL must be positive")
+	}
+
+	if c.ReservationSweepInterval <= 0 {
+		return fmt.Errorf("RESERVATION_SWEEP_INTERVAL must be positive")
+	}
+
+	if c.OutboxPollInterval <= 0 {
+		return fmt.Errorf("OUTBOX_POLL_INTERVAL must be positive")
+	}
+
+	if c.OutboxMaxAttempts <= 0 {
+		return fmt.Errorf("OUTBOX_MAX_ATTEMPTS must be positive")
+	}
+
+	switch c.EventBus {
+	case "memory":
+	case "nats":
+		if c.NatsURL == "" {
+			return fmt.Errorf("NATS_URL is required when EVENT_BUS is nats")
+		}
+		// NATS is there for more than one instance, and each would reject
+		// the others' cursors if they signed them with keys of their own
+		if c.CursorSecret == "" {
+			return fmt.Errorf("CURSOR_SECRET is required when EVENT_BUS is nats")
+		}
+	default:
+		return fmt.Errorf("EVENT_BUS must be one of memory, nats")
+	}
+
+	if c.EventSource == "" {
+		return fmt.Errorf("EVENT_SOURCE is required")
+	}
+
+	if c.EventStreamReplaySize <= 0 {
+		return fmt.Errorf("EVENT_STREAM_REPLAY_SIZE must be positive")
+	}
+
+	if c.EventStreamHeartbeat <= 0 {
+		return fmt.Errorf("EVENT_STREAM_HEARTBEAT must be positive")
+	}
+
+	if c.WebhookWorkers <= 0 {
+		return fmt.Errorf("WEBHOOK_WORKERS must be positive")
+	}
+
+	if c.WebhookPollInterval <= 0 {
+		return fmt.Errorf("WEBHOOK_POLL_INTERVAL must be positive")
+	}
+
+	if c.WebhookMaxAttempts <= 0 {
+		return fmt.Errorf("WEBHOOK_MAX_ATTEMPTS must be positive")
+	}
+
+	if c.WebhookTimeout <= 0 {
+		return fmt.Errorf("WEBHOOK_TIMEOUT must be positive")
+	}
+
 	return nil
 }
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	defaults, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("the defaults must be valid: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(c *Config)
+		// want is part of the error, or empty when the config is valid
+		want string
+	}{
+		{"no MongoDB database name", func(c *Config) { c.MongoDBName = "" }, ""},
+		{"memory products without MongoDB", func(c *Config) { c.ProductBackend = "memory"; c.MongoHost = "" }, ""},
+		{"unknown product backend", func(c *Config) { c.ProductBackend = "mysql" }, "PRODUCT_BACKEND"},
+		{"sqlite without a path", func(c *Config) { c.ProductBackend = "sqlite"; c.SQLitePath = "" }, "SQLITE_PATH"},
+		{"postgres without a database", func(c *Config) { c.ProductBackend = "postgres"; c.DBName = "" }, "DB_NAME"},
+		{"unknown cache backend", func(c *Config) { c.CacheBackend = "memcached" }, "CACHE_BACKEND"},
+		{"redis without a host", func(c *Config) { c.CacheBackend = "redis"; c.RedisHost = "" }, "REDIS_HOST"},
+		{"memory cache without redis", func(c *Config) { c.CacheBackend = "memory"; c.RedisHost = "" }, ""},
+		{"tiered without a local TTL", func(c *Config) { c.CacheBackend = "tiered"; c.CacheLocalTTL = 0 }, "CACHE_LOCAL_TTL"},
+		{"unbounded local cache", func(c *Config) { c.CacheLocalMaxEntries = 0 }, ""},
+		{"negative local cache size", func(c *Config) { c.CacheLocalMaxEntries = -1 }, "CACHE_LOCAL_MAX_ENTRIES"},
+		{"zero breaker threshold", func(c *Config) { c.CacheBreakerThreshold = 0 }, "CACHE_BREAKER_THRESHOLD"},
+		{"zero breaker retry interval", func(c *Config) { c.CacheBreakerRetryInterval = 0 }, "CACHE_BREAKER_RETRY_INTERVAL"},
+		{"single instance without a cursor secret", func(c *Config) { c.CursorSecret = "" }, ""},
+		{"nats without a cursor secret", func(c *Config) { c.EventBus = "nats"; c.CursorSecret = "" }, "CURSOR_SECRET"},
+		{"nats with a cursor secret", func(c *Config) { c.EventBus = "nats"; c.CursorSecret = "secret" }, ""},
+		{"zero stream replay size", func(c *Config) { c.EventStreamReplaySize = 0 }, "EVENT_STREAM_REPLAY_SIZE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := *defaults
+			tt.change(&c)
+
+			err := c.Validate()
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("Validate() = %v, want no error", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("Validate() = %v, want an error about %s", err, tt.want)
+			}
+		})
+	}
+}
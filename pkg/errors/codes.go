@@ -12,4 +12,6 @@ const (
 	ECACHE        = "ECACHE"        // Cache-related errors
 	EVALIDATION   = "EVALIDATION"   // Domain validation errors
 	EREPOSITORY   = "EREPOSITORY"   // Repository operation errors
+	EPRECONDITION = "EPRECONDITION" // Conditional request preconditions not met
+	EUNSUPPORTED  = "EUNSUPPORTED"  // Request body in a media type that isn't accepted
 )
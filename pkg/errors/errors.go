@@ -1,6 +1,7 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
 	"strings"
 )
@@ -9,6 +10,7 @@ type AppError struct {
 	Err     error
 	Message string
 	Code    string
+	Fields  map[string]string
 }
 
 // Implement the error interface
@@ -33,6 +35,11 @@ func (e *AppError) Error() string {
 	return b.String()
 }
 
+// Unwrap exposes the wrapped error so errors.Is and errors.As can walk the chain.
+func (e *AppError) Unwrap() error {
+	return e.Err
+}
+
 func StandardError(code string, err error) *AppError {
 	var msg string
 	switch code {
@@ -58,6 +65,10 @@ func StandardError(code string, err error) *AppError {
 		msg = MsgValidationFailed
 	case EREPOSITORY:
 		msg = MsgRepositoryError
+	case EPRECONDITION:
+		msg = MsgPreconditionFailed
+	case EUNSUPPORTED:
+		msg = MsgUnsupportedMedia
 	default:
 		msg = MsgInternalError
 	}
@@ -68,3 +79,26 @@ func StandardError(code string, err error) *AppError {
 		Err:     err,
 	}
 }
+
+// FieldError reports field-level validation failures keyed by field name.
+func FieldError(err error, fields map[string]string) *AppError {
+	appErr := StandardError(EVALIDATION, err)
+	appErr.Fields = fields
+	return appErr
+}
+
+// RootAppError returns the innermost AppError in err's chain. Repositories
+// produce the most specific code and command handlers re-wrap it, so the
+// deepest one is the one that describes what actually went wrong.
+func RootAppError(err error) (*AppError, bool) {
+	var root *AppError
+	for err != nil {
+		var appErr *AppError
+		if !stderrors.As(err, &appErr) {
+			break
+		}
+		root = appErr
+		err = appErr.Err
+	}
+	return root, root != nil
+}
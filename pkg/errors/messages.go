@@ -1,16 +1,18 @@
 package errors
 
 const (
-	MsgNotFound         = "Resource not found"
-	MsgInvalidInput     = "Invalid input provided"
-	MsgAlreadyExists    = "Resource already exists"
-	MsgInternalError    = "Internal server error occurred"
-	MsgUnauthorized     = "Unauthorized access"
-	MsgForbidden        = "Access forbidden"
-	MsgBadRequest       = "Bad request"
-	MsgTimeout          = "Operation timed out"
-	MsgCacheError       = "Cache operation failed"
-	MsgValidationFailed = "Validation failed"
-	MsgRepositoryError  = "Repository operation failed"
-	MsgInvalidOperation = "Invalid operation"
+	MsgNotFound           = "Resource not found"
+	MsgInvalidInput       = "Invalid input provided"
+	MsgAlreadyExists      = "Resource already exists"
+	MsgInternalError      = "Internal server error occurred"
+	MsgUnauthorized       = "Unauthorized access"
+	MsgForbidden          = "Access forbidden"
+	MsgBadRequest         = "Bad request"
+	MsgTimeout            = "Operation timed out"
+	MsgCacheError         = "Cache operation failed"
+	MsgValidationFailed   = "Validation failed"
+	MsgRepositoryError    = "Repository operation failed"
+	MsgInvalidOperation   = "Invalid operation"
+	MsgPreconditionFailed = "Precondition failed"
+	MsgUnsupportedMedia   = "Unsupported media type"
 )